@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"mini-sirus/internal/adapter/notification"
 	"mini-sirus/internal/adapter/observer"
 	"mini-sirus/internal/adapter/repository/memory"
 	"mini-sirus/internal/adapter/rule_engine"
+	"mini-sirus/internal/adapter/rule_engine/sandbox"
 	"mini-sirus/internal/infrastructure/config"
 	infrastructure "mini-sirus/internal/infrastructure/lock"
 	"mini-sirus/internal/infrastructure/logger"
 	"mini-sirus/internal/interface/http/handler"
 	"mini-sirus/internal/interface/http/router"
+	"mini-sirus/internal/usecase/activity"
+	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
 	"mini-sirus/internal/usecase/task"
 	"net/http"
 )
@@ -24,37 +29,108 @@ func main() {
 
 	// 初始化仓储层
 	taskRepo := memory.NewTaskRepositoryMemory()
+	taskDefRepo := memory.NewTaskDefinitionRepositoryMemory()
 	taskDetailRepo := memory.NewTaskDetailRepositoryMemory()
+	taskPeriodRepo := memory.NewTaskPeriodRepositoryMemory()
+	activityRepo := memory.NewActivityRepositoryMemory()
+	rewardRepo := memory.NewRewardRepositoryMemory()
+	rewardLedgerRepo := memory.NewRewardLedgerRepositoryMemory()
+	budgetRepo := memory.NewRewardBudgetRepositoryMemory()
+	traceRepo := memory.NewExpressionTraceRepositoryMemory()
 
 	// 初始化适配器层
-	ruleEngine := rule_engine.NewGovaluateAdapter()
+	limits := sandbox.DefaultLimits()
+	limits.MaxSteps = cfg.RuleEngine.MaxSteps
+	limits.Timeout = cfg.RuleEngine.EvalTimeout
+	ruleEngine, err := rule_engine.New(cfg.RuleEngine.Engine, cfg.RuleEngine.ExpressionCacheSize, limits)
+	if err != nil {
+		log.Error("Create rule engine failed", "error", err)
+		panic(err)
+	}
 	observerRegistry := observer.NewTaskObserverRegistry()
-	memLock := infrastructure.NewMemoryLock()
-	distributedLock := infrastructure.NewDistributedLockAdapter(memLock)
+	activityObserverRegistry := observer.NewActivityObserverRegistry()
+	distributedLock, err := infrastructure.NewDistributedLock(cfg.Lock.Backend, infrastructure.RedisLockOptions{
+		Addr:         cfg.Lock.RedisAddr,
+		Password:     cfg.Lock.RedisPassword,
+		DB:           cfg.Lock.RedisDB,
+		DialTimeout:  cfg.Lock.DialTimeout,
+		IOTimeout:    cfg.Lock.IOTimeout,
+		PollInterval: cfg.Lock.PollInterval,
+	})
+	if err != nil {
+		log.Error("Create distributed lock failed", "error", err)
+		panic(err)
+	}
 	reachAdapter := notification.NewReachAdapter()
 	riskCheckService := memory.NewRiskCheckServiceMemory()
+	taskModeRegistry := dto.NewDefaultTaskModeRegistry()
+	functionRegistry := function.NewBuiltinRegistry()
 
 	// 注册观察者（仅注册适合异步执行的观察者）
 	// 风控服务不应该作为观察者，而应该在用例层同步执行
 	checkinObserver := observer.NewCheckinReachObserver(reachAdapter)
 	observerRegistry.Register(checkinObserver)
+	unlockObserver := observer.NewTaskUnlockReachObserver(reachAdapter)
+	observerRegistry.Register(unlockObserver)
+
+	// 用户任务锁竞争策略
+	lockPolicy, err := task.ParseLockPolicy(cfg.Task.LockPolicy)
+	if err != nil {
+		log.Error("Invalid lock policy", "error", err)
+		panic(err)
+	}
+	lockContention := task.LockContention{
+		Policy:      lockPolicy,
+		TTL:         cfg.Task.LockTimeout,
+		WaitTimeout: cfg.Task.LockWaitTimeout,
+		MaxRetry:    cfg.Task.MaxRetry,
+		Backoff:     cfg.Task.LockRetryBackoff,
+		QueueSize:   cfg.Task.LockQueueSize,
+	}
 
 	// 初始化用例层
 	// 风控服务作为依赖注入到 TriggerTaskUseCase
 	triggerTaskUC := task.NewTriggerTaskUseCase(
 		taskRepo,
+		taskDefRepo,
 		taskDetailRepo,
+		taskPeriodRepo,
+		activityRepo,
+		rewardRepo,
+		rewardLedgerRepo,
+		budgetRepo,
+		traceRepo,
 		ruleEngine,
+		functionRegistry,
 		observerRegistry,
 		distributedLock,
 		riskCheckService, // 风控服务作为依赖注入，在任务完成前同步执行
+		cfg.Task.TaskExpireDays,
+		cfg.RuleEngine.TraceSampleRate,
+		lockContention,
 	)
-	createTaskUC := task.NewCreateTaskUseCase(taskRepo)
-	queryTaskUC := task.NewQueryTaskUseCase(taskRepo)
+	createTaskUC := task.NewCreateTaskUseCase(taskRepo, taskDefRepo, functionRegistry.Clock())
+	queryTaskUC := task.NewQueryTaskUseCase(taskRepo, taskDefRepo, taskPeriodRepo, functionRegistry.Clock())
+	queryRewardUC := task.NewQueryRewardUseCase(rewardLedgerRepo)
+	queryBudgetUC := task.NewQueryBudgetUseCase(activityRepo, taskDefRepo, budgetRepo)
+	createActivityUC := activity.NewCreateActivityUseCase(activityRepo)
+	updateActivityUC := activity.NewUpdateActivityUseCase(activityRepo)
+	activityLifecycleUC := activity.NewActivityLifecycleUseCase(activityRepo, activityObserverRegistry, functionRegistry.Clock())
+	queryActivityUC := activity.NewQueryActivityUseCase(activityRepo, taskRepo, taskDefRepo)
+	manageTaskDefUC := task.NewManageTaskDefinitionUseCase(taskRepo, taskDefRepo, activityRepo, ruleEngine, functionRegistry, taskModeRegistry)
+	dryRunExpressionUC := task.NewDryRunExpressionUseCase(ruleEngine, functionRegistry, taskModeRegistry)
+	listFunctionUC := task.NewListFunctionUseCase(functionRegistry, taskModeRegistry)
+	queryTraceUC := task.NewQueryTraceUseCase(traceRepo)
+	activityScheduler := activity.NewActivityScheduler(activityRepo, activityObserverRegistry, cfg.Activity.SchedulerInterval, functionRegistry.Clock())
+
+	// 启动活动状态调度器
+	go activityScheduler.Run(context.Background())
 
 	// 初始化接口层
-	taskHandler := handler.NewTaskHandler(triggerTaskUC, createTaskUC, queryTaskUC)
-	r := router.NewRouter(taskHandler)
+	taskHandler := handler.NewTaskHandler(triggerTaskUC, createTaskUC, queryTaskUC, taskModeRegistry)
+	rewardHandler := handler.NewRewardHandler(queryRewardUC, queryBudgetUC)
+	adminHandler := handler.NewAdminHandler(createActivityUC, updateActivityUC, activityLifecycleUC, queryActivityUC, manageTaskDefUC, dryRunExpressionUC, queryTraceUC, listFunctionUC)
+	r := router.NewRouter(taskHandler, rewardHandler, adminHandler)
 
 	// 启动 HTTP 服务器
 	addr := fmt.Sprintf(":%d", cfg.App.Port)
@@ -8,12 +8,16 @@ import (
 	"mini-sirus/internal/adapter/observer"
 	"mini-sirus/internal/adapter/repository/memory"
 	"mini-sirus/internal/adapter/rule_engine"
+	"mini-sirus/internal/adapter/rule_engine/sandbox"
 	"mini-sirus/internal/domain/entity"
 	"mini-sirus/internal/domain/valueobject"
 	"mini-sirus/internal/infrastructure/config"
 	infrastructure "mini-sirus/internal/infrastructure/lock"
 	"mini-sirus/internal/infrastructure/logger"
+	"mini-sirus/internal/usecase/activity"
 	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
+	"mini-sirus/internal/usecase/port/output"
 	"mini-sirus/internal/usecase/task"
 	"time"
 )
@@ -21,78 +25,201 @@ import (
 // Container 依赖注入容器
 type Container struct {
 	// Repositories
-	TaskRepo       *memory.TaskRepositoryMemory
-	TaskDetailRepo *memory.TaskDetailRepositoryMemory
-	ActivityRepo   *memory.ActivityRepositoryMemory
+	TaskRepo         *memory.TaskRepositoryMemory
+	TaskDefRepo      *memory.TaskDefinitionRepositoryMemory
+	TaskDetailRepo   *memory.TaskDetailRepositoryMemory
+	TaskPeriodRepo   *memory.TaskPeriodRepositoryMemory
+	ActivityRepo     *memory.ActivityRepositoryMemory
+	CheckpointRepo   *memory.BatchCheckpointStoreMemory
+	RewardRepo       *memory.RewardRepositoryMemory
+	RewardLedgerRepo *memory.RewardLedgerRepositoryMemory
+	BudgetRepo       *memory.RewardBudgetRepositoryMemory
+	TraceRepo        *memory.ExpressionTraceRepositoryMemory
 
 	// Adapters
-	RuleEngine       *rule_engine.GovaluateAdapter
-	ObserverRegistry *observer.TaskObserverRegistry
-	DistributedLock  *infrastructure.DistributedLockAdapter
-	ReachAdapter     *notification.ReachAdapter
-	RiskCheckService *memory.RiskCheckServiceMemory
+	RuleEngine               output.RuleEngine
+	ObserverRegistry         *observer.TaskObserverRegistry
+	ActivityObserverRegistry *observer.ActivityObserverRegistry
+	DistributedLock          output.DistributedLock
+	ReachAdapter             *notification.ReachAdapter
+	RiskCheckService         *memory.RiskCheckServiceMemory
+	TaskModeRegistry         *dto.TaskModeRegistry
+	FunctionRegistry         *function.Registry
 
 	// Use Cases
-	TriggerTaskUC *task.TriggerTaskUseCase
-	CreateTaskUC  *task.CreateTaskUseCase
-	QueryTaskUC   *task.QueryTaskUseCase
+	TriggerTaskUC       *task.TriggerTaskUseCase
+	CreateTaskUC        *task.CreateTaskUseCase
+	QueryTaskUC         *task.QueryTaskUseCase
+	BatchCreateUC       *task.BatchCreateTaskUseCase
+	QueryRewardUC       *task.QueryRewardUseCase
+	QueryBudgetUC       *task.QueryBudgetUseCase
+	CreateActivityUC    *activity.CreateActivityUseCase
+	UpdateActivityUC    *activity.UpdateActivityUseCase
+	ActivityLifecycleUC *activity.ActivityLifecycleUseCase
+	QueryActivityUC     *activity.QueryActivityUseCase
+	ManageTaskDefUC     *task.ManageTaskDefinitionUseCase
+	DryRunExpressionUC  *task.DryRunExpressionUseCase
+	QueryTraceUC        *task.QueryTraceUseCase
+	ListFunctionUC      *task.ListFunctionUseCase
+	ActivityScheduler   *activity.ActivityScheduler
 
 	// Infrastructure
+	Clock  function.Clock
 	Config *config.Config
 	Logger logger.Logger
 }
 
-// NewContainer 创建依赖注入容器
+// NewContainer 创建依赖注入容器（使用默认配置）
 func NewContainer() *Container {
-	// 配置和日志
-	cfg := config.NewDefaultConfig()
+	return NewContainerWithConfig(config.NewDefaultConfig())
+}
+
+// NewContainerWithConfig 使用指定配置创建依赖注入容器
+func NewContainerWithConfig(cfg *config.Config) *Container {
+	return NewContainerWithClock(cfg, function.SystemClock{})
+}
+
+// NewContainerWithClock 使用指定配置和时钟创建依赖注入容器
+// 时间类表达式函数、周期任务和连续打卡任务都按该时钟判断当前时间
+func NewContainerWithClock(cfg *config.Config, clock function.Clock) *Container {
+	// 日志
 	log := logger.NewSimpleLogger("mini-sirus")
 
 	// 仓储层
 	taskRepo := memory.NewTaskRepositoryMemory()
+	taskDefRepo := memory.NewTaskDefinitionRepositoryMemory()
 	taskDetailRepo := memory.NewTaskDetailRepositoryMemory()
+	taskPeriodRepo := memory.NewTaskPeriodRepositoryMemory()
 	activityRepo := memory.NewActivityRepositoryMemory()
+	rewardRepo := memory.NewRewardRepositoryMemory()
+	rewardLedgerRepo := memory.NewRewardLedgerRepositoryMemory()
+	budgetRepo := memory.NewRewardBudgetRepositoryMemory()
+	traceRepo := memory.NewExpressionTraceRepositoryMemory()
+	checkpointRepo := memory.NewBatchCheckpointStoreMemory()
 
 	// 适配器层
-	ruleEngine := rule_engine.NewGovaluateAdapter()
+	limits := sandbox.DefaultLimits()
+	limits.MaxSteps = cfg.RuleEngine.MaxSteps
+	limits.Timeout = cfg.RuleEngine.EvalTimeout
+	ruleEngine, err := rule_engine.New(cfg.RuleEngine.Engine, cfg.RuleEngine.ExpressionCacheSize, limits)
+	if err != nil {
+		log.Error("Create rule engine failed", "error", err)
+		panic(err)
+	}
 	observerRegistry := observer.NewTaskObserverRegistry()
-	memLock := infrastructure.NewMemoryLock()
-	distributedLock := infrastructure.NewDistributedLockAdapter(memLock)
+	activityObserverRegistry := observer.NewActivityObserverRegistry()
+	distributedLock, err := infrastructure.NewDistributedLock(cfg.Lock.Backend, infrastructure.RedisLockOptions{
+		Addr:         cfg.Lock.RedisAddr,
+		Password:     cfg.Lock.RedisPassword,
+		DB:           cfg.Lock.RedisDB,
+		DialTimeout:  cfg.Lock.DialTimeout,
+		IOTimeout:    cfg.Lock.IOTimeout,
+		PollInterval: cfg.Lock.PollInterval,
+	})
+	if err != nil {
+		log.Error("Create distributed lock failed", "error", err)
+		panic(err)
+	}
 	reachAdapter := notification.NewReachAdapter()
 	riskCheckService := memory.NewRiskCheckServiceMemory()
+	taskModeRegistry := dto.NewDefaultTaskModeRegistry()
+	functionRegistry := function.NewBuiltinRegistryWithClock(clock)
 
 	// 注册观察者（仅注册适合异步执行的观察者）
 	// 风控服务不应该作为观察者，而应该在用例层同步执行
 	checkinObserver := observer.NewCheckinReachObserver(reachAdapter)
 	observerRegistry.Register(checkinObserver)
+	unlockObserver := observer.NewTaskUnlockReachObserver(reachAdapter)
+	observerRegistry.Register(unlockObserver)
+
+	// 用户任务锁竞争策略
+	lockPolicy, err := task.ParseLockPolicy(cfg.Task.LockPolicy)
+	if err != nil {
+		log.Error("Invalid lock policy", "error", err)
+		panic(err)
+	}
+	lockContention := task.LockContention{
+		Policy:      lockPolicy,
+		TTL:         cfg.Task.LockTimeout,
+		WaitTimeout: cfg.Task.LockWaitTimeout,
+		MaxRetry:    cfg.Task.MaxRetry,
+		Backoff:     cfg.Task.LockRetryBackoff,
+		QueueSize:   cfg.Task.LockQueueSize,
+	}
 
 	// 用例层
 	// 风控服务作为依赖注入到 TriggerTaskUseCase
 	triggerTaskUC := task.NewTriggerTaskUseCase(
 		taskRepo,
+		taskDefRepo,
 		taskDetailRepo,
+		taskPeriodRepo,
+		activityRepo,
+		rewardRepo,
+		rewardLedgerRepo,
+		budgetRepo,
+		traceRepo,
 		ruleEngine,
+		functionRegistry,
 		observerRegistry,
 		distributedLock,
 		riskCheckService, // 风控服务作为依赖注入，在任务完成前同步执行
+		cfg.Task.TaskExpireDays,
+		cfg.RuleEngine.TraceSampleRate,
+		lockContention,
 	)
-	createTaskUC := task.NewCreateTaskUseCase(taskRepo)
-	queryTaskUC := task.NewQueryTaskUseCase(taskRepo)
+	createTaskUC := task.NewCreateTaskUseCase(taskRepo, taskDefRepo, clock)
+	queryTaskUC := task.NewQueryTaskUseCase(taskRepo, taskDefRepo, taskPeriodRepo, clock)
+	queryRewardUC := task.NewQueryRewardUseCase(rewardLedgerRepo)
+	queryBudgetUC := task.NewQueryBudgetUseCase(activityRepo, taskDefRepo, budgetRepo)
+	createActivityUC := activity.NewCreateActivityUseCase(activityRepo)
+	updateActivityUC := activity.NewUpdateActivityUseCase(activityRepo)
+	activityLifecycleUC := activity.NewActivityLifecycleUseCase(activityRepo, activityObserverRegistry, clock)
+	queryActivityUC := activity.NewQueryActivityUseCase(activityRepo, taskRepo, taskDefRepo)
+	manageTaskDefUC := task.NewManageTaskDefinitionUseCase(taskRepo, taskDefRepo, activityRepo, ruleEngine, functionRegistry, taskModeRegistry)
+	dryRunExpressionUC := task.NewDryRunExpressionUseCase(ruleEngine, functionRegistry, taskModeRegistry)
+	listFunctionUC := task.NewListFunctionUseCase(functionRegistry, taskModeRegistry)
+	queryTraceUC := task.NewQueryTraceUseCase(traceRepo)
+	activityScheduler := activity.NewActivityScheduler(activityRepo, activityObserverRegistry, cfg.Activity.SchedulerInterval, clock)
+	batchCreateUC := task.NewBatchCreateTaskUseCase(taskRepo, taskDefRepo, checkpointRepo, clock)
 
 	return &Container{
-		TaskRepo:         taskRepo,
-		TaskDetailRepo:   taskDetailRepo,
-		ActivityRepo:     activityRepo,
-		RuleEngine:       ruleEngine,
-		ObserverRegistry: observerRegistry,
-		DistributedLock:  distributedLock,
-		ReachAdapter:     reachAdapter,
-		RiskCheckService: riskCheckService,
-		TriggerTaskUC:    triggerTaskUC,
-		CreateTaskUC:     createTaskUC,
-		QueryTaskUC:      queryTaskUC,
-		Config:           cfg,
-		Logger:           log,
+		TaskRepo:                 taskRepo,
+		TaskDefRepo:              taskDefRepo,
+		TaskDetailRepo:           taskDetailRepo,
+		TaskPeriodRepo:           taskPeriodRepo,
+		ActivityRepo:             activityRepo,
+		CheckpointRepo:           checkpointRepo,
+		RewardRepo:               rewardRepo,
+		RewardLedgerRepo:         rewardLedgerRepo,
+		BudgetRepo:               budgetRepo,
+		TraceRepo:                traceRepo,
+		RuleEngine:               ruleEngine,
+		ObserverRegistry:         observerRegistry,
+		ActivityObserverRegistry: activityObserverRegistry,
+		DistributedLock:          distributedLock,
+		ReachAdapter:             reachAdapter,
+		RiskCheckService:         riskCheckService,
+		TaskModeRegistry:         taskModeRegistry,
+		FunctionRegistry:         functionRegistry,
+		TriggerTaskUC:            triggerTaskUC,
+		CreateTaskUC:             createTaskUC,
+		QueryTaskUC:              queryTaskUC,
+		BatchCreateUC:            batchCreateUC,
+		QueryRewardUC:            queryRewardUC,
+		QueryBudgetUC:            queryBudgetUC,
+		CreateActivityUC:         createActivityUC,
+		UpdateActivityUC:         updateActivityUC,
+		ActivityLifecycleUC:      activityLifecycleUC,
+		QueryActivityUC:          queryActivityUC,
+		ManageTaskDefUC:          manageTaskDefUC,
+		DryRunExpressionUC:       dryRunExpressionUC,
+		QueryTraceUC:             queryTraceUC,
+		ListFunctionUC:           listFunctionUC,
+		ActivityScheduler:        activityScheduler,
+		Clock:                    clock,
+		Config:                   cfg,
+		Logger:                   log,
 	}
 }
 
@@ -107,13 +234,25 @@ func main() {
 
 	// 示例1: 创建任务
 	fmt.Println("--- Example 1: Create Task ---")
-	createInput := dto.CreateTaskInput{
+	publishDef := &entity.ActTaskDefinition{
 		ActivityID:   1,
-		TaskID:       100,
-		UserID:       12345,
-		Target:       3,
+		Name:         "发布3篇带话题的优质内容",
 		TaskType:     valueobject.TaskTypePublishTimes,
 		TaskCondExpr: "WITH_ANY_TOPIC(tag_ids, required_tag_ids) && LIKE_COUNT_GTE(like_count, 10) && IS_AUDITED(is_audited)",
+		Parameters: valueobject.TaskParameters{
+			{Name: "required_tag_ids", Type: valueobject.TaskParameterIDList, Value: []uint64{1001, 1002}},
+		},
+		Target:      3,
+		RewardValue: 1,
+	}
+	if err := container.TaskDefRepo.Create(ctx, publishDef); err != nil {
+		log.Fatalf("Create task definition failed: %v", err)
+	}
+
+	createInput := dto.CreateTaskInput{
+		ActivityID: publishDef.ActivityID,
+		TaskID:     publishDef.ID,
+		UserID:     12345,
 	}
 
 	taskOutput, err := container.CreateTaskUC.Execute(ctx, createInput)
@@ -145,13 +284,22 @@ func main() {
 
 	// 示例3: 创建签到任务
 	fmt.Println("--- Example 3: Create Checkin Task ---")
-	checkinCreateInput := dto.CreateTaskInput{
+	checkinDef := &entity.ActTaskDefinition{
 		ActivityID:   2,
-		TaskID:       200,
-		UserID:       12345,
-		Target:       1,
+		Name:         "每日签到",
 		TaskType:     valueobject.TaskTypeCheckin,
-		TaskCondExpr: "IS_TODAY()",
+		TaskCondExpr: "IS_TODAY(date, timezone)",
+		Target:       1,
+		RewardValue:  1,
+	}
+	if err := container.TaskDefRepo.Create(ctx, checkinDef); err != nil {
+		log.Fatalf("Create checkin task definition failed: %v", err)
+	}
+
+	checkinCreateInput := dto.CreateTaskInput{
+		ActivityID: checkinDef.ActivityID,
+		TaskID:     checkinDef.ID,
+		UserID:     12345,
 	}
 
 	checkinTaskOutput, err := container.CreateTaskUC.Execute(ctx, checkinCreateInput)
@@ -164,7 +312,7 @@ func main() {
 	fmt.Println("--- Example 4: Trigger Checkin Task ---")
 	checkinEvent := &dto.CheckinEventDTO{
 		UserID: 12345,
-		Date:   "2024-01-01",
+		Date:   time.Now().Format("2006-01-02"),
 	}
 
 	checkinTriggerInput := dto.TriggerTaskInput{
@@ -202,7 +350,7 @@ func main() {
 		log.Printf("Create activity failed: %v", err)
 	} else {
 		fmt.Printf("Activity created: ID=%d, Name=%s, IsActive=%v\n",
-			activity.ID, activity.Name, activity.IsActive())
+			activity.ID, activity.Name, activity.IsActive(container.Clock.Now()))
 	}
 
 	fmt.Println("\n=== Example execution completed ===")
@@ -217,13 +365,27 @@ func main() {
 func testRiskControl(ctx context.Context, container *Container) {
 	// 创建测试用户的签到任务
 	fmt.Println("创建测试用户(999)的签到任务...")
+	newCheckinDef := func() (*entity.ActTaskDefinition, error) {
+		def := &entity.ActTaskDefinition{
+			ActivityID:   3,
+			Name:         "风控测试签到",
+			TaskType:     valueobject.TaskTypeCheckin,
+			TaskCondExpr: "IS_TODAY(date, timezone)",
+			Target:       1,
+			RewardValue:  1,
+		}
+		return def, container.TaskDefRepo.Create(ctx, def)
+	}
+
+	def, err := newCheckinDef()
+	if err != nil {
+		log.Printf("创建测试任务定义失败: %v", err)
+		return
+	}
 	testCreateInput := dto.CreateTaskInput{
-		ActivityID:   3,
-		TaskID:       300,
-		UserID:       999,
-		Target:       1,
-		TaskType:     valueobject.TaskTypeCheckin,
-		TaskCondExpr: "IS_TODAY()",
+		ActivityID: def.ActivityID,
+		TaskID:     def.ID,
+		UserID:     999,
 	}
 
 	testTask, err := container.CreateTaskUC.Execute(ctx, testCreateInput)
@@ -255,11 +417,14 @@ func testRiskControl(ctx context.Context, container *Container) {
 	fmt.Println("\n测试2: 模拟短时间内频繁签到...")
 	for i := 0; i < 12; i++ {
 		// 重新创建任务（因为之前的已完成）
-		testCreateInput.TaskID = int64(300 + i + 1)
-		_, err := container.CreateTaskUC.Execute(ctx, testCreateInput)
+		def, err := newCheckinDef()
 		if err != nil {
 			continue
 		}
+		testCreateInput.TaskID = def.ID
+		if _, err := container.CreateTaskUC.Execute(ctx, testCreateInput); err != nil {
+			continue
+		}
 
 		checkinEvent := &dto.CheckinEventDTO{
 			UserID: 999,
@@ -293,9 +458,13 @@ func testRiskControl(ctx context.Context, container *Container) {
 	// 测试4: 尝试黑名单用户签到
 	if isBlacklisted {
 		fmt.Println("\n测试4: 黑名单用户尝试签到...")
-		testCreateInput.TaskID = 400
-		_, err := container.CreateTaskUC.Execute(ctx, testCreateInput)
+		def, err := newCheckinDef()
 		if err != nil {
+			log.Printf("创建任务定义失败: %v", err)
+			return
+		}
+		testCreateInput.TaskID = def.ID
+		if _, err := container.CreateTaskUC.Execute(ctx, testCreateInput); err != nil {
 			log.Printf("创建任务失败: %v", err)
 			return
 		}
@@ -317,4 +486,3 @@ func testRiskControl(ctx context.Context, container *Container) {
 
 	fmt.Println("\n风控测试完成！")
 }
-
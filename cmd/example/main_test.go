@@ -1,10 +1,34 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/adapter/repository/memory"
+	"mini-sirus/internal/adapter/rule_engine"
 	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/event"
+	"mini-sirus/internal/domain/repository"
 	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/infrastructure/config"
+	infrastructure "mini-sirus/internal/infrastructure/lock"
+	"mini-sirus/internal/infrastructure/resp/resptest"
+	"mini-sirus/internal/interface/http/handler"
+	"mini-sirus/internal/interface/http/router"
+	"mini-sirus/internal/usecase/activity"
 	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
+	"mini-sirus/internal/usecase/port/output"
+	"mini-sirus/internal/usecase/task"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,30 +36,79 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+const (
+	publishCondExpr = "WITH_ANY_TOPIC(tag_ids, required_tag_ids) && LIKE_COUNT_GTE(like_count, 10) && IS_AUDITED(is_audited)"
+	checkinCondExpr = "IS_TODAY(date, timezone)"
+)
+
+// publishParams 发布任务要求的话题，publishCondExpr 通过 required_tag_ids 引用
+var publishParams = []dto.TaskParameterDTO{
+	{Name: "required_tag_ids", Type: valueobject.TaskParameterIDList, Value: []interface{}{1001.0, 1002.0}},
+}
+
 // setupContainer 为测试创建依赖注入容器
+// 时钟固定在创建时刻，测试中的日期都取自该时钟，不受跨零点影响
 func setupContainer() *Container {
-	return NewContainer()
+	return NewContainerWithClock(config.NewDefaultConfig(), function.NewFixedClock(time.Now()))
+}
+
+// testClock 获取测试容器的固定时钟
+func testClock(container *Container) *function.FixedClock {
+	return container.Clock.(*function.FixedClock)
+}
+
+// today 按测试容器的时钟获取服务器时区下今天的日期
+func today(container *Container) string {
+	return container.Clock.Now().Format("2006-01-02")
+}
+
+// createTaskDefinition 为测试创建任务定义
+func createTaskDefinition(
+	t *testing.T,
+	container *Container,
+	activityID int64,
+	taskType valueobject.TaskType,
+	target int,
+	condExpr string,
+) *entity.ActTaskDefinition {
+	t.Helper()
+
+	def := &entity.ActTaskDefinition{
+		ActivityID:   activityID,
+		Name:         string(taskType),
+		TaskType:     taskType,
+		TaskCondExpr: condExpr,
+		Target:       target,
+		RewardValue:  1,
+	}
+	if taskType == valueobject.TaskTypePublishTimes {
+		def.Parameters = valueobject.TaskParameters{
+			{Name: "required_tag_ids", Type: valueobject.TaskParameterIDList, Value: []uint64{1001, 1002}},
+		}
+	}
+	require.NoError(t, container.TaskDefRepo.Create(context.Background(), def), "创建任务定义不应该失败")
+	return def
 }
 
 func TestCreateTask(t *testing.T) {
 	container := setupContainer()
 	ctx := context.Background()
 
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypePublishTimes, 3, publishCondExpr)
 	createInput := dto.CreateTaskInput{
-		ActivityID:   1,
-		TaskID:       100,
-		UserID:       12345,
-		Target:       3,
-		TaskType:     valueobject.TaskTypePublishTimes,
-		TaskCondExpr: "WITH_ANY_TOPIC(tag_ids, required_tag_ids) && LIKE_COUNT_GTE(like_count, 10) && IS_AUDITED(is_audited)",
+		ActivityID: 1,
+		TaskID:     def.ID,
+		UserID:     12345,
 	}
 
 	taskOutput, err := container.CreateTaskUC.Execute(ctx, createInput)
 
 	require.NoError(t, err, "创建任务不应该失败")
-	assert.Equal(t, int64(100), taskOutput.TaskID)   // TaskID是输入的任务ID，而不是自增的ID
+	assert.Equal(t, def.ID, taskOutput.TaskID)       // TaskID是任务定义ID，而不是自增的ID
 	assert.Equal(t, int64(12345), taskOutput.UserID) // UserID是int64类型
 	assert.NotEmpty(t, taskOutput.Status)
+	assert.Equal(t, 3, taskOutput.Target, "目标应该来自任务定义")
+	assert.Equal(t, publishCondExpr, taskOutput.TaskCondExpr, "条件表达式应该来自任务定义")
 }
 
 func TestTriggerPublishTask(t *testing.T) {
@@ -43,13 +116,11 @@ func TestTriggerPublishTask(t *testing.T) {
 	ctx := context.Background()
 
 	// 先创建任务
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypePublishTimes, 3, publishCondExpr)
 	createInput := dto.CreateTaskInput{
-		ActivityID:   1,
-		TaskID:       100,
-		UserID:       12345,
-		Target:       3,
-		TaskType:     valueobject.TaskTypePublishTimes,
-		TaskCondExpr: "WITH_ANY_TOPIC(tag_ids, required_tag_ids) && LIKE_COUNT_GTE(like_count, 10) && IS_AUDITED(is_audited)",
+		ActivityID: 1,
+		TaskID:     def.ID,
+		UserID:     12345,
 	}
 
 	_, err := container.CreateTaskUC.Execute(ctx, createInput)
@@ -72,25 +143,28 @@ func TestTriggerPublishTask(t *testing.T) {
 
 	err = container.TriggerTaskUC.Execute(ctx, triggerInput)
 	assert.NoError(t, err, "触发发布任务不应该失败")
+
+	tasks, err := container.QueryTaskUC.ExecuteList(ctx, 12345)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, 1, tasks[0].Progress, "发布事件应该推进任务进度")
 }
 
 func TestCreateCheckinTask(t *testing.T) {
 	container := setupContainer()
 	ctx := context.Background()
 
+	def := createTaskDefinition(t, container, 2, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
 	checkinCreateInput := dto.CreateTaskInput{
-		ActivityID:   2,
-		TaskID:       200,
-		UserID:       12345,
-		Target:       1,
-		TaskType:     valueobject.TaskTypeCheckin,
-		TaskCondExpr: "IS_TODAY()",
+		ActivityID: 2,
+		TaskID:     def.ID,
+		UserID:     12345,
 	}
 
 	checkinTaskOutput, err := container.CreateTaskUC.Execute(ctx, checkinCreateInput)
 
 	require.NoError(t, err, "创建签到任务不应该失败")
-	assert.Equal(t, int64(200), checkinTaskOutput.TaskID)
+	assert.Equal(t, def.ID, checkinTaskOutput.TaskID)
 	assert.Equal(t, int64(12345), checkinTaskOutput.UserID)
 }
 
@@ -99,13 +173,11 @@ func TestTriggerCheckinTask(t *testing.T) {
 	ctx := context.Background()
 
 	// 先创建签到任务
+	def := createTaskDefinition(t, container, 2, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
 	checkinCreateInput := dto.CreateTaskInput{
-		ActivityID:   2,
-		TaskID:       200,
-		UserID:       12345,
-		Target:       1,
-		TaskType:     valueobject.TaskTypeCheckin,
-		TaskCondExpr: "IS_TODAY()",
+		ActivityID: 2,
+		TaskID:     def.ID,
+		UserID:     12345,
 	}
 
 	_, err := container.CreateTaskUC.Execute(ctx, checkinCreateInput)
@@ -114,7 +186,7 @@ func TestTriggerCheckinTask(t *testing.T) {
 	// 触发签到事件
 	checkinEvent := &dto.CheckinEventDTO{
 		UserID: 12345,
-		Date:   "2024-01-01",
+		Date:   today(container),
 	}
 
 	checkinTriggerInput := dto.TriggerTaskInput{
@@ -130,23 +202,11 @@ func TestQueryUserTasks(t *testing.T) {
 	ctx := context.Background()
 
 	// 创建多个任务
+	publishDef := createTaskDefinition(t, container, 1, valueobject.TaskTypePublishTimes, 3, publishCondExpr)
+	checkinDef := createTaskDefinition(t, container, 2, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
 	createInputs := []dto.CreateTaskInput{
-		{
-			ActivityID:   1,
-			TaskID:       100,
-			UserID:       12345,
-			Target:       3,
-			TaskType:     valueobject.TaskTypePublishTimes,
-			TaskCondExpr: "WITH_ANY_TOPIC(tag_ids, required_tag_ids) && LIKE_COUNT_GTE(like_count, 10) && IS_AUDITED(is_audited)",
-		},
-		{
-			ActivityID:   2,
-			TaskID:       200,
-			UserID:       12345,
-			Target:       1,
-			TaskType:     valueobject.TaskTypeCheckin,
-			TaskCondExpr: "IS_TODAY()",
-		},
+		{ActivityID: 1, TaskID: publishDef.ID, UserID: 12345},
+		{ActivityID: 2, TaskID: checkinDef.ID, UserID: 12345},
 	}
 
 	for _, input := range createInputs {
@@ -166,22 +226,62 @@ func TestQueryUserTasks(t *testing.T) {
 	}
 }
 
+func TestTaskDefinitionSharedAcrossUsers(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypePublishTimes, 3, publishCondExpr)
+	for _, userID := range []int64{10001, 10002} {
+		_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{
+			ActivityID: 1,
+			TaskID:     def.ID,
+			UserID:     userID,
+		})
+		require.NoError(t, err)
+	}
+
+	// 修改一次任务定义，所有用户的任务都应生效
+	def.Target = 5
+	require.NoError(t, container.TaskDefRepo.Update(ctx, def))
+
+	for _, userID := range []int64{10001, 10002} {
+		tasks, err := container.QueryTaskUC.ExecuteList(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, 5, tasks[0].Target)
+	}
+
+	// 活动不匹配时不允许创建
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{
+		ActivityID: 2,
+		TaskID:     def.ID,
+		UserID:     10003,
+	})
+	assert.Error(t, err, "任务定义不属于该活动时应该创建失败")
+}
+
 func TestActivityManagement(t *testing.T) {
 	container := setupContainer()
 	ctx := context.Background()
 
 	// 使用正确的实体类型
+	clock := testClock(container)
 	activity := &entity.ActActivity{
 		Name:      "Spring Festival Activity",
-		StartTime: time.Now(),
-		EndTime:   time.Now().Add(30 * 24 * time.Hour),
+		StartTime: clock.Now(),
+		EndTime:   clock.Now().Add(30 * 24 * time.Hour),
 		Status:    entity.ActivityStatusActive,
 	}
 
 	err := container.ActivityRepo.Create(ctx, activity)
 	require.NoError(t, err, "创建活动不应该失败")
 	assert.Greater(t, activity.ID, int64(0), "活动ID应该大于0")
-	assert.True(t, activity.IsActive(), "活动应该是激活状态")
+
+	// 活动窗口按注入的时钟判断
+	clock.Advance(time.Minute)
+	assert.True(t, activity.IsActive(clock.Now()), "活动应该是激活状态")
+	clock.Advance(30 * 24 * time.Hour)
+	assert.False(t, activity.IsActive(clock.Now()), "超过结束时间后活动不再激活")
 }
 
 func TestRiskControl_NormalCheckin(t *testing.T) {
@@ -189,23 +289,21 @@ func TestRiskControl_NormalCheckin(t *testing.T) {
 	ctx := context.Background()
 
 	// 创建测试用户的签到任务
+	def := createTaskDefinition(t, container, 3, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
 	testCreateInput := dto.CreateTaskInput{
-		ActivityID:   3,
-		TaskID:       300,
-		UserID:       999,
-		Target:       1,
-		TaskType:     valueobject.TaskTypeCheckin,
-		TaskCondExpr: "IS_TODAY()",
+		ActivityID: 3,
+		TaskID:     def.ID,
+		UserID:     999,
 	}
 
 	testTask, err := container.CreateTaskUC.Execute(ctx, testCreateInput)
 	require.NoError(t, err, "创建测试任务不应该失败")
-	assert.Equal(t, int64(300), testTask.TaskID)
+	assert.Equal(t, def.ID, testTask.TaskID)
 
 	// 正常签到
 	normalCheckin := &dto.CheckinEventDTO{
 		UserID: 999,
-		Date:   time.Now().Format("2006-01-02"),
+		Date:   today(container),
 	}
 	triggerInput := dto.TriggerTaskInput{
 		TaskMode: normalCheckin,
@@ -220,7 +318,6 @@ func TestRiskControl_FrequentCheckin(t *testing.T) {
 	ctx := context.Background()
 
 	userID := int64(888)
-	baseTaskID := int64(400)
 
 	// 模拟短时间内频繁签到
 	maxAttempts := 12
@@ -229,13 +326,11 @@ func TestRiskControl_FrequentCheckin(t *testing.T) {
 
 	for i := 0; i < maxAttempts; i++ {
 		// 为每次签到创建新任务
+		def := createTaskDefinition(t, container, 3, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
 		testCreateInput := dto.CreateTaskInput{
-			ActivityID:   3,
-			TaskID:       baseTaskID + int64(i),
-			UserID:       userID,
-			Target:       1,
-			TaskType:     valueobject.TaskTypeCheckin,
-			TaskCondExpr: "IS_TODAY()",
+			ActivityID: 3,
+			TaskID:     def.ID,
+			UserID:     userID,
 		}
 
 		_, err := container.CreateTaskUC.Execute(ctx, testCreateInput)
@@ -245,7 +340,7 @@ func TestRiskControl_FrequentCheckin(t *testing.T) {
 
 		checkinEvent := &dto.CheckinEventDTO{
 			UserID: userID,
-			Date:   time.Now().Format("2006-01-02"),
+			Date:   today(container),
 		}
 		triggerInput := dto.TriggerTaskInput{
 			TaskMode: checkinEvent,
@@ -275,24 +370,21 @@ func TestRiskControl_BlacklistCheck(t *testing.T) {
 	ctx := context.Background()
 
 	userID := int64(777)
-	baseTaskID := int64(500)
 
 	// 先执行多次操作触发风控
 	for i := 0; i < 15; i++ {
+		def := createTaskDefinition(t, container, 3, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
 		testCreateInput := dto.CreateTaskInput{
-			ActivityID:   3,
-			TaskID:       baseTaskID + int64(i),
-			UserID:       userID,
-			Target:       1,
-			TaskType:     valueobject.TaskTypeCheckin,
-			TaskCondExpr: "IS_TODAY()",
+			ActivityID: 3,
+			TaskID:     def.ID,
+			UserID:     userID,
 		}
 
 		container.CreateTaskUC.Execute(ctx, testCreateInput)
 
 		checkinEvent := &dto.CheckinEventDTO{
 			UserID: userID,
-			Date:   time.Now().Format("2006-01-02"),
+			Date:   today(container),
 		}
 		triggerInput := dto.TriggerTaskInput{
 			TaskMode: checkinEvent,
@@ -310,20 +402,18 @@ func TestRiskControl_BlacklistCheck(t *testing.T) {
 		t.Logf("用户%d已被加入黑名单", userID)
 
 		// 测试黑名单用户无法签到
+		def := createTaskDefinition(t, container, 3, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
 		testCreateInput := dto.CreateTaskInput{
-			ActivityID:   3,
-			TaskID:       600,
-			UserID:       userID,
-			Target:       1,
-			TaskType:     valueobject.TaskTypeCheckin,
-			TaskCondExpr: "IS_TODAY()",
+			ActivityID: 3,
+			TaskID:     def.ID,
+			UserID:     userID,
 		}
 
 		_, err := container.CreateTaskUC.Execute(ctx, testCreateInput)
 		if err == nil {
 			blacklistCheckin := &dto.CheckinEventDTO{
 				UserID: userID,
-				Date:   time.Now().Format("2006-01-02"),
+				Date:   today(container),
 			}
 			triggerInput := dto.TriggerTaskInput{
 				TaskMode: blacklistCheckin,
@@ -345,22 +435,18 @@ func TestRiskControl_BlacklistUser(t *testing.T) {
 
 	// 尝试黑名单用户操作
 	testCreateInput := dto.CreateTaskInput{
-		ActivityID:   3,
-		TaskID:       700,
-		UserID:       userID,
-		Target:       1,
-		TaskType:     valueobject.TaskTypeCheckin,
-		TaskCondExpr: "IS_TODAY()",
+		ActivityID: 3,
+		UserID:     userID,
 	}
 
 	// 先触发多次操作以确保用户被加入黑名单
 	for i := 0; i < 20; i++ {
-		testCreateInput.TaskID = 700 + int64(i)
+		testCreateInput.TaskID = createTaskDefinition(t, container, 3, valueobject.TaskTypeCheckin, 1, checkinCondExpr).ID
 		container.CreateTaskUC.Execute(ctx, testCreateInput)
 
 		checkinEvent := &dto.CheckinEventDTO{
 			UserID: userID,
-			Date:   time.Now().Format("2006-01-02"),
+			Date:   today(container),
 		}
 		triggerInput := dto.TriggerTaskInput{
 			TaskMode: checkinEvent,
@@ -375,13 +461,13 @@ func TestRiskControl_BlacklistUser(t *testing.T) {
 
 	if isBlacklisted {
 		// 黑名单用户尝试新操作
-		testCreateInput.TaskID = 800
+		testCreateInput.TaskID = createTaskDefinition(t, container, 3, valueobject.TaskTypeCheckin, 1, checkinCondExpr).ID
 		_, createErr := container.CreateTaskUC.Execute(ctx, testCreateInput)
 
 		if createErr == nil {
 			blacklistCheckin := &dto.CheckinEventDTO{
 				UserID: userID,
-				Date:   time.Now().Format("2006-01-02"),
+				Date:   today(container),
 			}
 			triggerInput := dto.TriggerTaskInput{
 				TaskMode: blacklistCheckin,
@@ -392,3 +478,2130 @@ func TestRiskControl_BlacklistUser(t *testing.T) {
 		}
 	}
 }
+
+func TestBatchCreateTasks(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	createTaskDefinition(t, container, 10, valueobject.TaskTypePublishTimes, 3, publishCondExpr)
+	checkinDef := createTaskDefinition(t, container, 10, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
+
+	var reports []dto.BatchCreateProgress
+	progress, err := container.BatchCreateUC.Execute(ctx, dto.BatchCreateTaskInput{
+		ActivityID: 10,
+		Source:     dto.UserIDSourceInput{Type: dto.UserIDSourceRange, StartID: 20001, EndID: 20250},
+		ChunkSize:  16,
+		Workers:    4,
+		OnProgress: func(p dto.BatchCreateProgress) { reports = append(reports, p) },
+	})
+	require.NoError(t, err, "批量预创建不应该失败")
+	assert.Equal(t, dto.BatchStatusCompleted, progress.Status)
+	assert.Equal(t, int64(250), progress.Total)
+	assert.Equal(t, int64(250), progress.Processed)
+	assert.Equal(t, int64(500), progress.Created, "每个用户应创建活动下的全部任务")
+	assert.NotEmpty(t, reports, "应该有进度回调")
+
+	tasks, err := container.QueryTaskUC.ExecuteList(ctx, 20125)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+
+	// 已预创建的用户任务不能再单独创建
+	_, err = container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 10, TaskID: checkinDef.ID, UserID: 20125})
+	assert.ErrorIs(t, err, repository.ErrUserTaskExists)
+
+	// 已完成的批次重复执行直接返回
+	again, err := container.BatchCreateUC.Execute(ctx, dto.BatchCreateTaskInput{
+		ActivityID: 10,
+		Source:     dto.UserIDSourceInput{Type: dto.UserIDSourceRange, StartID: 20001, EndID: 20250},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), again.Created)
+}
+
+func TestBatchCreateTasks_ResumeFromCheckpoint(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	createTaskDefinition(t, container, 11, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
+
+	// 模拟上次执行中断在第3个用户之后
+	require.NoError(t, container.CheckpointRepo.Save(ctx, &output.BatchCheckpoint{
+		JobID:      "resume_job",
+		ActivityID: 11,
+		Status:     dto.BatchStatusFailed,
+		Total:      5,
+		Offset:     3,
+		Created:    3,
+		StartedAt:  time.Now(),
+	}))
+
+	progress, err := container.BatchCreateUC.Execute(ctx, dto.BatchCreateTaskInput{
+		JobID:      "resume_job",
+		ActivityID: 11,
+		Source:     dto.UserIDSourceInput{Type: dto.UserIDSourceList, UserIDs: []int64{30001, 30002, 30003, 30004, 30005}},
+		ChunkSize:  1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), progress.Processed)
+	assert.Equal(t, int64(5), progress.Created)
+
+	skipped, err := container.QueryTaskUC.ExecuteList(ctx, 30001)
+	require.NoError(t, err)
+	assert.Empty(t, skipped, "断点之前的用户不应被重复处理")
+
+	resumed, err := container.QueryTaskUC.ExecuteList(ctx, 30005)
+	require.NoError(t, err)
+	assert.Len(t, resumed, 1)
+
+	stored, err := container.BatchCreateUC.GetProgress(ctx, "resume_job")
+	require.NoError(t, err)
+	assert.Equal(t, dto.BatchStatusCompleted, stored.Status)
+}
+
+// failingBatchTaskRepo 批量创建包含指定用户的批次时延迟后失败，其余批次先于其完成
+type failingBatchTaskRepo struct {
+	*memory.TaskRepositoryMemory
+	failUserID int64
+}
+
+func (r *failingBatchTaskRepo) BatchCreate(ctx context.Context, tasks []*entity.ActUserTask) (int, error) {
+	for _, task := range tasks {
+		if task.UserID == r.failUserID {
+			time.Sleep(50 * time.Millisecond)
+			return 0, errors.New("storage unavailable")
+		}
+	}
+	return r.TaskRepositoryMemory.BatchCreate(ctx, tasks)
+}
+
+func TestBatchCreateTasks_ResumeAfterOutOfOrderFailure(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	createTaskDefinition(t, container, 13, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
+	input := dto.BatchCreateTaskInput{
+		JobID:      "out_of_order_job",
+		ActivityID: 13,
+		Source:     dto.UserIDSourceInput{Type: dto.UserIDSourceList, UserIDs: []int64{31001, 31002, 31003, 31004, 31005}},
+		ChunkSize:  1,
+		Workers:    4,
+	}
+
+	// 第一个批次失败时，其后已完成的批次记录在断点中
+	repo := &failingBatchTaskRepo{TaskRepositoryMemory: container.TaskRepo, failUserID: 31001}
+	failing := task.NewBatchCreateTaskUseCase(repo, container.TaskDefRepo, container.CheckpointRepo, container.Clock)
+	progress, err := failing.Execute(ctx, input)
+	assert.Error(t, err)
+	assert.Equal(t, dto.BatchStatusFailed, progress.Status)
+	assert.Equal(t, int64(0), progress.Processed)
+	stored, err := container.CheckpointRepo.Load(ctx, input.JobID)
+	require.NoError(t, err)
+	assert.Len(t, stored.Completed, 4)
+
+	// 续跑只处理失败的批次，已完成的批次不被重复计为跳过
+	progress, err = container.BatchCreateUC.Execute(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, dto.BatchStatusCompleted, progress.Status)
+	assert.Equal(t, int64(5), progress.Processed)
+	assert.Equal(t, int64(5), progress.Created)
+	assert.Equal(t, int64(0), progress.Skipped)
+}
+
+func TestBatchCreateTasks_FileSource(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	createTaskDefinition(t, container, 12, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
+
+	path := filepath.Join(t.TempDir(), "users.txt")
+	require.NoError(t, os.WriteFile(path, []byte("40001\n40002\n\n40003\n40002\n"), 0o644))
+
+	progress, err := container.BatchCreateUC.Execute(ctx, dto.BatchCreateTaskInput{
+		ActivityID: 12,
+		Source:     dto.UserIDSourceInput{Type: dto.UserIDSourceFile, FilePath: path},
+		ChunkSize:  2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), progress.Processed)
+	assert.Equal(t, int64(3), progress.Created)
+	assert.Equal(t, int64(1), progress.Skipped, "重复的用户应被跳过")
+
+	// 非法用户ID应中断并记录错误
+	badPath := filepath.Join(t.TempDir(), "bad.txt")
+	require.NoError(t, os.WriteFile(badPath, []byte("40004\nabc\n"), 0o644))
+	failed, err := container.BatchCreateUC.Execute(ctx, dto.BatchCreateTaskInput{
+		JobID:      "bad_file_job",
+		ActivityID: 12,
+		Source:     dto.UserIDSourceInput{Type: dto.UserIDSourceFile, FilePath: badPath},
+		ChunkSize:  1,
+	})
+	assert.Error(t, err)
+	assert.Equal(t, dto.BatchStatusFailed, failed.Status)
+}
+
+func TestLazyCreateOnFirstEvent(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	activity := &entity.ActActivity{
+		Name:      "Lazy Activity",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+		Status:    entity.ActivityStatusActive,
+	}
+	require.NoError(t, container.ActivityRepo.Create(ctx, activity))
+
+	lazyDef := createTaskDefinition(t, container, activity.ID, valueobject.TaskTypePublishTimes, 3, publishCondExpr)
+	lazyDef.CreateMode = entity.TaskCreateModeLazy
+	require.NoError(t, container.TaskDefRepo.Update(ctx, lazyDef))
+
+	// 预创建模式的任务定义不应被自动创建
+	createTaskDefinition(t, container, activity.ID, valueobject.TaskTypePublishTimes, 1, publishCondExpr)
+
+	userID := int64(50001)
+	for i, contentID := range []int64{1, 2} {
+		err := container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+			TaskMode: &dto.PublishEventDTO{
+				UserID:    userID,
+				ContentID: contentID,
+				TopicIDs:  []uint64{1001},
+				LikeCount: 20,
+				IsAudited: true,
+			},
+		})
+		require.NoError(t, err, "首次事件应自动创建任务")
+
+		tasks, err := container.QueryTaskUC.ExecuteList(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1, "只应创建延迟创建的任务，且不重复创建")
+		assert.Equal(t, lazyDef.ID, tasks[0].TaskID)
+		assert.Equal(t, i+1, tasks[0].Progress)
+	}
+}
+
+func TestTriggerTaskOverHTTP(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypePublishTimes, 3, publishCondExpr)
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: 60001})
+	require.NoError(t, err)
+
+	registry := dto.NewDefaultTaskModeRegistry()
+	// 新事件类型只需注册，无需修改处理器
+	require.NoError(t, registry.Register("publish_v2", func() dto.TaskModeDTO { return &dto.PublishEventDTO{} }))
+
+	taskHandler := handler.NewTaskHandler(container.TriggerTaskUC, container.CreateTaskUC, container.QueryTaskUC, registry)
+	rewardHandler := handler.NewRewardHandler(container.QueryRewardUC, container.QueryBudgetUC)
+	server := httptest.NewServer(router.NewRouter(taskHandler, rewardHandler, newAdminHandler(container)))
+	defer server.Close()
+
+	post := func(body string) int {
+		resp, err := http.Post(server.URL+"/api/v1/task/trigger", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	cases := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"publish", `{"event_type":"publish","payload":{"user_id":60001,"content_id":1,"topic_ids":[1001],"like_count":12,"is_audited":true}}`, http.StatusOK},
+		{"registered type", `{"event_type":"publish_v2","payload":{"user_id":60001,"content_id":2,"topic_ids":[1002],"like_count":12,"is_audited":true}}`, http.StatusOK},
+		{"checkin", `{"event_type":"checkin","payload":{"user_id":60001,"date":"2024-01-01"}}`, http.StatusOK},
+		{"unknown type", `{"event_type":"unknown","payload":{"user_id":60001}}`, http.StatusBadRequest},
+		{"missing user", `{"event_type":"publish","payload":{"content_id":3}}`, http.StatusBadRequest},
+		{"bad date", `{"event_type":"checkin","payload":{"user_id":60001,"date":"01/01/2024"}}`, http.StatusBadRequest},
+		{"bad payload", `{"event_type":"publish","payload":{"user_id":"abc"}}`, http.StatusBadRequest},
+		{"malformed body", `{"event_type":`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.code, post(tc.body), tc.name)
+	}
+
+	tasks, err := container.QueryTaskUC.ExecuteList(ctx, 60001)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, 2, tasks[0].Progress, "两次发布事件都应计入进度")
+}
+
+// triggerEvent 触发事件并返回用户在该任务定义下的任务进度
+func triggerEvent(t *testing.T, container *Container, taskMode dto.TaskModeDTO, def *entity.ActTaskDefinition) *dto.TaskOutput {
+	t.Helper()
+	ctx := context.Background()
+
+	require.NoError(t, container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{TaskMode: taskMode}))
+	return findTaskOutput(t, container, taskMode.GetUserID(), def)
+}
+
+func TestTriggerShareTask(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	userID := int64(70001)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeShareTimes, 2,
+		"CHANNEL_IN(channel, 'wechat', 'weibo') && IS_OTHERS_CONTENT(user_id, author_id)")
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	task := triggerEvent(t, container, &dto.ShareEventDTO{UserID: userID, ContentID: 1, AuthorID: 70100, Channel: "wechat"}, def)
+	assert.Equal(t, 1, task.Progress, "分享到微信应计入进度")
+
+	task = triggerEvent(t, container, &dto.ShareEventDTO{UserID: userID, ContentID: 1, AuthorID: 70100, Channel: "wechat"}, def)
+	assert.Equal(t, 1, task.Progress, "同一内容同一渠道重复分享不应重复计数")
+
+	task = triggerEvent(t, container, &dto.ShareEventDTO{UserID: userID, ContentID: 1, AuthorID: 70100, Channel: "qq"}, def)
+	assert.Equal(t, 1, task.Progress, "不在指定渠道的分享不应计数")
+
+	task = triggerEvent(t, container, &dto.ShareEventDTO{UserID: userID, ContentID: 2, AuthorID: userID, Channel: "wechat"}, def)
+	assert.Equal(t, 1, task.Progress, "分享自己的内容不应计数")
+
+	// 通过事件信封解码的分享事件
+	taskMode, err := dto.NewDefaultTaskModeRegistry().Decode(dto.TriggerEventEnvelope{
+		EventType: dto.EventTypeShare,
+		Payload:   []byte(`{"user_id":70001,"content_id":1,"author_id":70100,"channel":"weibo"}`),
+	})
+	require.NoError(t, err)
+	task = triggerEvent(t, container, taskMode, def)
+	assert.Equal(t, 2, task.Progress, "同一内容分享到不同渠道应分别计数")
+	assert.Equal(t, "done", task.Status)
+}
+
+func TestTriggerLikeTask(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	userID := int64(70002)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeLikeTimes, 3, "IS_OTHERS_CONTENT(user_id, author_id)")
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	task := triggerEvent(t, container, &dto.LikeEventDTO{UserID: userID, ContentID: 1, AuthorID: 70100}, def)
+	assert.Equal(t, 1, task.Progress)
+
+	task = triggerEvent(t, container, &dto.LikeEventDTO{UserID: userID, ContentID: 1, AuthorID: 70100}, def)
+	assert.Equal(t, 1, task.Progress, "取消后重新点赞同一内容不应重复计数")
+
+	task = triggerEvent(t, container, &dto.LikeEventDTO{UserID: userID, ContentID: 2, AuthorID: userID}, def)
+	assert.Equal(t, 1, task.Progress, "给自己点赞不应计数")
+
+	task = triggerEvent(t, container, &dto.LikeEventDTO{UserID: userID, ContentID: 3, AuthorID: 70200}, def)
+	assert.Equal(t, 2, task.Progress)
+	assert.Equal(t, "pending", task.Status)
+}
+
+func TestTriggerCommentTask(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	userID := int64(70003)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeCommentTimes, 2,
+		"LENGTH_GTE(comment_length, 5) && AUTHOR_IN(author_id, 70100, 70200)")
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	task := triggerEvent(t, container, &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 1, AuthorID: 70100, Text: "太好看了吧！"}, def)
+	assert.Equal(t, 1, task.Progress, "字数按字符计算")
+
+	task = triggerEvent(t, container, &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 2, AuthorID: 70100, Text: "赞"}, def)
+	assert.Equal(t, 1, task.Progress, "字数不足的评论不应计数")
+
+	task = triggerEvent(t, container, &dto.CommentEventDTO{UserID: userID, ContentID: 2, CommentID: 3, AuthorID: 70300, Text: "写得真不错啊"}, def)
+	assert.Equal(t, 1, task.Progress, "非指定作者的内容不应计数")
+
+	task = triggerEvent(t, container, &dto.CommentEventDTO{UserID: userID, ContentID: 3, CommentID: 4, AuthorID: 70200, Text: "学到了很多东西"}, def)
+	assert.Equal(t, 2, task.Progress)
+	assert.Equal(t, "done", task.Status)
+}
+
+func TestRecurrencePeriodKey(t *testing.T) {
+	// 2024-01-01 00:30 上海时间，对应 UTC 2023-12-31 16:30
+	at := time.Date(2023, 12, 31, 16, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		recurrence valueobject.Recurrence
+		expected   string
+	}{
+		{valueobject.NewRecurrence(valueobject.RecurrenceNone, ""), ""},
+		{valueobject.NewRecurrence(valueobject.RecurrenceDaily, "Asia/Shanghai"), "2024-01-01"},
+		{valueobject.NewRecurrence(valueobject.RecurrenceDaily, "UTC"), "2023-12-31"},
+		{valueobject.NewRecurrence(valueobject.RecurrenceWeekly, "Asia/Shanghai"), "2024-W01"},
+		{valueobject.NewRecurrence(valueobject.RecurrenceWeekly, "UTC"), "2023-W52"},
+		{valueobject.NewRecurrence(valueobject.RecurrenceMonthly, "Asia/Shanghai"), "2024-01"},
+	}
+	for _, tc := range cases {
+		assert.True(t, tc.recurrence.IsValid())
+		assert.Equal(t, tc.expected, tc.recurrence.PeriodKey(at), tc.recurrence.String())
+	}
+
+	assert.False(t, valueobject.NewRecurrence(valueobject.RecurrenceDaily, "Mars/Base").IsValid())
+	assert.False(t, valueobject.NewRecurrence("hourly", "").IsValid())
+}
+
+func TestDailyRecurringTask(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	userID := int64(80001)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeCheckin, 1, checkinCondExpr)
+	def.Recurrence = valueobject.NewRecurrence(valueobject.RecurrenceDaily, "Asia/Shanghai")
+	require.NoError(t, container.TaskDefRepo.Update(ctx, def))
+
+	created, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+	clock := testClock(container)
+	firstDay := def.PeriodKeyAt(clock.Now())
+	assert.Equal(t, firstDay, created.PeriodKey)
+	checkin := func() *dto.TaskOutput {
+		return triggerEvent(t, container, &dto.CheckinEventDTO{UserID: userID, Date: def.PeriodKeyAt(clock.Now()), Timezone: "Asia/Shanghai"}, def)
+	}
+
+	// 第一天完成签到
+	task := checkin()
+	assert.Equal(t, "done", task.Status)
+
+	// 次日进入新周期，查询返回当前周期的进度
+	clock.Advance(24 * time.Hour)
+	secondDay := def.PeriodKeyAt(clock.Now())
+	current, err := container.QueryTaskUC.Execute(ctx, dto.QueryTaskInput{TaskID: task.ID})
+	require.NoError(t, err)
+	assert.Equal(t, secondDay, current.PeriodKey)
+	assert.Equal(t, 0, current.Progress)
+	assert.Equal(t, "pending", current.Status)
+
+	history, err := container.QueryTaskUC.ExecuteHistory(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, firstDay, history[0].PeriodKey)
+	assert.Equal(t, "done", history[0].Status)
+
+	// 次日再次签到，任务在新周期重新完成，第一天的进度归档为历史
+	task = checkin()
+	assert.Equal(t, "done", task.Status)
+	assert.Equal(t, 1, task.Progress)
+	assert.Equal(t, secondDay, task.PeriodKey)
+
+	history, err = container.QueryTaskUC.ExecuteHistory(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, firstDay, history[0].PeriodKey)
+	assert.Equal(t, 1, history[0].Progress)
+}
+
+func TestStreakCheckinTask(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeCheckin, 7, checkinCondExpr)
+	def.Kind = entity.TaskKindStreak
+	def.Streak = valueobject.NewStreakPolicy(1, "Asia/Shanghai")
+	require.NoError(t, container.TaskDefRepo.Update(ctx, def))
+	require.NoError(t, container.RewardRepo.Create(ctx, &entity.ActTaskReward{
+		TaskDefID: def.ID, Type: entity.RewardTypePoints, Amount: 10, GrantTiming: entity.RewardGrantOnReach,
+	}))
+
+	// 每个场景使用独立用户：同一用户同一天的打卡按唯一标识去重
+	createStreakTask := func(userID int64) *dto.TaskOutput {
+		created, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+		require.NoError(t, err)
+		return created
+	}
+	dayKey := func(offset int) string {
+		return def.Streak.DayKey(testClock(container).Now().AddDate(0, 0, offset))
+	}
+	// setStreak 模拟历史打卡状态
+	setStreak := func(taskID int64, lastOffset, progress, graceUsed int) {
+		stored, err := container.TaskRepo.GetByID(ctx, taskID)
+		require.NoError(t, err)
+		stored.LastQualifiedDate = dayKey(lastOffset)
+		stored.Progress = progress
+		stored.BestStreak = progress
+		stored.GraceUsed = graceUsed
+		require.NoError(t, container.TaskRepo.Update(ctx, stored))
+	}
+	checkin := func(userID int64, date string) *dto.TaskOutput {
+		return triggerEvent(t, container, &dto.CheckinEventDTO{UserID: userID, Date: date, Timezone: "Asia/Shanghai"}, def)
+	}
+
+	userID := int64(80002)
+	created := createStreakTask(userID)
+	assert.Equal(t, "streak", created.Kind)
+	assert.Equal(t, 1, created.GraceCardsLeft)
+
+	task := checkin(userID, dayKey(0))
+	assert.Equal(t, 1, task.CurrentStreak)
+	assert.Equal(t, 1, task.BestStreak)
+
+	// 同一天换用历史日期重复打卡不计数，也不写明细、不发奖励
+	checkin(userID, dayKey(-1))
+	task = checkin(userID, dayKey(-2))
+	assert.Equal(t, 1, task.CurrentStreak)
+	details, err := container.TaskDetailRepo.ListByTaskID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Len(t, details, 1)
+	wallet, err := container.QueryRewardUC.ExecuteWallet(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), wallet.Points)
+
+	// 昨天已连续3天，今天继续打卡
+	userID = 80030
+	created = createStreakTask(userID)
+	setStreak(created.ID, -1, 3, 0)
+	task = checkin(userID, dayKey(0))
+	assert.Equal(t, 4, task.CurrentStreak)
+	assert.Equal(t, 4, task.BestStreak)
+
+	// 断签2天，补签卡只有1张，从头开始
+	userID = 80031
+	created = createStreakTask(userID)
+	setStreak(created.ID, -3, 4, 0)
+	current, err := container.QueryTaskUC.Execute(ctx, dto.QueryTaskInput{TaskID: created.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 0, current.CurrentStreak, "补签卡不足时查询应显示已断签")
+	task = checkin(userID, dayKey(0))
+	assert.Equal(t, 1, task.CurrentStreak)
+	assert.Equal(t, 4, task.BestStreak, "最长连续天数不应被重置")
+	details, err = container.TaskDetailRepo.ListByTaskID(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, details, 1)
+	assert.Equal(t, 1, details[0].ProgressDelta, "断签重置时明细记录重新计入的天数")
+
+	// 断签1天，使用补签卡补齐，补签的那天也计入连续天数
+	userID = 80032
+	created = createStreakTask(userID)
+	setStreak(created.ID, -2, 2, 0)
+	current, err = container.QueryTaskUC.Execute(ctx, dto.QueryTaskInput{TaskID: created.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, current.CurrentStreak, "还有补签卡时查询不应显示断签")
+	task = checkin(userID, dayKey(0))
+	assert.Equal(t, 4, task.CurrentStreak)
+	assert.Equal(t, 0, task.GraceCardsLeft)
+
+	// 连续满7天完成任务
+	userID = 80003
+	created = createStreakTask(userID)
+	setStreak(created.ID, -1, 6, 1)
+	task = checkin(userID, dayKey(0))
+	assert.Equal(t, 7, task.Progress)
+	assert.Equal(t, "done", task.Status)
+	assert.Equal(t, 7, task.BestStreak)
+}
+
+func TestWeightedProgressTask(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	userID := int64(80004)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypePublishTimes, 100, "IS_AUDITED(is_audited)")
+	def.ProgressExpr = "like_count"
+	require.NoError(t, container.TaskDefRepo.Update(ctx, def))
+
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	publish := func(contentID int64, likeCount int) *dto.TaskOutput {
+		return triggerEvent(t, container, &dto.PublishEventDTO{
+			UserID: userID, ContentID: contentID, LikeCount: likeCount, IsAudited: true,
+		}, def)
+	}
+
+	task := publish(1, 30)
+	assert.Equal(t, 30, task.Progress, "进度应按点赞数累加")
+
+	task = publish(2, 0)
+	assert.Equal(t, 30, task.Progress, "增量为0时不计入进度")
+
+	task = publish(3, 90)
+	assert.Equal(t, 100, task.Progress, "进度应截断到目标值")
+	assert.Equal(t, "done", task.Status)
+
+	details, err := container.TaskDetailRepo.ListByTaskID(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, details, 2)
+	deltas := []int{details[0].ProgressDelta, details[1].ProgressDelta}
+	assert.ElementsMatch(t, []int{30, 70}, deltas, "明细应记录实际生效的增量")
+}
+
+func TestTaskUnlockChain(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	userID := int64(80005)
+	shareDef := createTaskDefinition(t, container, 1, valueobject.TaskTypeShareTimes, 1, "CHANNEL_IN(channel, 'wechat')")
+	likeDef := createTaskDefinition(t, container, 1, valueobject.TaskTypeLikeTimes, 1, "IS_OTHERS_CONTENT(user_id, author_id)")
+
+	// 评论任务需要分享和点赞都完成后解锁
+	commentDef := createTaskDefinition(t, container, 1, valueobject.TaskTypeCommentTimes, 1, "LENGTH_GTE(comment_length, 1)")
+	commentDef.Prerequisites = []int64{shareDef.ID, likeDef.ID}
+	commentDef.PrerequisiteMode = entity.PrerequisiteAllOf
+	require.NoError(t, container.TaskDefRepo.Update(ctx, commentDef))
+
+	// 发布任务完成分享或点赞任一即可解锁
+	publishDef := createTaskDefinition(t, container, 1, valueobject.TaskTypePublishTimes, 1, "IS_AUDITED(is_audited)")
+	publishDef.Prerequisites = []int64{shareDef.ID, likeDef.ID}
+	publishDef.PrerequisiteMode = entity.PrerequisiteAnyOf
+	require.NoError(t, container.TaskDefRepo.Update(ctx, publishDef))
+
+	for _, def := range []*entity.ActTaskDefinition{shareDef, likeDef, commentDef, publishDef} {
+		_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+		require.NoError(t, err)
+	}
+
+	task := triggerEvent(t, container, &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 1, AuthorID: 70100, Text: "不错"}, commentDef)
+	assert.Equal(t, "locked", task.Status, "前置任务未完成时任务应保持锁定")
+	assert.Equal(t, 0, task.Progress, "锁定的任务不应计入进度")
+	assert.Equal(t, []int64{shareDef.ID, likeDef.ID}, task.Prerequisites)
+
+	triggerEvent(t, container, &dto.ShareEventDTO{UserID: userID, ContentID: 1, AuthorID: 70100, Channel: "wechat"}, shareDef)
+
+	task = findTaskOutput(t, container, userID, publishDef)
+	assert.Equal(t, "pending", task.Status, "任一前置任务完成即应解锁")
+	task = findTaskOutput(t, container, userID, commentDef)
+	assert.Equal(t, "locked", task.Status, "全部前置任务完成前应保持锁定")
+
+	triggerEvent(t, container, &dto.LikeEventDTO{UserID: userID, ContentID: 2, AuthorID: 70100}, likeDef)
+
+	task = findTaskOutput(t, container, userID, commentDef)
+	assert.Equal(t, "pending", task.Status, "全部前置任务完成后应解锁")
+
+	task = triggerEvent(t, container, &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 2, AuthorID: 70100, Text: "不错"}, commentDef)
+	assert.Equal(t, 1, task.Progress, "解锁后的任务应正常计入进度")
+	assert.Equal(t, "done", task.Status)
+}
+
+// findTaskOutput 查询用户在该任务定义下的任务
+func findTaskOutput(t *testing.T, container *Container, userID int64, def *entity.ActTaskDefinition) *dto.TaskOutput {
+	t.Helper()
+
+	tasks, err := container.QueryTaskUC.ExecuteList(context.Background(), userID)
+	require.NoError(t, err)
+	for _, task := range tasks {
+		if task.TaskID == def.ID {
+			return task
+		}
+	}
+	t.Fatalf("task for definition %d not found", def.ID)
+	return nil
+}
+
+func TestRewardLedger(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	userID := int64(80006)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeLikeTimes, 2, "IS_OTHERS_CONTENT(user_id, author_id)")
+	rewards := []*entity.ActTaskReward{
+		{TaskDefID: def.ID, Type: entity.RewardTypePoints, Amount: 10, GrantTiming: entity.RewardGrantOnReach},
+		{TaskDefID: def.ID, Type: entity.RewardTypeCoupon, Amount: 2, ItemCode: "coupon_5off", GrantTiming: entity.RewardGrantOnComplete},
+		{TaskDefID: def.ID, Type: entity.RewardTypeBadge, Amount: 1, ItemCode: "badge_liker", GrantTiming: entity.RewardGrantOnComplete},
+	}
+	for _, reward := range rewards {
+		require.NoError(t, container.RewardRepo.Create(ctx, reward))
+	}
+	assert.Error(t, container.RewardRepo.Create(ctx, &entity.ActTaskReward{TaskDefID: def.ID, Type: entity.RewardTypeCoupon, Amount: 1}),
+		"优惠券奖励必须指定券模板编码")
+
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	triggerEvent(t, container, &dto.LikeEventDTO{UserID: userID, ContentID: 1, AuthorID: 70100}, def)
+	wallet, err := container.QueryRewardUC.ExecuteWallet(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), wallet.Points, "每次达成应发放积分")
+	assert.Empty(t, wallet.Coupons, "任务未完成不应发放完成奖励")
+
+	triggerEvent(t, container, &dto.LikeEventDTO{UserID: userID, ContentID: 1, AuthorID: 70100}, def)
+	task := triggerEvent(t, container, &dto.LikeEventDTO{UserID: userID, ContentID: 2, AuthorID: 70100}, def)
+	assert.Equal(t, "done", task.Status)
+
+	wallet, err = container.QueryRewardUC.ExecuteWallet(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), wallet.Points, "重复事件不应重复发放积分")
+	assert.Equal(t, map[string]int{"coupon_5off": 2}, wallet.Coupons)
+	assert.Contains(t, wallet.Badges, "badge_liker")
+
+	ledger, err := container.QueryRewardUC.ExecuteLedger(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, ledger, 4)
+	assert.Equal(t, "points", ledger[0].RewardType)
+
+	// 同一明细同一奖励重复记账应被幂等拦截
+	details, err := container.TaskDetailRepo.ListByTaskID(ctx, task.ID)
+	require.NoError(t, err)
+	appended, err := container.RewardLedgerRepo.Append(ctx, &entity.ActRewardLedgerEntry{
+		UserID:         userID,
+		RewardID:       rewards[0].ID,
+		Type:           entity.RewardTypePoints,
+		Amount:         10,
+		IdempotencyKey: entity.RewardIdempotencyKey(details[0], rewards[0].ID),
+	})
+	require.NoError(t, err)
+	assert.False(t, appended)
+
+	wallet, err = container.QueryRewardUC.ExecuteWallet(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), wallet.Points)
+}
+
+// failingLedgerRepo 前 failures 次记账失败，模拟进度落库后奖励记账失败
+type failingLedgerRepo struct {
+	*memory.RewardLedgerRepositoryMemory
+	failures int
+}
+
+func (r *failingLedgerRepo) Append(ctx context.Context, entry *entity.ActRewardLedgerEntry) (bool, error) {
+	if r.failures > 0 {
+		r.failures--
+		return false, errors.New("ledger unavailable")
+	}
+	return r.RewardLedgerRepositoryMemory.Append(ctx, entry)
+}
+
+func TestRewardRegrantAfterLedgerFailure(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	userID := int64(80029)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeLikeTimes, 1, "IS_OTHERS_CONTENT(user_id, author_id)")
+	rewards := []*entity.ActTaskReward{
+		{TaskDefID: def.ID, Type: entity.RewardTypePoints, Amount: 10, GrantTiming: entity.RewardGrantOnReach},
+		{TaskDefID: def.ID, Type: entity.RewardTypeCoupon, Amount: 1, ItemCode: "coupon_5off", GrantTiming: entity.RewardGrantOnComplete},
+	}
+	for _, reward := range rewards {
+		require.NoError(t, container.RewardRepo.Create(ctx, reward))
+	}
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	ledgerRepo := &failingLedgerRepo{RewardLedgerRepositoryMemory: container.RewardLedgerRepo, failures: 1}
+	triggerUC := task.NewTriggerTaskUseCase(
+		container.TaskRepo, container.TaskDefRepo, container.TaskDetailRepo, container.TaskPeriodRepo,
+		container.ActivityRepo, container.RewardRepo, ledgerRepo, container.BudgetRepo,
+		container.TraceRepo, container.RuleEngine, container.FunctionRegistry, container.ObserverRegistry,
+		container.DistributedLock, container.RiskCheckService, 30, 0, task.LockContention{},
+	)
+	like := dto.TriggerTaskInput{TaskMode: &dto.LikeEventDTO{UserID: userID, ContentID: 1, AuthorID: 70100}}
+
+	// 进度已落库、奖励记账失败：任务已完成但没有奖励
+	assert.Error(t, triggerUC.Execute(ctx, like))
+	assert.Equal(t, "done", findTaskOutput(t, container, userID, def).Status)
+	wallet, err := container.QueryRewardUC.ExecuteWallet(ctx, userID)
+	require.NoError(t, err)
+	assert.Zero(t, wallet.Points)
+
+	// 重试同一事件：不再推进进度，按已有明细补发全部奖励
+	require.NoError(t, triggerUC.Execute(ctx, like))
+	require.NoError(t, triggerUC.Execute(ctx, like))
+	wallet, err = container.QueryRewardUC.ExecuteWallet(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), wallet.Points)
+	assert.Equal(t, map[string]int{"coupon_5off": 1}, wallet.Coupons)
+	details, err := container.TaskDetailRepo.ListByTaskID(ctx, findTaskOutput(t, container, userID, def).ID)
+	require.NoError(t, err)
+	assert.Len(t, details, 1)
+}
+
+func TestRewardBudgetCaps(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	activity := &entity.ActActivity{
+		Name:      "Limited Prize Pool",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+		Status:    entity.ActivityStatusActive,
+		Budget:    valueobject.NewRewardBudget(3, 0),
+	}
+	require.NoError(t, container.ActivityRepo.Create(ctx, activity))
+
+	// 点赞任务仅限 1 人完成
+	likeDef := createTaskDefinition(t, container, activity.ID, valueobject.TaskTypeLikeTimes, 1, "IS_OTHERS_CONTENT(user_id, author_id)")
+	likeDef.Budget = valueobject.NewRewardBudget(0, 1)
+	require.NoError(t, container.TaskDefRepo.Update(ctx, likeDef))
+	commentDef := createTaskDefinition(t, container, activity.ID, valueobject.TaskTypeCommentTimes, 10, "LENGTH_GTE(comment_length, 1)")
+
+	// 预算按本次发放的奖励消耗，与任务定义的激励值无关；评论任务的完成奖励在完成前不占用预算
+	for _, def := range []*entity.ActTaskDefinition{likeDef, commentDef} {
+		def.RewardValue = 0
+		require.NoError(t, container.TaskDefRepo.Update(ctx, def))
+		require.NoError(t, container.RewardRepo.Create(ctx, &entity.ActTaskReward{
+			TaskDefID: def.ID, Type: entity.RewardTypePoints, Amount: 1, GrantTiming: entity.RewardGrantOnReach,
+		}))
+	}
+	require.NoError(t, container.RewardRepo.Create(ctx, &entity.ActTaskReward{
+		TaskDefID: commentDef.ID, Type: entity.RewardTypePoints, Amount: 100, GrantTiming: entity.RewardGrantOnComplete,
+	}))
+
+	firstUser, secondUser := int64(80007), int64(80008)
+	for _, userID := range []int64{firstUser, secondUser} {
+		for _, def := range []*entity.ActTaskDefinition{likeDef, commentDef} {
+			_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: activity.ID, TaskID: def.ID, UserID: userID})
+			require.NoError(t, err)
+		}
+	}
+
+	task := triggerEvent(t, container, &dto.LikeEventDTO{UserID: firstUser, ContentID: 1, AuthorID: 70100}, likeDef)
+	assert.Equal(t, "done", task.Status)
+
+	err := container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+		TaskMode: &dto.LikeEventDTO{UserID: secondUser, ContentID: 1, AuthorID: 70100},
+	})
+	require.ErrorIs(t, err, entity.ErrBudgetExhausted, "完成人数已满时应返回预算耗尽")
+	task = findTaskOutput(t, container, secondUser, likeDef)
+	assert.Equal(t, 0, task.Progress, "预算耗尽时不应计入进度")
+
+	taskBudget, err := container.QueryBudgetUC.ExecuteTask(ctx, likeDef.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), taskBudget.Completers)
+	assert.Equal(t, int64(0), taskBudget.RemainingCompleters)
+	assert.Equal(t, int64(-1), taskBudget.RemainingRewardUnits, "未配置激励值上限时不限制")
+	assert.True(t, taskBudget.Exhausted)
+
+	for commentID := int64(1); commentID <= 2; commentID++ {
+		triggerEvent(t, container, &dto.CommentEventDTO{UserID: firstUser, ContentID: 1, CommentID: commentID, AuthorID: 70100, Text: "好"}, commentDef)
+	}
+	err = container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+		TaskMode: &dto.CommentEventDTO{UserID: firstUser, ContentID: 1, CommentID: 3, AuthorID: 70100, Text: "好"},
+	})
+	require.ErrorIs(t, err, entity.ErrBudgetExhausted, "活动激励值用尽时应返回预算耗尽")
+	task = findTaskOutput(t, container, firstUser, commentDef)
+	assert.Equal(t, 2, task.Progress)
+
+	activityBudget, err := container.QueryBudgetUC.ExecuteActivity(ctx, activity.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), activityBudget.UsedRewardUnits)
+	assert.Equal(t, int64(0), activityBudget.RemainingRewardUnits)
+	assert.True(t, activityBudget.Exhausted)
+}
+
+func TestTriggerHonorsActivityWindow(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	activity := &entity.ActActivity{
+		Name:      "Weekend Activity",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+		Status:    entity.ActivityStatusInactive,
+	}
+	require.NoError(t, container.ActivityRepo.Create(ctx, activity))
+
+	userID := int64(80009)
+	def := createTaskDefinition(t, container, activity.ID, valueobject.TaskTypeLikeTimes, 5, "IS_OTHERS_CONTENT(user_id, author_id)")
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: activity.ID, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	like := func(userID, contentID int64) error {
+		return container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+			TaskMode: &dto.LikeEventDTO{UserID: userID, ContentID: contentID, AuthorID: 70100},
+		})
+	}
+
+	assert.ErrorIs(t, like(userID, 1), entity.ErrActivityNotActive, "未激活的活动应拒绝事件")
+
+	activity.Status = entity.ActivityStatusActive
+	require.NoError(t, container.ActivityRepo.Update(ctx, activity))
+	require.NoError(t, like(userID, 2))
+
+	activity.EndTime = time.Now().Add(-time.Minute)
+	require.NoError(t, container.ActivityRepo.Update(ctx, activity))
+	assert.ErrorIs(t, like(userID, 3), entity.ErrActivityNotActive, "活动结束后应拒绝事件")
+
+	task := findTaskOutput(t, container, userID, def)
+	assert.Equal(t, 1, task.Progress, "仅活动进行中的事件计入进度")
+
+	// 未配置活动的任务按任务有效天数判断过期
+	fallbackUserID := int64(80010)
+	fallbackDef := createTaskDefinition(t, container, 99, valueobject.TaskTypeLikeTimes, 5, "IS_OTHERS_CONTENT(user_id, author_id)")
+	output, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 99, TaskID: fallbackDef.ID, UserID: fallbackUserID})
+	require.NoError(t, err)
+	fallbackTask, err := container.TaskRepo.GetByID(ctx, output.ID)
+	require.NoError(t, err)
+	fallbackTask.CreatedAt = time.Now().AddDate(0, 0, -container.Config.Task.TaskExpireDays-1)
+	require.NoError(t, container.TaskRepo.Update(ctx, fallbackTask))
+
+	require.NoError(t, like(fallbackUserID, 4))
+	task = findTaskOutput(t, container, fallbackUserID, fallbackDef)
+	assert.Equal(t, 0, task.Progress, "超过有效天数的任务不应计入进度")
+}
+
+// activityEventRecorder 记录活动状态变更事件的观察者
+type activityEventRecorder struct {
+	events []*event.ActivityStatusChanged
+}
+
+func (r *activityEventRecorder) OnActivityStatusChanged(ctx context.Context, changed *event.ActivityStatusChanged) error {
+	r.events = append(r.events, changed)
+	return nil
+}
+
+func (r *activityEventRecorder) GetObserverName() string {
+	return "activity_event_recorder"
+}
+
+// failingActivityRepo 更新指定活动时返回版本冲突，模拟调度与管理操作并发修改同一活动
+type failingActivityRepo struct {
+	*memory.ActivityRepositoryMemory
+	failID int64
+}
+
+func (r *failingActivityRepo) Update(ctx context.Context, a *entity.ActActivity) error {
+	if a.ID == r.failID {
+		return &repository.VersionConflictError{Entity: "activity", ID: a.ID, Expected: a.Version, Actual: a.Version + 1}
+	}
+	return r.ActivityRepositoryMemory.Update(ctx, a)
+}
+
+func TestActivitySchedulerPartialFailure(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	now := time.Now()
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		a := &entity.ActActivity{
+			Name:      fmt.Sprintf("scheduled-%d", i),
+			StartTime: now.Add(-time.Minute),
+			EndTime:   now.Add(time.Hour),
+			Status:    entity.ActivityStatusInactive,
+			Published: true,
+		}
+		require.NoError(t, container.ActivityRepo.Create(ctx, a))
+		ids = append(ids, a.ID)
+	}
+
+	// 单个活动更新失败不影响其他活动，错误汇总返回，失败的活动在下一次调度时重试
+	repo := &failingActivityRepo{ActivityRepositoryMemory: container.ActivityRepo, failID: ids[0]}
+	scheduler := activity.NewActivityScheduler(repo, container.ActivityObserverRegistry, time.Minute, container.Clock)
+	changed, err := scheduler.RunOnce(ctx, now)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+	assert.Equal(t, 2, changed)
+	for i, id := range ids {
+		stored, err := container.ActivityRepo.GetByID(ctx, id)
+		require.NoError(t, err)
+		if i == 0 {
+			assert.Equal(t, entity.ActivityStatusInactive, stored.Status)
+		} else {
+			assert.Equal(t, entity.ActivityStatusActive, stored.Status)
+		}
+	}
+
+	repo.failID = 0
+	changed, err = scheduler.RunOnce(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed)
+}
+
+func TestActivityLifecycle(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	recorder := &activityEventRecorder{}
+	container.ActivityObserverRegistry.Register(recorder)
+
+	now := time.Now()
+	created, err := container.CreateActivityUC.Execute(ctx, dto.CreateActivityInput{
+		Name:      "Double Eleven",
+		StartTime: now.Add(time.Hour),
+		EndTime:   now.Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "inactive", created.Status)
+
+	_, err = container.CreateActivityUC.Execute(ctx, dto.CreateActivityInput{Name: "Broken", StartTime: now, EndTime: now})
+	assert.Error(t, err, "结束时间必须晚于开始时间")
+
+	_, err = container.ActivityLifecycleUC.ExecutePause(ctx, created.ID)
+	assert.ErrorIs(t, err, entity.ErrInvalidActivityTransition, "未激活的活动不能暂停")
+
+	published, err := container.ActivityLifecycleUC.ExecutePublish(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "inactive", published.Status, "未到开始时间的活动发布后等待调度器激活")
+	assert.True(t, published.Published)
+
+	changed, err := container.ActivityScheduler.RunOnce(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, changed)
+
+	changed, err = container.ActivityScheduler.RunOnce(ctx, now.Add(time.Hour+time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed, "到达开始时间应自动激活")
+
+	paused, err := container.ActivityLifecycleUC.ExecutePause(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "paused", paused.Status)
+
+	resumed, err := container.ActivityLifecycleUC.ExecutePublish(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "active", resumed.Status, "暂停的活动重新发布后恢复")
+
+	updated, err := container.UpdateActivityUC.Execute(ctx, dto.UpdateActivityInput{
+		ActivityID:     created.ID,
+		Name:           "Double Eleven Extended",
+		StartTime:      now.Add(time.Hour),
+		EndTime:        now.Add(3 * time.Hour),
+		MaxRewardUnits: 100,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), updated.MaxRewardUnits)
+	assert.Equal(t, "active", updated.Status, "更新配置不改变状态")
+
+	changed, err = container.ActivityScheduler.RunOnce(ctx, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed, "到达结束时间应自动过期")
+
+	_, err = container.ActivityLifecycleUC.ExecutePublish(ctx, created.ID)
+	assert.ErrorIs(t, err, entity.ErrInvalidActivityTransition, "已结束的活动不能再发布")
+	_, err = container.UpdateActivityUC.Execute(ctx, dto.UpdateActivityInput{
+		ActivityID: created.ID, Name: "Again", StartTime: now, EndTime: now.Add(time.Hour),
+	})
+	assert.Error(t, err, "已结束的活动不能修改")
+
+	transitions := make([]string, 0, len(recorder.events))
+	for _, changed := range recorder.events {
+		transitions = append(transitions, fmt.Sprintf("%s->%s:%s", changed.From, changed.To, changed.Trigger))
+	}
+	assert.Equal(t, []string{
+		"inactive->active:scheduler",
+		"active->paused:manual",
+		"paused->active:manual",
+		"active->expired:scheduler",
+	}, transitions)
+
+	// 已到开始时间的活动发布后立即激活，可手动提前结束
+	running, err := container.CreateActivityUC.Execute(ctx, dto.CreateActivityInput{
+		Name:      "Flash Sale",
+		StartTime: now.Add(-time.Minute),
+		EndTime:   now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	running, err = container.ActivityLifecycleUC.ExecutePublish(ctx, running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "active", running.Status)
+	ended, err := container.ActivityLifecycleUC.ExecuteEnd(ctx, running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "expired", ended.Status)
+}
+
+// newAdminHandler 为测试创建运营管理处理器
+func newAdminHandler(container *Container) *handler.AdminHandler {
+	return handler.NewAdminHandler(
+		container.CreateActivityUC,
+		container.UpdateActivityUC,
+		container.ActivityLifecycleUC,
+		container.QueryActivityUC,
+		container.ManageTaskDefUC,
+		container.DryRunExpressionUC,
+		container.QueryTraceUC,
+		container.ListFunctionUC,
+	)
+}
+
+// adminCall 调用运营管理接口，body 为 nil 时发送 GET 请求，成功时将 data 解码到 out
+func adminCall(t *testing.T, server *httptest.Server, path string, body interface{}, out interface{}) int {
+	t.Helper()
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	if body == nil {
+		resp, err = http.Get(server.URL + path)
+	} else {
+		payload, marshalErr := json.Marshal(body)
+		require.NoError(t, marshalErr)
+		resp, err = http.Post(server.URL+path, "application/json", bytes.NewReader(payload))
+	}
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK && out != nil {
+		envelope := struct {
+			Code int             `json:"code"`
+			Data json.RawMessage `json:"data"`
+		}{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&envelope))
+		require.NoError(t, json.Unmarshal(envelope.Data, out))
+	}
+	return resp.StatusCode
+}
+
+func TestAdminAPI(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	taskHandler := handler.NewTaskHandler(container.TriggerTaskUC, container.CreateTaskUC, container.QueryTaskUC, dto.NewDefaultTaskModeRegistry())
+	rewardHandler := handler.NewRewardHandler(container.QueryRewardUC, container.QueryBudgetUC)
+	server := httptest.NewServer(router.NewRouter(taskHandler, rewardHandler, newAdminHandler(container)))
+	defer server.Close()
+
+	// 创建并发布活动
+	var activity dto.ActivityOutput
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/activity/create", dto.CreateActivityInput{
+		Name:      "Admin Activity",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+	}, &activity))
+	assert.Equal(t, "inactive", activity.Status)
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/activity/publish", map[string]int64{"activity_id": activity.ID}, &activity))
+	assert.Equal(t, "active", activity.Status)
+
+	// 创建任务定义，前置任务必须属于同一活动
+	var publishDef, likeDef dto.TaskDefinitionOutput
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/task_definition/create", dto.TaskDefinitionInput{
+		ActivityID:   activity.ID,
+		Name:         "发布 1 篇",
+		TaskType:     valueobject.TaskTypePublishTimes,
+		TaskCondExpr: publishCondExpr,
+		Parameters:   publishParams,
+		Target:       1,
+		RewardValue:  1,
+	}, &publishDef))
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/task_definition/create", dto.TaskDefinitionInput{
+		ActivityID:   activity.ID,
+		Name:         "点赞 1 次",
+		TaskType:     valueobject.TaskTypeLikeTimes,
+		TaskCondExpr: "IS_OTHERS_CONTENT(user_id, author_id)",
+		Target:       1,
+		RewardValue:  1,
+		CreateMode:   "lazy",
+	}, &likeDef))
+	assert.Equal(t, "lazy", likeDef.CreateMode)
+	assert.Equal(t, http.StatusBadRequest, adminCall(t, server, "/api/v1/admin/task_definition/create", dto.TaskDefinitionInput{
+		ActivityID:    activity.ID,
+		Name:          "跨活动前置",
+		TaskType:      valueobject.TaskTypeShareTimes,
+		TaskCondExpr:  "true",
+		Target:        1,
+		Prerequisites: []int64{999999},
+	}, nil), "前置任务不存在时应拒绝")
+
+	// 参数校验失败返回 400，任务定义不存在返回 404
+	badDef := dto.TaskDefinitionInput{ActivityID: activity.ID, Name: "bad", TaskType: valueobject.TaskTypeShareTimes, TaskCondExpr: "true", Target: 0}
+	assert.Equal(t, http.StatusBadRequest, adminCall(t, server, "/api/v1/admin/task_definition/create", badDef, nil), "目标值为 0")
+	badDef.Target, badDef.CreateMode = 1, "eager"
+	assert.Equal(t, http.StatusBadRequest, adminCall(t, server, "/api/v1/admin/task_definition/create", badDef, nil), "未知的创建方式")
+	badDef.ID, badDef.CreateMode = 999999, ""
+	assert.Equal(t, http.StatusNotFound, adminCall(t, server, "/api/v1/admin/task_definition/update", badDef, nil))
+	assert.Equal(t, http.StatusBadRequest, adminCall(t, server, "/api/v1/admin/activity/create", dto.CreateActivityInput{
+		Name:      "Reversed",
+		StartTime: time.Now().Add(time.Hour),
+		EndTime:   time.Now(),
+	}, nil), "结束时间早于开始时间")
+	assert.Equal(t, http.StatusBadRequest, adminCall(t, server, "/api/v1/admin/activity/participants?activity_id=0", nil, nil))
+
+	users := []int64{80011, 80012, 80013}
+	for _, userID := range users {
+		_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: activity.ID, TaskID: publishDef.ID, UserID: userID})
+		require.NoError(t, err)
+	}
+	require.NoError(t, container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+		TaskMode: &dto.PublishEventDTO{UserID: users[0], ContentID: 1, TopicIDs: []uint64{1001}, LikeCount: 12, IsAudited: true},
+	}))
+
+	// 参与用户分页
+	var page dto.ParticipantPageOutput
+	path := fmt.Sprintf("/api/v1/admin/activity/participants?activity_id=%d&page=1&page_size=2", activity.ID)
+	require.Equal(t, http.StatusOK, adminCall(t, server, path, nil, &page))
+	assert.Equal(t, 3, page.Total)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, users[0], page.Items[0].UserID)
+	assert.Equal(t, 1, page.Items[0].CompletedCount)
+
+	path = fmt.Sprintf("/api/v1/admin/activity/participants?activity_id=%d&page=2&page_size=2", activity.ID)
+	require.Equal(t, http.StatusOK, adminCall(t, server, path, nil, &page))
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, users[2], page.Items[0].UserID)
+
+	// 页码过大时返回空页，而不是偏移量溢出
+	path = fmt.Sprintf("/api/v1/admin/activity/participants?activity_id=%d&page=9223372036854775807&page_size=2", activity.ID)
+	require.Equal(t, http.StatusOK, adminCall(t, server, path, nil, &page))
+	assert.Empty(t, page.Items)
+	assert.Equal(t, 3, page.Total)
+
+	// 完成统计
+	var stats dto.ActivityStatsOutput
+	require.Equal(t, http.StatusOK, adminCall(t, server, fmt.Sprintf("/api/v1/admin/activity/stats?activity_id=%d", activity.ID), nil, &stats))
+	assert.Equal(t, 3, stats.Participants)
+	assert.Equal(t, 1, stats.CompletedUsers)
+	assert.Equal(t, 1, stats.TaskCompletions)
+	require.Len(t, stats.Tasks, 1)
+	assert.Equal(t, "发布 1 篇", stats.Tasks[0].Name)
+	assert.InDelta(t, 1.0/3, stats.Tasks[0].CompletionRate, 0.001)
+
+	// 归档任务定义后不再出现在列表中，事件也不再计入进度
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/task_definition/archive", map[string]int64{"task_def_id": publishDef.ID}, nil))
+	var defs []*dto.TaskDefinitionOutput
+	require.Equal(t, http.StatusOK, adminCall(t, server, fmt.Sprintf("/api/v1/admin/task_definition/list?activity_id=%d", activity.ID), nil, &defs))
+	require.Len(t, defs, 1)
+	assert.Equal(t, likeDef.ID, defs[0].ID)
+	require.Equal(t, http.StatusOK, adminCall(t, server, fmt.Sprintf("/api/v1/admin/task_definition/list?activity_id=%d&include_archived=true", activity.ID), nil, &defs))
+	assert.Len(t, defs, 2)
+
+	require.NoError(t, container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+		TaskMode: &dto.PublishEventDTO{UserID: users[1], ContentID: 2, TopicIDs: []uint64{1001}, LikeCount: 12, IsAudited: true},
+	}))
+	tasks, err := container.QueryTaskUC.ExecuteList(ctx, users[1])
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, 0, tasks[0].Progress, "已归档的任务定义不应计入进度")
+
+	// 进行中的活动不能归档，结束后归档，归档后不可修改
+	activityAction := map[string]int64{"activity_id": activity.ID}
+	assert.Equal(t, http.StatusConflict, adminCall(t, server, "/api/v1/admin/activity/archive", activityAction, nil))
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/activity/end", activityAction, nil))
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/activity/archive", activityAction, &activity))
+	assert.True(t, activity.Archived)
+	assert.Equal(t, http.StatusConflict, adminCall(t, server, "/api/v1/admin/activity/update", dto.UpdateActivityInput{
+		ActivityID: activity.ID,
+		Name:       "Renamed",
+		StartTime:  time.Now(),
+		EndTime:    time.Now().Add(time.Hour),
+	}, nil))
+
+	var activities []*dto.ActivityOutput
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/activity/list", nil, &activities))
+	for _, a := range activities {
+		assert.NotEqual(t, activity.ID, a.ID, "默认列表不包含已归档活动")
+	}
+	assert.Equal(t, http.StatusNotFound, adminCall(t, server, "/api/v1/admin/activity/get?activity_id=999999", nil, nil))
+}
+
+func TestTaskDefinitionGuards(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	taskHandler := handler.NewTaskHandler(container.TriggerTaskUC, container.CreateTaskUC, container.QueryTaskUC, dto.NewDefaultTaskModeRegistry())
+	rewardHandler := handler.NewRewardHandler(container.QueryRewardUC, container.QueryBudgetUC)
+	server := httptest.NewServer(router.NewRouter(taskHandler, rewardHandler, newAdminHandler(container)))
+	defer server.Close()
+
+	var activity dto.ActivityOutput
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/activity/create", dto.CreateActivityInput{
+		Name:      "Guard Activity",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+	}, &activity))
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/activity/publish", map[string]int64{"activity_id": activity.ID}, &activity))
+
+	// 前置任务不能构成循环依赖，否则相关任务都无法解锁
+	var firstDef, secondDef dto.TaskDefinitionOutput
+	shareInput := dto.TaskDefinitionInput{ActivityID: activity.ID, Name: "分享 1 次", TaskType: valueobject.TaskTypeShareTimes, TaskCondExpr: "true", Target: 1}
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/task_definition/create", shareInput, &firstDef))
+	shareInput.Name = "再分享 1 次"
+	shareInput.Prerequisites = []int64{firstDef.ID}
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/task_definition/create", shareInput, &secondDef))
+	shareInput.ID = firstDef.ID
+	shareInput.Name = firstDef.Name
+	shareInput.Prerequisites = []int64{secondDef.ID}
+	assert.Equal(t, http.StatusBadRequest, adminCall(t, server, "/api/v1/admin/task_definition/update", shareInput, nil), "循环依赖应被拒绝")
+
+	// 目标值不能调低到进行中用户任务的进度及以下
+	userID := int64(80027)
+	var commentDef dto.TaskDefinitionOutput
+	commentInput := dto.TaskDefinitionInput{ActivityID: activity.ID, Name: "评论 3 次", TaskType: valueobject.TaskTypeCommentTimes, TaskCondExpr: "LENGTH_GTE(comment_length, 1)", Target: 3}
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/task_definition/create", commentInput, &commentDef))
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: activity.ID, TaskID: commentDef.ID, UserID: userID})
+	require.NoError(t, err)
+	require.NoError(t, container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+		TaskMode: &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 1, AuthorID: 70100, Text: "好"},
+	}))
+	commentInput.ID = commentDef.ID
+	commentInput.Target = 1
+	assert.Equal(t, http.StatusConflict, adminCall(t, server, "/api/v1/admin/task_definition/update", commentInput, nil))
+	commentInput.Target = 2
+	assert.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/task_definition/update", commentInput, nil))
+}
+
+func TestExpressionValidationAndDryRun(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	activity := &entity.ActActivity{
+		Name:      "Expression Activity",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+		Status:    entity.ActivityStatusActive,
+	}
+	require.NoError(t, container.ActivityRepo.Create(ctx, activity))
+
+	newInput := func(taskType valueobject.TaskType, condExpr, progressExpr string) dto.TaskDefinitionInput {
+		var params []dto.TaskParameterDTO
+		if taskType == valueobject.TaskTypePublishTimes {
+			params = publishParams
+		}
+		return dto.TaskDefinitionInput{
+			ActivityID:   activity.ID,
+			Name:         "expression task",
+			TaskType:     taskType,
+			TaskCondExpr: condExpr,
+			ProgressExpr: progressExpr,
+			Parameters:   params,
+			Target:       10,
+			RewardValue:  1,
+		}
+	}
+
+	invalid := []struct {
+		name     string
+		taskType valueobject.TaskType
+		cond     string
+		progress string
+	}{
+		{"syntax error", valueobject.TaskTypePublishTimes, "LIKE_COUNT_GTE(like_count, 10", ""},
+		{"undefined parameter", valueobject.TaskTypePublishTimes, "LIKE_COUNT_GTE(like_cnt, 10)", ""},
+		{"function of other event type", valueobject.TaskTypePublishTimes, "IS_TODAY()", ""},
+		{"unknown function", valueobject.TaskTypeLikeTimes, "IS_FRIEND(user_id, author_id)", ""},
+		{"bad progress expression", valueobject.TaskTypePublishTimes, publishCondExpr, "like_count +"},
+	}
+	for _, tc := range invalid {
+		_, err := container.ManageTaskDefUC.ExecuteCreate(ctx, newInput(tc.taskType, tc.cond, tc.progress))
+		assert.ErrorIs(t, err, valueobject.ErrInvalidExpression, tc.name)
+	}
+
+	_, err := container.ManageTaskDefUC.ExecuteCreate(ctx, newInput(valueobject.TaskTypePublishTimes, publishCondExpr, "like_count"))
+	require.NoError(t, err)
+
+	dryRun := func(payload, condExpr, progressExpr string) *dto.DryRunExpressionOutput {
+		output, err := container.DryRunExpressionUC.Execute(ctx, dto.DryRunExpressionInput{
+			Event:        dto.TriggerEventEnvelope{EventType: dto.EventTypePublish, Payload: []byte(payload)},
+			TaskCondExpr: condExpr,
+			ProgressExpr: progressExpr,
+			Parameters:   publishParams,
+		})
+		require.NoError(t, err)
+		return output
+	}
+
+	output := dryRun(`{"user_id":1,"content_id":1,"topic_ids":[1001],"like_count":12,"is_audited":true}`, publishCondExpr, "like_count")
+	assert.True(t, output.Valid)
+	assert.True(t, output.Reach)
+	assert.Equal(t, 12.0, output.Progress)
+	assert.Contains(t, output.Schema.Functions, "WITH_ANY_TOPIC")
+
+	output = dryRun(`{"user_id":1,"content_id":1,"topic_ids":[1001],"like_count":5,"is_audited":true}`, publishCondExpr, "")
+	assert.True(t, output.Valid)
+	assert.False(t, output.Reach, "点赞数不足时不应达成")
+
+	output = dryRun(`{"user_id":1,"content_id":1}`, "LIKE_COUNT_GTE(likes, 10)", "")
+	assert.False(t, output.Valid)
+	assert.Contains(t, output.Error, "undefined parameter likes")
+
+	_, err = container.DryRunExpressionUC.Execute(ctx, dto.DryRunExpressionInput{
+		Event:        dto.TriggerEventEnvelope{EventType: dto.EventTypePublish, Payload: []byte(`{"content_id":1}`)},
+		TaskCondExpr: publishCondExpr,
+	})
+	assert.Error(t, err, "样例事件不合法时应返回错误")
+
+	// HTTP：创建表达式不合法的任务定义返回 400
+	taskHandler := handler.NewTaskHandler(container.TriggerTaskUC, container.CreateTaskUC, container.QueryTaskUC, container.TaskModeRegistry)
+	rewardHandler := handler.NewRewardHandler(container.QueryRewardUC, container.QueryBudgetUC)
+	server := httptest.NewServer(router.NewRouter(taskHandler, rewardHandler, newAdminHandler(container)))
+	defer server.Close()
+
+	assert.Equal(t, http.StatusBadRequest, adminCall(t, server, "/api/v1/admin/task_definition/create",
+		newInput(valueobject.TaskTypeCheckin, "IS_TODAY(dat)", ""), nil))
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/expression/dry_run", map[string]interface{}{
+		"event":          map[string]interface{}{"event_type": "checkin", "payload": map[string]interface{}{"user_id": 1, "date": "2024-01-01"}},
+		"task_cond_expr": checkinCondExpr,
+	}, &output))
+	assert.True(t, output.Valid)
+	assert.Equal(t, valueobject.TaskTypeCheckin, output.TaskType)
+}
+
+func TestExpressionCache(t *testing.T) {
+	ctx := context.Background()
+
+	// 触发路径：同一任务的后续事件只命中缓存，不再解析
+	container := setupContainer()
+	userID := int64(80014)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeCommentTimes, 10, "LENGTH_GTE(comment_length, 1)")
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	triggerEvent(t, container, &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 1, AuthorID: 70100, Text: "好"}, def)
+	engine := container.RuleEngine.(*rule_engine.GovaluateAdapter)
+	before := engine.CacheStats()
+	for commentID := int64(2); commentID <= 4; commentID++ {
+		triggerEvent(t, container, &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: commentID, AuthorID: 70100, Text: "好"}, def)
+	}
+	after := engine.CacheStats()
+	assert.Equal(t, before.Misses, after.Misses, "后续事件不应重新解析表达式")
+	assert.Equal(t, before.Hits+3, after.Hits)
+}
+
+func TestExpressionTrace(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.NewDefaultConfig()
+	cfg.RuleEngine.TraceSampleRate = 1
+	container := NewContainerWithConfig(cfg)
+
+	userID := int64(80015)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypePublishTimes, 3, publishCondExpr)
+	task, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	output := triggerEvent(t, container, &dto.PublishEventDTO{UserID: userID, ContentID: 1, TopicIDs: []uint64{1001}, LikeCount: 5, IsAudited: true}, def)
+	assert.Equal(t, 0, output.Progress)
+
+	traces, err := container.QueryTraceUC.Execute(ctx, dto.QueryTraceInput{UserID: userID})
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+	trace := traces[0]
+	assert.False(t, trace.Reach)
+	assert.Equal(t, task.ID, trace.TaskID)
+	assert.Equal(t, "publish:80015:1", trace.UniqueFlag)
+	assert.Equal(t, "LIKE_COUNT_GTE(like_count, 10)", trace.FailedClause, "应定位到未通过的子表达式")
+
+	root := trace.Trace
+	assert.Equal(t, valueobject.TraceNodeAnd, root.Kind)
+	require.Len(t, root.Children, 3)
+	assert.True(t, root.Children[0].Passed())
+	likeClause := root.Children[1]
+	assert.Equal(t, 5.0, likeClause.Inputs["like_count"])
+	require.Len(t, likeClause.Children, 1)
+	assert.Equal(t, valueobject.TraceNodeFunction, likeClause.Children[0].Kind)
+	assert.Equal(t, "LIKE_COUNT_GTE", likeClause.Children[0].Expr)
+	assert.Equal(t, []interface{}{5.0, 10.0}, likeClause.Children[0].Args)
+	assert.Equal(t, false, likeClause.Children[0].Result)
+	assert.True(t, root.Children[2].Passed(), "短路后的子表达式也应求值")
+
+	triggerEvent(t, container, &dto.PublishEventDTO{UserID: userID, ContentID: 2, TopicIDs: []uint64{1001}, LikeCount: 12, IsAudited: true}, def)
+	traces, err = container.QueryTraceUC.Execute(ctx, dto.QueryTraceInput{TaskID: task.ID})
+	require.NoError(t, err)
+	require.Len(t, traces, 2)
+	assert.True(t, traces[0].Reach, "最新的轨迹在前")
+	assert.Empty(t, traces[0].FailedClause)
+
+	// 逻辑或、逻辑非与括号分组
+	root, err = container.RuleEngine.Explain(ctx, "(like_count > 10 || comment_count > 0) && !(is_audited)", nil,
+		valueobject.ExpressionArguments{"like_count": 12.0, "comment_count": 0.0, "is_audited": true})
+	require.NoError(t, err)
+	require.Len(t, root.Children, 2)
+	assert.Equal(t, valueobject.TraceNodeOr, root.Children[0].Kind)
+	assert.True(t, root.Children[0].Passed())
+	assert.Equal(t, valueobject.TraceNodeNot, root.Children[1].Kind)
+	assert.Equal(t, "!(is_audited)", root.FirstFailed().Expr)
+
+	// HTTP 查询
+	taskHandler := handler.NewTaskHandler(container.TriggerTaskUC, container.CreateTaskUC, container.QueryTaskUC, container.TaskModeRegistry)
+	rewardHandler := handler.NewRewardHandler(container.QueryRewardUC, container.QueryBudgetUC)
+	server := httptest.NewServer(router.NewRouter(taskHandler, rewardHandler, newAdminHandler(container)))
+	defer server.Close()
+
+	var httpTraces []*dto.ExpressionTraceOutput
+	require.Equal(t, http.StatusOK, adminCall(t, server, fmt.Sprintf("/api/v1/admin/expression/trace?user_id=%d&limit=1", userID), nil, &httpTraces))
+	require.Len(t, httpTraces, 1)
+	assert.Equal(t, valueobject.TraceNodeAnd, httpTraces[0].Trace.Kind)
+
+	// 采样率为 0 时不记录轨迹
+	cfg = config.NewDefaultConfig()
+	cfg.RuleEngine.TraceSampleRate = 0
+	container = NewContainerWithConfig(cfg)
+	def = createTaskDefinition(t, container, 1, valueobject.TaskTypePublishTimes, 3, publishCondExpr)
+	_, err = container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+	triggerEvent(t, container, &dto.PublishEventDTO{UserID: userID, ContentID: 1, TopicIDs: []uint64{1001}, LikeCount: 5, IsAudited: true}, def)
+	traces, err = container.QueryTraceUC.Execute(ctx, dto.QueryTraceInput{UserID: userID})
+	require.NoError(t, err)
+	assert.Empty(t, traces)
+}
+
+func TestFunctionRegistry(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+	registry := container.FunctionRegistry
+
+	// 按签名校验参数类型与个数
+	likeCountGte, ok := registry.Get("LIKE_COUNT_GTE")
+	require.True(t, ok)
+	assert.Equal(t, "LIKE_COUNT_GTE(like_count number, min number) bool", likeCountGte.Signature())
+	result, err := likeCountGte.Call(12.0, 10.0)
+	require.NoError(t, err)
+	assert.Equal(t, true, result)
+	_, err = likeCountGte.Call(12.0, "10")
+	assert.ErrorIs(t, err, function.ErrArgumentType)
+	_, err = likeCountGte.Call(12.0)
+	assert.ErrorIs(t, err, function.ErrArgumentType)
+
+	channelIn, ok := registry.Get("CHANNEL_IN")
+	require.True(t, ok)
+	result, err = channelIn.Call("weibo", "wechat", "weibo")
+	require.NoError(t, err)
+	assert.Equal(t, true, result)
+	_, err = channelIn.Call("weibo", "wechat", 1.0)
+	assert.ErrorIs(t, err, function.ErrArgumentType, "可变参数也应校验类型")
+
+	// 表达式求值时参数类型错误应报错，而不是 panic
+	reach, err := container.RuleEngine.Evaluate(ctx, "LIKE_COUNT_GTE(like_count, 'ten')",
+		registry.Functions([]string{"LIKE_COUNT_GTE"}), valueobject.ExpressionArguments{"like_count": 12.0})
+	assert.Error(t, err)
+	assert.False(t, reach)
+
+	// 同一组函数返回同一个函数表，保证表达式缓存命中
+	functions := registry.Functions([]string{"IS_AUDITED", "WITH_ANY_TOPIC"})
+	assert.Len(t, functions, 2)
+	assert.Equal(t, fmt.Sprintf("%p", functions), fmt.Sprintf("%p", registry.Functions([]string{"WITH_ANY_TOPIC", "IS_AUDITED"})))
+
+	// 注册自定义函数
+	assert.Error(t, registry.Register(&function.Definition{Name: "IS_AUDITED", Returns: function.TypeBool, Impl: likeCountGte.Impl}), "函数名不能重复")
+	require.NoError(t, registry.Register(&function.Definition{
+		Name:    "TOPIC_COUNT_GTE",
+		Params:  []function.Param{{Name: "tag_ids", Type: function.TypeIDList}, {Name: "min", Type: function.TypeNumber}},
+		Returns: function.TypeBool,
+		Doc:     "携带的话题数大于等于指定值",
+		Example: "TOPIC_COUNT_GTE(tag_ids, 2)",
+		Impl: func(args ...interface{}) (interface{}, error) {
+			return float64(len(args[0].([]uint64))) >= args[1].(float64), nil
+		},
+	}))
+	reach, err = container.RuleEngine.Evaluate(ctx, "TOPIC_COUNT_GTE(tag_ids, 2)",
+		registry.Functions([]string{"TOPIC_COUNT_GTE"}), valueobject.ExpressionArguments{"tag_ids": []uint64{1, 2}})
+	require.NoError(t, err)
+	assert.True(t, reach)
+
+	// HTTP 查询：按任务类型限定可用函数
+	taskHandler := handler.NewTaskHandler(container.TriggerTaskUC, container.CreateTaskUC, container.QueryTaskUC, container.TaskModeRegistry)
+	rewardHandler := handler.NewRewardHandler(container.QueryRewardUC, container.QueryBudgetUC)
+	server := httptest.NewServer(router.NewRouter(taskHandler, rewardHandler, newAdminHandler(container)))
+	defer server.Close()
+
+	var all []*dto.FunctionOutput
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/expression/functions", nil, &all))
+	names := make(map[string]*dto.FunctionOutput)
+	for _, fn := range all {
+		names[fn.Name] = fn
+	}
+	require.Contains(t, names, "AUTHOR_IN")
+	assert.ElementsMatch(t, []valueobject.TaskType{valueobject.TaskTypeShareTimes, valueobject.TaskTypeLikeTimes, valueobject.TaskTypeCommentTimes},
+		names["AUTHOR_IN"].TaskTypes)
+	assert.True(t, names["AUTHOR_IN"].Variadic)
+	assert.Contains(t, names, "TOPIC_COUNT_GTE")
+
+	var publish []*dto.FunctionOutput
+	require.Equal(t, http.StatusOK, adminCall(t, server, "/api/v1/admin/expression/functions?task_type="+string(valueobject.TaskTypePublishTimes), nil, &publish))
+	publishNames := make([]string, 0, len(publish))
+	for _, fn := range publish {
+		publishNames = append(publishNames, fn.Name)
+	}
+	assert.Equal(t, []string{"IS_AUDITED", "LIKE_COUNT_GTE", "WITH_ANY_TOPIC"}, publishNames)
+	assert.Equal(t, http.StatusBadRequest, adminCall(t, server, "/api/v1/admin/expression/functions?task_type=unknown", nil, nil))
+}
+
+func TestTimeFunctions(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	// 2026-03-01 周日 23:30 UTC，即上海时间 2026-03-02 周一 07:30
+	clock := function.NewFixedClock(time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC))
+	registry := function.NewBuiltinRegistryWithClock(clock)
+	checkin := &dto.CheckinEventDTO{}
+	functions := registry.Functions(checkin.GetExpressionFunctions())
+	eval := func(expr string, args valueobject.ExpressionArguments) (bool, error) {
+		return container.RuleEngine.Evaluate(ctx, expr, functions, args)
+	}
+	args := func(date, tz string) valueobject.ExpressionArguments {
+		return valueobject.ExpressionArguments{"date": date, "timezone": tz}
+	}
+
+	cases := []struct {
+		expr string
+		args valueobject.ExpressionArguments
+		want bool
+	}{
+		{"IS_TODAY(date, timezone)", args("2026-03-01", "UTC"), true},
+		{"IS_TODAY(date, timezone)", args("2026-03-01", "Asia/Shanghai"), false},
+		{"IS_TODAY(date, timezone)", args("2026-03-02", "Asia/Shanghai"), true},
+		{"DAYS_SINCE(date, timezone) == 1", args("2026-03-01", "Asia/Shanghai"), true},
+		{"DAYS_SINCE(date, timezone) == -1", args("2026-03-02", "UTC"), true},
+		{"DAYS_SINCE(date, timezone) == 28", args("2026-02-01", "UTC"), true},
+		{"HOUR_BETWEEN(7, 8, timezone)", args("", "Asia/Shanghai"), true},
+		{"HOUR_BETWEEN(8, 22, timezone)", args("", "Asia/Shanghai"), false},
+		{"HOUR_BETWEEN(22, 6, timezone)", args("", "UTC"), true},
+		{"WEEKDAY_IN(timezone, 6, 7)", args("", "UTC"), true},
+		{"WEEKDAY_IN(timezone, 6, 7)", args("", "Asia/Shanghai"), false},
+		{"WEEKDAY_IN(timezone, 1)", args("", "Asia/Shanghai"), true},
+	}
+	for _, tc := range cases {
+		got, err := eval(tc.expr, tc.args)
+		require.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.want, got, "%s %v", tc.expr, tc.args)
+	}
+
+	// 时钟前进后结果随之变化
+	clock.Advance(time.Hour)
+	got, err := eval("IS_TODAY(date, timezone)", args("2026-03-01", "UTC"))
+	require.NoError(t, err)
+	assert.False(t, got)
+
+	for _, expr := range []string{"IS_TODAY(date, timezone)", "WEEKDAY_IN(timezone, 8)", "HOUR_BETWEEN(0, 25, timezone)"} {
+		_, err := eval(expr, args("2026/03/01", "Mars/Olympus"))
+		assert.Error(t, err, expr)
+	}
+	_, err = eval("WITHIN_ACTIVITY()", nil)
+	assert.Error(t, err, "没有活动时间窗口时应报错")
+
+	// 按活动绑定时间窗口，同一活动复用同一个函数集
+	window := function.Env{ActivityID: 1, ActivityStart: clock.Now().Add(-time.Hour), ActivityEnd: clock.Now().Add(time.Hour)}
+	bound := registry.FunctionsWithEnv(checkin.GetExpressionFunctions(), window)
+	assert.Equal(t, fmt.Sprintf("%p", bound), fmt.Sprintf("%p", registry.FunctionsWithEnv(checkin.GetExpressionFunctions(), window)))
+	assert.NotEqual(t, fmt.Sprintf("%p", bound), fmt.Sprintf("%p", functions))
+	got, err = container.RuleEngine.Evaluate(ctx, "WITHIN_ACTIVITY()", bound, nil)
+	require.NoError(t, err)
+	assert.True(t, got)
+
+	// 与活动的 IsActive 一致，结束时刻已不在窗口内
+	clock.Set(window.ActivityEnd)
+	got, err = container.RuleEngine.Evaluate(ctx, "WITHIN_ACTIVITY()", bound, nil)
+	require.NoError(t, err)
+	assert.False(t, got)
+
+	// 活动时间窗口调整后重建该活动的函数集
+	window.ActivityEnd = window.ActivityEnd.Add(time.Hour)
+	extended := registry.FunctionsWithEnv(checkin.GetExpressionFunctions(), window)
+	assert.NotEqual(t, fmt.Sprintf("%p", bound), fmt.Sprintf("%p", extended))
+	got, err = container.RuleEngine.Evaluate(ctx, "WITHIN_ACTIVITY()", extended, nil)
+	require.NoError(t, err)
+	assert.True(t, got)
+
+	// 触发签到：只有今天的签到计入进度
+	activity := &entity.ActActivity{
+		Name:      "Checkin Activity",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+		Status:    entity.ActivityStatusActive,
+	}
+	require.NoError(t, container.ActivityRepo.Create(ctx, activity))
+	userID := int64(80016)
+	def := createTaskDefinition(t, container, activity.ID, valueobject.TaskTypeCheckin, 1, "IS_TODAY(date, timezone) && WITHIN_ACTIVITY()")
+	_, err = container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: activity.ID, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	tz := "Asia/Shanghai"
+	loc, err := time.LoadLocation(tz)
+	require.NoError(t, err)
+	now := testClock(container).Now().In(loc)
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	task := triggerEvent(t, container, &dto.CheckinEventDTO{UserID: userID, Date: yesterday, Timezone: tz}, def)
+	assert.Equal(t, "pending", task.Status, "补签昨天不应计入")
+	task = triggerEvent(t, container, &dto.CheckinEventDTO{UserID: userID, Date: now.Format("2006-01-02"), Timezone: tz}, def)
+	assert.Equal(t, "done", task.Status)
+
+	assert.Error(t, (&dto.CheckinEventDTO{UserID: userID, Date: yesterday, Timezone: "Mars/Olympus"}).Validate())
+
+	// 活动过滤同样按注入的时钟判断：时钟到达结束时间后事件不再计入，与 WITHIN_ACTIVITY 一致
+	userID = 80034
+	def = createTaskDefinition(t, container, activity.ID, valueobject.TaskTypeCommentTimes, 10, "LENGTH_GTE(comment_length, 1)")
+	_, err = container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: activity.ID, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+	task = triggerEvent(t, container, &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 1, AuthorID: 70100, Text: "好"}, def)
+	assert.Equal(t, 1, task.Progress)
+	testClock(container).Set(activity.EndTime)
+	err = container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+		TaskMode: &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 2, AuthorID: 70100, Text: "好"},
+	})
+	assert.ErrorIs(t, err, entity.ErrActivityNotActive)
+	assert.Equal(t, 1, findTaskOutput(t, container, userID, def).Progress, "活动已结束时不应计入进度")
+}
+
+func TestTaskParameters(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	activity := &entity.ActActivity{
+		Name:      "Topic Activity",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+		Status:    entity.ActivityStatusActive,
+	}
+	require.NoError(t, container.ActivityRepo.Create(ctx, activity))
+
+	condExpr := "WITH_ANY_TOPIC(tag_ids, required_tag_ids) && LIKE_COUNT_GTE(like_count, min_likes)"
+	newInput := func(name string, topics []interface{}, minLikes interface{}) dto.TaskDefinitionInput {
+		return dto.TaskDefinitionInput{
+			ActivityID:   activity.ID,
+			Name:         name,
+			TaskType:     valueobject.TaskTypePublishTimes,
+			TaskCondExpr: condExpr,
+			Parameters: []dto.TaskParameterDTO{
+				{Name: "required_tag_ids", Type: valueobject.TaskParameterIDList, Value: topics},
+				{Name: "min_likes", Type: valueobject.TaskParameterNumber, Value: minLikes},
+			},
+			Target:      1,
+			RewardValue: 1,
+		}
+	}
+
+	// 两个发布任务要求不同的话题和点赞门槛
+	travel, err := container.ManageTaskDefUC.ExecuteCreate(ctx, newInput("旅行话题", []interface{}{1001.0}, 10.0))
+	require.NoError(t, err)
+	food, err := container.ManageTaskDefUC.ExecuteCreate(ctx, newInput("美食话题", []interface{}{2001.0, 2002.0}, 0.0))
+	require.NoError(t, err)
+	require.Len(t, food.Parameters, 2)
+	assert.Equal(t, []uint64{2001, 2002}, food.Parameters[0].Value)
+
+	userID := int64(80017)
+	for _, def := range []*dto.TaskDefinitionOutput{travel, food} {
+		_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: activity.ID, TaskID: def.ID, UserID: userID})
+		require.NoError(t, err)
+	}
+	require.NoError(t, container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+		TaskMode: &dto.PublishEventDTO{UserID: userID, ContentID: 1, TopicIDs: []uint64{2002}, LikeCount: 3},
+	}))
+
+	tasks, err := container.QueryTaskUC.ExecuteList(ctx, userID)
+	require.NoError(t, err)
+	status := make(map[int64]string)
+	for _, task := range tasks {
+		status[task.TaskID] = task.Status
+	}
+	assert.Equal(t, "pending", status[travel.ID], "话题不匹配的任务不应达成")
+	assert.Equal(t, "done", status[food.ID])
+
+	// 同一事件同时满足两个任务时，两个任务都应推进
+	bothUserID := int64(80028)
+	for _, def := range []*dto.TaskDefinitionOutput{travel, food} {
+		_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: activity.ID, TaskID: def.ID, UserID: bothUserID})
+		require.NoError(t, err)
+	}
+	require.NoError(t, container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+		TaskMode: &dto.PublishEventDTO{UserID: bothUserID, ContentID: 2, TopicIDs: []uint64{1001, 2001}, LikeCount: 12},
+	}))
+	tasks, err = container.QueryTaskUC.ExecuteList(ctx, bothUserID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	for _, task := range tasks {
+		assert.Equal(t, "done", task.Status, "task %d", task.TaskID)
+	}
+
+	// 参数取值类型不匹配
+	_, err = container.ManageTaskDefUC.ExecuteCreate(ctx, newInput("bad topics", []interface{}{"travel"}, 10.0))
+	assert.Error(t, err)
+	_, err = container.ManageTaskDefUC.ExecuteCreate(ctx, newInput("bad threshold", []interface{}{1001.0}, "10"))
+	assert.Error(t, err)
+
+	// 未配置的参数不能在表达式中引用，任务参数也不能覆盖事件参数
+	input := newInput("missing param", []interface{}{1001.0}, 10.0)
+	input.Parameters = input.Parameters[:1]
+	_, err = container.ManageTaskDefUC.ExecuteCreate(ctx, input)
+	assert.ErrorIs(t, err, valueobject.ErrInvalidExpression)
+	input = newInput("conflict", []interface{}{1001.0}, 10.0)
+	input.Parameters = append(input.Parameters, dto.TaskParameterDTO{Name: "like_count", Type: valueobject.TaskParameterNumber, Value: 100.0})
+	_, err = container.ManageTaskDefUC.ExecuteCreate(ctx, input)
+	assert.ErrorIs(t, err, valueobject.ErrInvalidExpression)
+	input = newInput("duplicate", []interface{}{1001.0}, 10.0)
+	input.Parameters = append(input.Parameters, input.Parameters[1])
+	_, err = container.ManageTaskDefUC.ExecuteCreate(ctx, input)
+	assert.Error(t, err)
+
+	// 试运行使用任务参数
+	output, err := container.DryRunExpressionUC.Execute(ctx, dto.DryRunExpressionInput{
+		Event:        dto.TriggerEventEnvelope{EventType: dto.EventTypePublish, Payload: []byte(`{"user_id":1,"content_id":1,"topic_ids":[1001],"like_count":12}`)},
+		TaskCondExpr: condExpr,
+		Parameters:   newInput("dry run", []interface{}{1001.0}, 10.0).Parameters,
+	})
+	require.NoError(t, err)
+	assert.True(t, output.Valid)
+	assert.True(t, output.Reach)
+	assert.Equal(t, 10.0, output.Arguments["min_likes"])
+}
+
+func TestSandboxRuleEngine(t *testing.T) {
+	ctx := context.Background()
+
+	// 通过配置切换引擎，触发与建任务校验走同一套端口
+	cfg := config.NewDefaultConfig()
+	cfg.RuleEngine.Engine = rule_engine.EngineSandbox
+	container := NewContainerWithConfig(cfg)
+
+	userID := int64(80018)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypePublishTimes, 3, publishCondExpr)
+	_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+	output := triggerEvent(t, container, &dto.PublishEventDTO{UserID: userID, ContentID: 1, TopicIDs: []uint64{1001}, LikeCount: 12, IsAudited: true}, def)
+	assert.Equal(t, 1, output.Progress)
+
+	activity := &entity.ActActivity{
+		Name:      "Sandbox Activity",
+		StartTime: time.Now().Add(-time.Hour),
+		EndTime:   time.Now().Add(24 * time.Hour),
+		Status:    entity.ActivityStatusActive,
+	}
+	require.NoError(t, container.ActivityRepo.Create(ctx, activity))
+	for _, expr := range []string{"LIKE_COUNT_GTE(like_count, 'ten')", "like_count && is_audited"} {
+		_, err = container.ManageTaskDefUC.ExecuteCreate(ctx, dto.TaskDefinitionInput{
+			ActivityID:   activity.ID,
+			Name:         "sandbox task",
+			TaskType:     valueobject.TaskTypePublishTimes,
+			TaskCondExpr: expr,
+			Parameters:   publishParams,
+			Target:       1,
+			RewardValue:  1,
+		})
+		assert.ErrorIs(t, err, valueobject.ErrInvalidExpression, expr)
+	}
+}
+
+func TestLockFencingToken(t *testing.T) {
+	ctx := context.Background()
+
+	// 隔离令牌：令牌早于已存储值的写入被仓储拒绝
+	container := setupContainer()
+	userID := int64(80019)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeCommentTimes, 10, "LENGTH_GTE(comment_length, 1)")
+	created, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+	triggerEvent(t, container, &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 1, AuthorID: 70100, Text: "好"}, def)
+
+	stored, err := container.TaskRepo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.NotZero(t, stored.FencingToken)
+	stale := *stored
+	stale.FencingToken--
+	stale.Progress = 0
+	assert.ErrorIs(t, container.TaskRepo.Update(ctx, &stale), repository.ErrStaleFencingToken)
+	stored, err = container.TaskRepo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Progress)
+
+	// 同一用户的并发事件依次等待锁，均计入进度
+	var wg sync.WaitGroup
+	for commentID := int64(2); commentID <= 3; commentID++ {
+		wg.Add(1)
+		go func(commentID int64) {
+			defer wg.Done()
+			assert.NoError(t, container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+				TaskMode: &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: commentID, AuthorID: 70100, Text: "好"},
+			}))
+		}(commentID)
+	}
+	wg.Wait()
+	assert.Equal(t, 3, findTaskOutput(t, container, userID, def).Progress)
+}
+
+// cancelObserver 收到任务明细时取消请求，模拟处理过程中客户端断开
+type cancelObserver struct {
+	cancel context.CancelFunc
+}
+
+func (o *cancelObserver) OnTaskDetailCreated(ctx context.Context, detail *entity.ActUserTaskDetail) error {
+	o.cancel()
+	return nil
+}
+
+func (o *cancelObserver) OnTaskCompleted(ctx context.Context, task *entity.ActUserTask) error {
+	return nil
+}
+
+func (o *cancelObserver) GetObserverName() string {
+	return "cancel_observer"
+}
+
+func TestRedisLockBackend(t *testing.T) {
+	ctx := context.Background()
+	server, err := resptest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	// 容器通过配置使用 Redis 锁，同一用户的并发事件依次处理
+	cfg := config.NewDefaultConfig()
+	cfg.Lock.Backend = infrastructure.BackendRedis
+	cfg.Lock.RedisAddr = server.Addr()
+	cfg.Lock.PollInterval = 5 * time.Millisecond
+	container := NewContainerWithConfig(cfg)
+
+	userID := int64(80020)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeCommentTimes, 10, "LENGTH_GTE(comment_length, 1)")
+	_, err = container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for commentID := int64(1); commentID <= 3; commentID++ {
+		wg.Add(1)
+		go func(commentID int64) {
+			defer wg.Done()
+			assert.NoError(t, container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+				TaskMode: &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: commentID, AuthorID: 70100, Text: "好"},
+			}))
+		}(commentID)
+	}
+	wg.Wait()
+	assert.Equal(t, 3, findTaskOutput(t, container, userID, def).Progress)
+
+	// 处理过程中请求被取消（客户端断开），锁仍被释放，后续事件无需等到过期
+	cancelCtx, cancel := context.WithCancel(ctx)
+	container.ObserverRegistry.Register(&cancelObserver{cancel: cancel})
+	_ = container.TriggerTaskUC.Execute(cancelCtx, dto.TriggerTaskInput{
+		TaskMode: &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 4, AuthorID: 70100, Text: "好"},
+	})
+	require.Error(t, cancelCtx.Err())
+	ok, lease, err := container.DistributedLock.TryLock(ctx, fmt.Sprintf("task_lock:%d:%s", userID, valueobject.TaskTypeCommentTimes), time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "请求取消后锁应已释放")
+	require.NoError(t, container.DistributedLock.Unlock(ctx, lease))
+}
+
+func TestLockContentionPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	// setup 按策略创建容器，并为用户创建评论任务
+	setup := func(userID int64, adjust func(cfg *config.TaskConfig)) (*Container, *entity.ActTaskDefinition) {
+		cfg := config.NewDefaultConfig()
+		adjust(&cfg.Task)
+		container := NewContainerWithConfig(cfg)
+		def := createTaskDefinition(t, container, 1, valueobject.TaskTypeCommentTimes, 10, "LENGTH_GTE(comment_length, 1)")
+		_, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+		require.NoError(t, err)
+		return container, def
+	}
+	comment := func(userID, commentID int64) dto.TriggerTaskInput {
+		return dto.TriggerTaskInput{TaskMode: &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: commentID, AuthorID: 70100, Text: "好"}}
+	}
+	// holdUserLock 模拟其他实例正在处理该用户的评论事件
+	holdUserLock := func(container *Container, userID int64) *output.Lease {
+		lease, err := container.DistributedLock.Lock(ctx, fmt.Sprintf("task_lock:%d:%s", userID, valueobject.TaskTypeCommentTimes), time.Minute)
+		require.NoError(t, err)
+		return lease
+	}
+	releaseAfter := func(container *Container, lease *output.Lease, delay time.Duration) {
+		go func() {
+			time.Sleep(delay)
+			assert.NoError(t, container.DistributedLock.Unlock(ctx, lease))
+		}()
+	}
+
+	_, err := task.ParseLockPolicy("fifo")
+	assert.Error(t, err)
+
+	// wait：等待超时后返回繁忙，锁在等待期间释放则继续处理
+	userID := int64(80021)
+	container, def := setup(userID, func(cfg *config.TaskConfig) {
+		cfg.LockPolicy = "wait"
+		cfg.LockWaitTimeout = 100 * time.Millisecond
+	})
+	lease := holdUserLock(container, userID)
+	start := time.Now()
+	err = container.TriggerTaskUC.Execute(ctx, comment(userID, 1))
+	assert.ErrorIs(t, err, task.ErrTaskLockBusy)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	releaseAfter(container, lease, 20*time.Millisecond)
+	require.NoError(t, container.TriggerTaskUC.Execute(ctx, comment(userID, 1)))
+	assert.Equal(t, 1, findTaskOutput(t, container, userID, def).Progress)
+
+	// HTTP 接口返回 429，提示调用方稍后重试
+	lease = holdUserLock(container, userID)
+	taskHandler := handler.NewTaskHandler(container.TriggerTaskUC, container.CreateTaskUC, container.QueryTaskUC, container.TaskModeRegistry)
+	rewardHandler := handler.NewRewardHandler(container.QueryRewardUC, container.QueryBudgetUC)
+	server := httptest.NewServer(router.NewRouter(taskHandler, rewardHandler, newAdminHandler(container)))
+	defer server.Close()
+	status := adminCall(t, server, "/api/v1/task/trigger", map[string]interface{}{
+		"event_type": "comment",
+		"payload":    map[string]interface{}{"user_id": userID, "content_id": 1, "comment_id": 2, "author_id": 70100, "text": "好"},
+	}, nil)
+	assert.Equal(t, http.StatusTooManyRequests, status)
+	require.NoError(t, container.DistributedLock.Unlock(ctx, lease))
+
+	// retry：按退避间隔重试，超过重试次数后返回繁忙
+	userID = 80022
+	container, def = setup(userID, func(cfg *config.TaskConfig) {
+		cfg.LockPolicy = "retry"
+		cfg.MaxRetry = 4
+		cfg.LockRetryBackoff = 10 * time.Millisecond
+	})
+	lease = holdUserLock(container, userID)
+	err = container.TriggerTaskUC.Execute(ctx, comment(userID, 1))
+	assert.ErrorIs(t, err, task.ErrTaskLockBusy)
+
+	releaseAfter(container, lease, 20*time.Millisecond)
+	require.NoError(t, container.TriggerTaskUC.Execute(ctx, comment(userID, 1)))
+	assert.Equal(t, 1, findTaskOutput(t, container, userID, def).Progress)
+
+	// queue：同一用户的事件按到达顺序处理，队列满时返回繁忙
+	userID = 80023
+	container, def = setup(userID, func(cfg *config.TaskConfig) {
+		cfg.LockPolicy = "queue"
+		cfg.LockQueueSize = 3
+	})
+	lease = holdUserLock(container, userID)
+
+	var wg sync.WaitGroup
+	for commentID := int64(1); commentID <= 3; commentID++ {
+		wg.Add(1)
+		go func(commentID int64) {
+			defer wg.Done()
+			assert.NoError(t, container.TriggerTaskUC.Execute(ctx, comment(userID, commentID)))
+		}(commentID)
+		time.Sleep(10 * time.Millisecond) // 保证到达顺序
+	}
+	err = container.TriggerTaskUC.Execute(ctx, comment(userID, 4))
+	assert.ErrorIs(t, err, task.ErrTaskLockBusy, "排队事件数达到上限")
+
+	require.NoError(t, container.DistributedLock.Unlock(ctx, lease))
+	wg.Wait()
+
+	output := findTaskOutput(t, container, userID, def)
+	assert.Equal(t, 3, output.Progress)
+	details, err := container.TaskDetailRepo.ListByTaskID(ctx, output.ID)
+	require.NoError(t, err)
+	sort.Slice(details, func(i, j int) bool { return details[i].ID < details[j].ID })
+	var flags []string
+	for _, detail := range details {
+		flags = append(flags, detail.UniqueFlag)
+	}
+	assert.Equal(t, []string{"comment:80023:1", "comment:80023:2", "comment:80023:3"}, flags, "应按到达顺序处理")
+}
+
+// conflictingTaskRepo 在 UpdateBatch 写入前执行 beforeUpdate，模拟锁过期期间其他实例的并发写入
+type conflictingTaskRepo struct {
+	*memory.TaskRepositoryMemory
+	beforeUpdate func()
+}
+
+func (r *conflictingTaskRepo) UpdateBatch(ctx context.Context, tasks []*entity.ActUserTask) error {
+	r.beforeUpdate()
+	return r.TaskRepositoryMemory.UpdateBatch(ctx, tasks)
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	ctx := context.Background()
+	container := setupContainer()
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeCommentTimes, 10, "LENGTH_GTE(comment_length, 1)")
+
+	// 用户任务：基于旧版本的写入被拒绝
+	userID := int64(80024)
+	created, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+	first, err := container.TaskRepo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), first.Version)
+	second := *first
+
+	first.Progress = 1
+	require.NoError(t, container.TaskRepo.Update(ctx, first))
+	assert.Equal(t, int64(2), first.Version)
+
+	second.Progress = 5
+	err = container.TaskRepo.Update(ctx, &second)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+	var conflict *repository.VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, created.ID, conflict.ID)
+	assert.Equal(t, int64(1), conflict.Expected)
+	assert.Equal(t, int64(2), conflict.Actual)
+	stored, err := container.TaskRepo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Progress)
+
+	// 活动：同样按版本号比较后写入
+	activity := &entity.ActActivity{Name: "版本测试", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+	require.NoError(t, container.ActivityRepo.Create(ctx, activity))
+	assert.Equal(t, int64(1), activity.Version)
+	staleActivity := *activity
+	require.NoError(t, container.ActivityRepo.Update(ctx, activity))
+	assert.Equal(t, staleActivity.Version+1, activity.Version)
+	assert.ErrorIs(t, container.ActivityRepo.Update(ctx, &staleActivity), repository.ErrVersionConflict)
+
+	// 触发任务：写入前其他实例计入了另一条评论，冲突后基于最新进度重试，两条评论各计一次
+	newTriggerUC := func(taskRepo repository.TaskRepository, traceSampleRate float64) *task.TriggerTaskUseCase {
+		return task.NewTriggerTaskUseCase(
+			taskRepo, container.TaskDefRepo, container.TaskDetailRepo, container.TaskPeriodRepo,
+			container.ActivityRepo, container.RewardRepo, container.RewardLedgerRepo, container.BudgetRepo,
+			container.TraceRepo, container.RuleEngine, container.FunctionRegistry, container.ObserverRegistry,
+			container.DistributedLock, container.RiskCheckService, 30, traceSampleRate, task.LockContention{},
+		)
+	}
+	comment := func(userID, commentID int64) dto.TriggerTaskInput {
+		return dto.TriggerTaskInput{TaskMode: &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: commentID, AuthorID: 70100, Text: "好"}}
+	}
+
+	userID = 80025
+	created, err = container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+	// concurrentWrite 模拟其他实例基于最新版本计入一次进度
+	concurrentWrite := func() {
+		stored, err := container.TaskRepo.GetByID(ctx, created.ID)
+		require.NoError(t, err)
+		stored.AddProgress(def, 1)
+		require.NoError(t, container.TaskRepo.Update(ctx, stored))
+	}
+	concurrent := 0
+	repo := &conflictingTaskRepo{TaskRepositoryMemory: container.TaskRepo}
+	repo.beforeUpdate = func() {
+		if concurrent == 0 {
+			concurrent++
+			concurrentWrite()
+		}
+	}
+	triggerUC := newTriggerUC(repo, 0)
+	require.NoError(t, triggerUC.Execute(ctx, comment(userID, 1)))
+	assert.Equal(t, 2, findTaskOutput(t, container, userID, def).Progress)
+	details, err := container.TaskDetailRepo.ListByTaskID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Len(t, details, 1, "冲突时写入的明细应被撤销")
+
+	// 重复事件仍按唯一标识幂等处理
+	require.NoError(t, triggerUC.Execute(ctx, comment(userID, 1)))
+	assert.Equal(t, 2, findTaskOutput(t, container, userID, def).Progress)
+
+	// 持续冲突：超过重试次数后返回冲突错误，本次事件不计入进度也不留下明细
+	repo.beforeUpdate = func() {
+		concurrentWrite()
+	}
+	err = triggerUC.Execute(ctx, comment(userID, 2))
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+	stored, err = container.TaskRepo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 6, stored.Progress, "仅计入 4 次并发写入")
+	details, err = container.TaskDetailRepo.ListByTaskID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Len(t, details, 1)
+
+	// 冲突重试时不重复记录求值轨迹
+	userID = 80033
+	created, err = container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+	concurrent = 0
+	repo.beforeUpdate = func() {
+		if concurrent == 0 {
+			concurrent++
+			concurrentWrite()
+		}
+	}
+	require.NoError(t, newTriggerUC(repo, 1).Execute(ctx, comment(userID, 1)))
+	assert.Equal(t, 2, findTaskOutput(t, container, userID, def).Progress)
+	traces, err := container.QueryTraceUC.Execute(ctx, dto.QueryTraceInput{UserID: userID})
+	require.NoError(t, err)
+	assert.Len(t, traces, 1)
+}
+
+func TestMissingTaskDefinition(t *testing.T) {
+	container := setupContainer()
+	ctx := context.Background()
+
+	// 任务定义缺失的用户任务被跳过，不影响同类型的其他任务
+	userID := int64(80026)
+	def := createTaskDefinition(t, container, 1, valueobject.TaskTypeCommentTimes, 10, "LENGTH_GTE(comment_length, 1)")
+	created, err := container.CreateTaskUC.Execute(ctx, dto.CreateTaskInput{ActivityID: 1, TaskID: def.ID, UserID: userID})
+	require.NoError(t, err)
+	orphan := &entity.ActUserTask{ActivityID: 1, TaskID: 999999, UserID: userID, TaskType: valueobject.TaskTypeCommentTimes, Status: entity.TaskStatusPending}
+	require.NoError(t, container.TaskRepo.Create(ctx, orphan))
+
+	require.NoError(t, container.TriggerTaskUC.Execute(ctx, dto.TriggerTaskInput{
+		TaskMode: &dto.CommentEventDTO{UserID: userID, ContentID: 1, CommentID: 1, AuthorID: 70100, Text: "好"},
+	}))
+	stored, err := container.TaskRepo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Progress)
+	stored, err = container.TaskRepo.GetByID(ctx, orphan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.Progress)
+
+	// 查询任务列表时同样跳过，不影响其他任务的展示
+	tasks, err := container.QueryTaskUC.ExecuteList(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, created.ID, tasks[0].ID)
+}
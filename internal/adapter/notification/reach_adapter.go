@@ -53,11 +53,11 @@ func (a *NotificationAdapter) SendTaskCompletedNotification(ctx context.Context,
 }
 
 // SendTaskProgressNotification 发送任务进度通知
-func (a *NotificationAdapter) SendTaskProgressNotification(ctx context.Context, userID int64, task *entity.ActUserTask) error {
+func (a *NotificationAdapter) SendTaskProgressNotification(ctx context.Context, userID int64, task *entity.ActUserTask, def *entity.ActTaskDefinition) error {
 	params := map[string]interface{}{
 		"task_id":  task.ID,
 		"progress": task.Progress,
-		"target":   task.Target,
+		"target":   def.Target,
 	}
 
 	return a.reachAdapter.Send(ctx, "task_progress", userID, params)
@@ -0,0 +1,66 @@
+package observer
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/domain/event"
+	"mini-sirus/internal/usecase/port/output"
+	"sync"
+)
+
+// ActivityObserverRegistry 活动观察者注册表实现
+type ActivityObserverRegistry struct {
+	mu        sync.RWMutex
+	observers map[string]output.ActivityObserver
+}
+
+// NewActivityObserverRegistry 创建活动观察者注册表
+func NewActivityObserverRegistry() *ActivityObserverRegistry {
+	return &ActivityObserverRegistry{
+		observers: make(map[string]output.ActivityObserver),
+	}
+}
+
+// Register 注册观察者
+func (r *ActivityObserverRegistry) Register(observer output.ActivityObserver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := observer.GetObserverName()
+	if name == "" {
+		return
+	}
+
+	if _, exists := r.observers[name]; exists {
+		return // 避免重复注册
+	}
+
+	r.observers[name] = observer
+}
+
+// Unregister 注销观察者
+func (r *ActivityObserverRegistry) Unregister(observerName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.observers, observerName)
+}
+
+// NotifyStatusChanged 通知所有观察者活动状态变更
+func (r *ActivityObserverRegistry) NotifyStatusChanged(ctx context.Context, changed *event.ActivityStatusChanged) error {
+	r.mu.RLock()
+	observersCopy := make([]output.ActivityObserver, 0, len(r.observers))
+	for _, obs := range r.observers {
+		observersCopy = append(observersCopy, obs)
+	}
+	r.mu.RUnlock()
+
+	for _, observer := range observersCopy {
+		if err := observer.OnActivityStatusChanged(ctx, changed); err != nil {
+			fmt.Printf("[Observer] %s failed: %v\n", observer.GetObserverName(), err)
+			return fmt.Errorf("observer %s failed: %w", observer.GetObserverName(), err)
+		}
+	}
+
+	return nil
+}
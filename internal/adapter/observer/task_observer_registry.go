@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/event"
 	"mini-sirus/internal/usecase/port/output"
 	"sync"
 )
@@ -67,3 +68,25 @@ func (r *TaskObserverRegistry) Notify(ctx context.Context, detail *entity.ActUse
 	return nil
 }
 
+// NotifyTaskUnlocked 通知所有关注任务解锁的观察者
+func (r *TaskObserverRegistry) NotifyTaskUnlocked(ctx context.Context, unlocked *event.TaskUnlocked) error {
+	r.mu.RLock()
+	observersCopy := make([]output.TaskUnlockObserver, 0, len(r.observers))
+	names := make([]string, 0, len(r.observers))
+	for name, obs := range r.observers {
+		if unlockObserver, ok := obs.(output.TaskUnlockObserver); ok {
+			observersCopy = append(observersCopy, unlockObserver)
+			names = append(names, name)
+		}
+	}
+	r.mu.RUnlock()
+
+	for i, observer := range observersCopy {
+		if err := observer.OnTaskUnlocked(ctx, unlocked); err != nil {
+			fmt.Printf("[Observer] %s failed: %v\n", names[i], err)
+			return fmt.Errorf("observer %s failed: %w", names[i], err)
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/event"
 	"mini-sirus/internal/usecase/port/output"
 )
 
@@ -49,4 +50,40 @@ func (o *CheckinReachObserver) GetObserverName() string {
 	return "checkin_reach_observer"
 }
 
+// TaskUnlockReachObserver 任务解锁触达观察者
+// 前置任务完成、后续任务解锁时提醒用户
+type TaskUnlockReachObserver struct {
+	reachService output.ReachService
+}
+
+// NewTaskUnlockReachObserver 创建任务解锁触达观察者
+func NewTaskUnlockReachObserver(reachService output.ReachService) *TaskUnlockReachObserver {
+	return &TaskUnlockReachObserver{
+		reachService: reachService,
+	}
+}
 
+// OnTaskDetailCreated 当任务明细创建时
+func (o *TaskUnlockReachObserver) OnTaskDetailCreated(ctx context.Context, detail *entity.ActUserTaskDetail) error {
+	return nil
+}
+
+// OnTaskCompleted 当任务完成时
+func (o *TaskUnlockReachObserver) OnTaskCompleted(ctx context.Context, task *entity.ActUserTask) error {
+	return nil
+}
+
+// OnTaskUnlocked 当任务解锁时
+func (o *TaskUnlockReachObserver) OnTaskUnlocked(ctx context.Context, unlocked *event.TaskUnlocked) error {
+	params := map[string]interface{}{
+		"task_id":     unlocked.TaskID,
+		"activity_id": unlocked.ActivityID,
+	}
+
+	return o.reachService.Send(ctx, "act_task_unlocked", unlocked.UserID, params)
+}
+
+// GetObserverName 获取观察者名称
+func (o *TaskUnlockReachObserver) GetObserverName() string {
+	return "task_unlock_reach_observer"
+}
@@ -2,8 +2,9 @@ package memory
 
 import (
 	"context"
-	"errors"
 	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"sort"
 	"sync"
 	"time"
 )
@@ -30,6 +31,9 @@ func (r *ActivityRepositoryMemory) Create(ctx context.Context, activity *entity.
 
 	r.idGen++
 	activity.ID = r.idGen
+	activity.CreatedAt = time.Now()
+	activity.UpdatedAt = time.Now()
+	activity.Version = 1
 
 	activityCopy := *activity
 	r.activities[activity.ID] = &activityCopy
@@ -37,15 +41,25 @@ func (r *ActivityRepositoryMemory) Create(ctx context.Context, activity *entity.
 	return nil
 }
 
-// Update 更新活动
+// Update 更新活动，版本号与已存储的不一致时拒绝写入
 func (r *ActivityRepositoryMemory) Update(ctx context.Context, activity *entity.ActActivity) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.activities[activity.ID]; !exists {
-		return errors.New("activity not found")
+	stored, exists := r.activities[activity.ID]
+	if !exists {
+		return repository.ErrActivityNotFound
+	}
+	if activity.Version != stored.Version {
+		return &repository.VersionConflictError{
+			Entity:   "activity",
+			ID:       activity.ID,
+			Expected: activity.Version,
+			Actual:   stored.Version,
+		}
 	}
 
+	activity.Version++
 	activityCopy := *activity
 	r.activities[activity.ID] = &activityCopy
 
@@ -59,13 +73,31 @@ func (r *ActivityRepositoryMemory) GetByID(ctx context.Context, activityID int64
 
 	activity, exists := r.activities[activityID]
 	if !exists {
-		return nil, errors.New("activity not found")
+		return nil, repository.ErrActivityNotFound
 	}
 
 	activityCopy := *activity
 	return &activityCopy, nil
 }
 
+// List 获取全部活动
+func (r *ActivityRepositoryMemory) List(ctx context.Context) ([]*entity.ActActivity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*entity.ActActivity, 0, len(r.activities))
+	for _, activity := range r.activities {
+		activityCopy := *activity
+		result = append(result, &activityCopy)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
 // ListActive 获取活动中的活动列表
 func (r *ActivityRepositoryMemory) ListActive(ctx context.Context) ([]*entity.ActActivity, error) {
 	r.mu.RLock()
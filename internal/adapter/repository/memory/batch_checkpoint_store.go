@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"mini-sirus/internal/usecase/port/output"
+	"sync"
+)
+
+// BatchCheckpointStoreMemory 批量任务断点存储内存实现
+type BatchCheckpointStoreMemory struct {
+	mu          sync.RWMutex
+	checkpoints map[string]*output.BatchCheckpoint
+}
+
+// NewBatchCheckpointStoreMemory 创建内存断点存储
+func NewBatchCheckpointStoreMemory() *BatchCheckpointStoreMemory {
+	return &BatchCheckpointStoreMemory{
+		checkpoints: make(map[string]*output.BatchCheckpoint),
+	}
+}
+
+// Load 加载断点
+func (s *BatchCheckpointStoreMemory) Load(ctx context.Context, jobID string) (*output.BatchCheckpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	checkpoint, exists := s.checkpoints[jobID]
+	if !exists {
+		return nil, nil
+	}
+
+	checkpointCopy := *checkpoint
+	checkpointCopy.Completed = append([]output.BatchChunk(nil), checkpoint.Completed...)
+	return &checkpointCopy, nil
+}
+
+// Save 保存断点
+func (s *BatchCheckpointStoreMemory) Save(ctx context.Context, checkpoint *output.BatchCheckpoint) error {
+	if checkpoint.JobID == "" {
+		return errors.New("job id is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpointCopy := *checkpoint
+	checkpointCopy.Completed = append([]output.BatchChunk(nil), checkpoint.Completed...)
+	s.checkpoints[checkpoint.JobID] = &checkpointCopy
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"mini-sirus/internal/domain/entity"
+	"sync"
+	"time"
+)
+
+// defaultMaxExpressionTraces 内存中最多保留的求值轨迹数量
+const defaultMaxExpressionTraces = 10000
+
+// ExpressionTraceRepositoryMemory 表达式求值轨迹仓储内存实现
+// 超出容量时丢弃最早的轨迹
+type ExpressionTraceRepositoryMemory struct {
+	mu        sync.RWMutex
+	traces    []*entity.ActExpressionTrace // 按写入顺序
+	maxTraces int
+	idGen     int64
+}
+
+// NewExpressionTraceRepositoryMemory 创建内存表达式求值轨迹仓储
+func NewExpressionTraceRepositoryMemory() *ExpressionTraceRepositoryMemory {
+	return &ExpressionTraceRepositoryMemory{
+		maxTraces: defaultMaxExpressionTraces,
+		idGen:     9000,
+	}
+}
+
+// Create 保存求值轨迹
+func (r *ExpressionTraceRepositoryMemory) Create(ctx context.Context, trace *entity.ActExpressionTrace) error {
+	if !trace.IsValid() {
+		return errors.New("invalid expression trace")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.idGen++
+	trace.ID = r.idGen
+	if trace.CreatedAt.IsZero() {
+		trace.CreatedAt = time.Now()
+	}
+
+	traceCopy := *trace
+	r.traces = append(r.traces, &traceCopy)
+	if len(r.traces) > r.maxTraces {
+		r.traces = r.traces[len(r.traces)-r.maxTraces:]
+	}
+
+	return nil
+}
+
+// ListByUserID 获取用户最近的求值轨迹
+func (r *ExpressionTraceRepositoryMemory) ListByUserID(ctx context.Context, userID int64, limit int) ([]*entity.ActExpressionTrace, error) {
+	return r.listLatest(limit, func(trace *entity.ActExpressionTrace) bool {
+		return trace.UserID == userID
+	}), nil
+}
+
+// ListByTaskID 获取用户任务最近的求值轨迹
+func (r *ExpressionTraceRepositoryMemory) ListByTaskID(ctx context.Context, taskID int64, limit int) ([]*entity.ActExpressionTrace, error) {
+	return r.listLatest(limit, func(trace *entity.ActExpressionTrace) bool {
+		return trace.TaskID == taskID
+	}), nil
+}
+
+// listLatest 从最新的轨迹开始查找匹配项
+func (r *ExpressionTraceRepositoryMemory) listLatest(limit int, match func(trace *entity.ActExpressionTrace) bool) []*entity.ActExpressionTrace {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*entity.ActExpressionTrace
+	for i := len(r.traces) - 1; i >= 0; i-- {
+		if !match(r.traces[i]) {
+			continue
+		}
+		traceCopy := *r.traces[i]
+		result = append(result, &traceCopy)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
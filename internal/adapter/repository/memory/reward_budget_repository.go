@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"mini-sirus/internal/domain/entity"
+	"sync"
+)
+
+// budgetCounter 单个预算范围的计数
+type budgetCounter struct {
+	rewardUnits int64
+	completers  map[int64]bool // 已完成的用户
+}
+
+// appliedReservation 已生效的预占，用于释放时回滚
+type appliedReservation struct {
+	userID        int64
+	rewardUnits   int64
+	scopes        []entity.BudgetScope
+	newCompleters []entity.BudgetScope // 本次预占新增完成人数的范围
+}
+
+// RewardBudgetRepositoryMemory 奖励预算仓储内存实现
+type RewardBudgetRepositoryMemory struct {
+	mu           sync.Mutex
+	counters     map[entity.BudgetScope]*budgetCounter
+	reservations map[int64]*appliedReservation
+	idGen        int64
+}
+
+// NewRewardBudgetRepositoryMemory 创建内存奖励预算仓储
+func NewRewardBudgetRepositoryMemory() *RewardBudgetRepositoryMemory {
+	return &RewardBudgetRepositoryMemory{
+		counters:     make(map[entity.BudgetScope]*budgetCounter),
+		reservations: make(map[int64]*appliedReservation),
+		idGen:        8000,
+	}
+}
+
+// Reserve 原子地预占预算
+func (r *RewardBudgetRepositoryMemory) Reserve(ctx context.Context, reservation *entity.BudgetReservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 先校验所有预算项，全部满足后再统一扣减
+	for _, line := range reservation.Lines {
+		counter := r.counter(line.Scope)
+
+		if line.Budget.MaxRewardUnits > 0 && counter.rewardUnits+reservation.RewardUnits > line.Budget.MaxRewardUnits {
+			return &entity.BudgetExhaustedError{Scope: line.Scope, Reason: "reward_units"}
+		}
+
+		if reservation.Completion && line.Budget.MaxCompleters > 0 && !counter.completers[reservation.UserID] &&
+			int64(len(counter.completers)) >= line.Budget.MaxCompleters {
+			return &entity.BudgetExhaustedError{Scope: line.Scope, Reason: "completers"}
+		}
+	}
+
+	applied := &appliedReservation{
+		userID:      reservation.UserID,
+		rewardUnits: reservation.RewardUnits,
+	}
+	for _, line := range reservation.Lines {
+		counter := r.counter(line.Scope)
+		counter.rewardUnits += reservation.RewardUnits
+		applied.scopes = append(applied.scopes, line.Scope)
+
+		if reservation.Completion && !counter.completers[reservation.UserID] {
+			counter.completers[reservation.UserID] = true
+			applied.newCompleters = append(applied.newCompleters, line.Scope)
+		}
+	}
+
+	r.idGen++
+	reservation.ID = r.idGen
+	r.reservations[reservation.ID] = applied
+
+	return nil
+}
+
+// Release 释放预占
+func (r *RewardBudgetRepositoryMemory) Release(ctx context.Context, reservationID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	applied, exists := r.reservations[reservationID]
+	if !exists {
+		return errors.New("budget reservation not found")
+	}
+
+	for _, scope := range applied.scopes {
+		r.counter(scope).rewardUnits -= applied.rewardUnits
+	}
+	for _, scope := range applied.newCompleters {
+		delete(r.counter(scope).completers, applied.userID)
+	}
+	delete(r.reservations, reservationID)
+
+	return nil
+}
+
+// GetUsage 获取预算范围的已用量
+func (r *RewardBudgetRepositoryMemory) GetUsage(ctx context.Context, scope entity.BudgetScope) (*entity.BudgetUsage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counter := r.counter(scope)
+	return &entity.BudgetUsage{
+		Scope:       scope,
+		RewardUnits: counter.rewardUnits,
+		Completers:  int64(len(counter.completers)),
+	}, nil
+}
+
+// counter 获取预算范围的计数，不存在时创建（调用方需持有锁）
+func (r *RewardBudgetRepositoryMemory) counter(scope entity.BudgetScope) *budgetCounter {
+	counter, exists := r.counters[scope]
+	if !exists {
+		counter = &budgetCounter{completers: make(map[int64]bool)}
+		r.counters[scope] = counter
+	}
+	return counter
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"mini-sirus/internal/domain/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RewardRepositoryMemory 任务奖励定义仓储内存实现
+type RewardRepositoryMemory struct {
+	mu      sync.RWMutex
+	rewards map[int64]*entity.ActTaskReward
+	idGen   int64
+}
+
+// NewRewardRepositoryMemory 创建内存任务奖励定义仓储
+func NewRewardRepositoryMemory() *RewardRepositoryMemory {
+	return &RewardRepositoryMemory{
+		rewards: make(map[int64]*entity.ActTaskReward),
+		idGen:   6000,
+	}
+}
+
+// Create 创建奖励定义
+func (r *RewardRepositoryMemory) Create(ctx context.Context, reward *entity.ActTaskReward) error {
+	if !reward.IsValid() {
+		return errors.New("invalid task reward")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.idGen++
+	reward.ID = r.idGen
+	reward.CreatedAt = time.Now()
+
+	rewardCopy := *reward
+	r.rewards[reward.ID] = &rewardCopy
+
+	return nil
+}
+
+// ListByTaskDefID 获取任务定义配置的奖励列表
+func (r *RewardRepositoryMemory) ListByTaskDefID(ctx context.Context, taskDefID int64) ([]*entity.ActTaskReward, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*entity.ActTaskReward
+	for _, reward := range r.rewards {
+		if reward.TaskDefID == taskDefID {
+			rewardCopy := *reward
+			result = append(result, &rewardCopy)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// RewardLedgerRepositoryMemory 奖励流水仓储内存实现
+type RewardLedgerRepositoryMemory struct {
+	mu      sync.RWMutex
+	entries map[int64][]*entity.ActRewardLedgerEntry // userID -> 流水
+	keys    map[string]bool                          // 已记账的幂等键
+	wallets map[int64]*entity.ActUserWallet
+	idGen   int64
+}
+
+// NewRewardLedgerRepositoryMemory 创建内存奖励流水仓储
+func NewRewardLedgerRepositoryMemory() *RewardLedgerRepositoryMemory {
+	return &RewardLedgerRepositoryMemory{
+		entries: make(map[int64][]*entity.ActRewardLedgerEntry),
+		keys:    make(map[string]bool),
+		wallets: make(map[int64]*entity.ActUserWallet),
+		idGen:   7000,
+	}
+}
+
+// Append 追加奖励流水并原子地更新用户余额
+func (r *RewardLedgerRepositoryMemory) Append(ctx context.Context, entry *entity.ActRewardLedgerEntry) (bool, error) {
+	if entry.IdempotencyKey == "" {
+		return false, errors.New("idempotency key is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.keys[entry.IdempotencyKey] {
+		return false, nil
+	}
+
+	r.idGen++
+	entry.ID = r.idGen
+	entry.CreatedAt = time.Now()
+
+	entryCopy := *entry
+	r.entries[entry.UserID] = append(r.entries[entry.UserID], &entryCopy)
+	r.keys[entry.IdempotencyKey] = true
+
+	wallet, exists := r.wallets[entry.UserID]
+	if !exists {
+		wallet = entity.NewActUserWallet(entry.UserID)
+		r.wallets[entry.UserID] = wallet
+	}
+	wallet.Apply(&entryCopy)
+
+	return true, nil
+}
+
+// ListByUserID 获取用户的奖励流水
+func (r *RewardLedgerRepositoryMemory) ListByUserID(ctx context.Context, userID int64) ([]*entity.ActRewardLedgerEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*entity.ActRewardLedgerEntry, 0, len(r.entries[userID]))
+	for _, entry := range r.entries[userID] {
+		entryCopy := *entry
+		result = append(result, &entryCopy)
+	}
+
+	return result, nil
+}
+
+// GetWallet 获取用户奖励余额
+func (r *RewardLedgerRepositoryMemory) GetWallet(ctx context.Context, userID int64) (*entity.ActUserWallet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wallet, exists := r.wallets[userID]
+	if !exists {
+		return entity.NewActUserWallet(userID), nil
+	}
+
+	return wallet.Clone(), nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"sync"
+	"time"
+)
+
+// TaskDefinitionRepositoryMemory 任务定义仓储内存实现
+type TaskDefinitionRepositoryMemory struct {
+	mu          sync.RWMutex
+	definitions map[int64]*entity.ActTaskDefinition
+	idGen       int64
+}
+
+// NewTaskDefinitionRepositoryMemory 创建内存任务定义仓储
+func NewTaskDefinitionRepositoryMemory() *TaskDefinitionRepositoryMemory {
+	return &TaskDefinitionRepositoryMemory{
+		definitions: make(map[int64]*entity.ActTaskDefinition),
+		idGen:       4000,
+	}
+}
+
+// Create 创建任务定义
+func (r *TaskDefinitionRepositoryMemory) Create(ctx context.Context, def *entity.ActTaskDefinition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.idGen++
+	def.ID = r.idGen
+	def.CreatedAt = time.Now()
+	def.UpdatedAt = time.Now()
+
+	defCopy := *def
+	r.definitions[def.ID] = &defCopy
+
+	return nil
+}
+
+// Update 更新任务定义
+func (r *TaskDefinitionRepositoryMemory) Update(ctx context.Context, def *entity.ActTaskDefinition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.definitions[def.ID]; !exists {
+		return repository.ErrTaskDefinitionNotFound
+	}
+
+	def.UpdatedAt = time.Now()
+	defCopy := *def
+	r.definitions[def.ID] = &defCopy
+
+	return nil
+}
+
+// GetByID 根据ID获取任务定义
+func (r *TaskDefinitionRepositoryMemory) GetByID(ctx context.Context, defID int64) (*entity.ActTaskDefinition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, exists := r.definitions[defID]
+	if !exists {
+		return nil, repository.ErrTaskDefinitionNotFound
+	}
+
+	defCopy := *def
+	return &defCopy, nil
+}
+
+// ListByActivityID 获取活动下的任务定义列表
+func (r *TaskDefinitionRepositoryMemory) ListByActivityID(ctx context.Context, activityID int64) ([]*entity.ActTaskDefinition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*entity.ActTaskDefinition
+	for _, def := range r.definitions {
+		if def.ActivityID == activityID {
+			defCopy := *def
+			result = append(result, &defCopy)
+		}
+	}
+
+	return result, nil
+}
@@ -69,17 +69,30 @@ func (r *TaskDetailRepositoryMemory) ListByTaskID(ctx context.Context, taskID in
 	return result, nil
 }
 
-// ExistsByUniqueFlag 判断唯一标识是否已存在
-func (r *TaskDetailRepositoryMemory) ExistsByUniqueFlag(ctx context.Context, uniqueFlag string) (bool, error) {
+// FindByUniqueFlag 查找用户任务下指定唯一标识的明细，不存在时返回 nil
+func (r *TaskDetailRepositoryMemory) FindByUniqueFlag(ctx context.Context, taskID int64, uniqueFlag string) (*entity.ActUserTaskDetail, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, detail := range r.details {
-		if detail.UniqueFlag == uniqueFlag {
-			return true, nil
+		if detail.TaskID == taskID && detail.UniqueFlag == uniqueFlag {
+			detailCopy := *detail
+			return &detailCopy, nil
 		}
 	}
 
-	return false, nil
+	return nil, nil
 }
 
+// Delete 删除任务明细
+func (r *TaskDetailRepositoryMemory) Delete(ctx context.Context, detailID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.details[detailID]; !exists {
+		return errors.New("task detail not found")
+	}
+
+	delete(r.details, detailID)
+	return nil
+}
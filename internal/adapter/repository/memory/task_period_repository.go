@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"mini-sirus/internal/domain/entity"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TaskPeriodRepositoryMemory 任务周期进度仓储内存实现
+type TaskPeriodRepositoryMemory struct {
+	mu      sync.RWMutex
+	periods map[int64][]*entity.ActUserTaskPeriod // userTaskID -> 历史周期
+	idGen   int64
+}
+
+// NewTaskPeriodRepositoryMemory 创建内存任务周期进度仓储
+func NewTaskPeriodRepositoryMemory() *TaskPeriodRepositoryMemory {
+	return &TaskPeriodRepositoryMemory{
+		periods: make(map[int64][]*entity.ActUserTaskPeriod),
+		idGen:   5000,
+	}
+}
+
+// Create 归档周期进度
+func (r *TaskPeriodRepositoryMemory) Create(ctx context.Context, period *entity.ActUserTaskPeriod) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.idGen++
+	period.ID = r.idGen
+	if period.CreatedAt.IsZero() {
+		period.CreatedAt = time.Now()
+	}
+
+	periodCopy := *period
+	r.periods[period.UserTaskID] = append(r.periods[period.UserTaskID], &periodCopy)
+
+	return nil
+}
+
+// ListByUserTaskID 获取用户任务的历史周期进度
+func (r *TaskPeriodRepositoryMemory) ListByUserTaskID(ctx context.Context, userTaskID int64) ([]*entity.ActUserTaskPeriod, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*entity.ActUserTaskPeriod, 0, len(r.periods[userTaskID]))
+	for _, period := range r.periods[userTaskID] {
+		periodCopy := *period
+		result = append(result, &periodCopy)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PeriodKey < result[j].PeriodKey
+	})
+
+	return result, nil
+}
@@ -3,8 +3,11 @@ package memory
 import (
 	"context"
 	"errors"
+	"fmt"
 	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
 	"mini-sirus/internal/domain/valueobject"
+	"sort"
 	"sync"
 	"time"
 )
@@ -13,14 +16,22 @@ import (
 type TaskRepositoryMemory struct {
 	mu      sync.RWMutex
 	tasks   map[int64]*entity.ActUserTask
+	userIdx map[userTaskKey]int64 // (用户ID, 任务定义ID) -> 任务ID
 	idGen   int64
 }
 
+// userTaskKey 用户任务唯一键
+type userTaskKey struct {
+	userID int64
+	taskID int64
+}
+
 // NewTaskRepositoryMemory 创建内存任务仓储
 func NewTaskRepositoryMemory() *TaskRepositoryMemory {
 	return &TaskRepositoryMemory{
-		tasks: make(map[int64]*entity.ActUserTask),
-		idGen: 1000,
+		tasks:   make(map[int64]*entity.ActUserTask),
+		userIdx: make(map[userTaskKey]int64),
+		idGen:   1000,
 	}
 }
 
@@ -29,34 +40,114 @@ func (r *TaskRepositoryMemory) Create(ctx context.Context, task *entity.ActUserT
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	key := userTaskKey{userID: task.UserID, taskID: task.TaskID}
+	if existing, exists := r.userIdx[key]; exists {
+		return fmt.Errorf("%w: user %d task definition %d (task %d)",
+			repository.ErrUserTaskExists, task.UserID, task.TaskID, existing)
+	}
+
 	r.idGen++
 	task.ID = r.idGen
 	task.CreatedAt = time.Now()
 	task.UpdatedAt = time.Now()
+	task.Version = 1
 
 	// 复制一份存储，避免外部修改
 	taskCopy := *task
 	r.tasks[task.ID] = &taskCopy
+	r.userIdx[key] = task.ID
 
 	return nil
 }
 
+// BatchCreate 批量创建任务，已存在的用户任务会被跳过
+func (r *TaskRepositoryMemory) BatchCreate(ctx context.Context, tasks []*entity.ActUserTask) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := 0
+	now := time.Now()
+	for _, task := range tasks {
+		key := userTaskKey{userID: task.UserID, taskID: task.TaskID}
+		if _, exists := r.userIdx[key]; exists {
+			continue
+		}
+
+		r.idGen++
+		task.ID = r.idGen
+		task.CreatedAt = now
+		task.UpdatedAt = now
+		task.Version = 1
+
+		taskCopy := *task
+		r.tasks[task.ID] = &taskCopy
+		r.userIdx[key] = task.ID
+		created++
+	}
+
+	return created, nil
+}
+
 // Update 更新任务
 func (r *TaskRepositoryMemory) Update(ctx context.Context, task *entity.ActUserTask) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.tasks[task.ID]; !exists {
-		return errors.New("task not found")
+	if err := r.checkWritableLocked(task); err != nil {
+		return err
 	}
 
 	task.UpdatedAt = time.Now()
+	task.Version++
 	taskCopy := *task
 	r.tasks[task.ID] = &taskCopy
 
 	return nil
 }
 
+// UpdateBatch 原子地更新多个任务
+func (r *TaskRepositoryMemory) UpdateBatch(ctx context.Context, tasks []*entity.ActUserTask) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, task := range tasks {
+		if err := r.checkWritableLocked(task); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		task.UpdatedAt = now
+		task.Version++
+		taskCopy := *task
+		r.tasks[task.ID] = &taskCopy
+	}
+
+	return nil
+}
+
+// checkWritableLocked 校验任务存在、写入携带的锁令牌未过期且版本号与已存储的一致，调用方需持有 r.mu
+func (r *TaskRepositoryMemory) checkWritableLocked(task *entity.ActUserTask) error {
+	stored, exists := r.tasks[task.ID]
+	if !exists {
+		return errors.New("task not found")
+	}
+	if task.FencingToken < stored.FencingToken {
+		return fmt.Errorf("%w: task %d written with token %d, stored %d",
+			repository.ErrStaleFencingToken, task.ID, task.FencingToken, stored.FencingToken)
+	}
+	if task.Version != stored.Version {
+		return &repository.VersionConflictError{
+			Entity:   "user_task",
+			ID:       task.ID,
+			Expected: task.Version,
+			Actual:   stored.Version,
+		}
+	}
+	return nil
+}
+
 // GetByID 根据ID获取任务
 func (r *TaskRepositoryMemory) GetByID(ctx context.Context, taskID int64) (*entity.ActUserTask, error) {
 	r.mu.RLock()
@@ -103,17 +194,102 @@ func (r *TaskRepositoryMemory) ListByUserIDAndType(ctx context.Context, userID i
 	return result, nil
 }
 
-// UpdateProgress 更新任务进度
-func (r *TaskRepositoryMemory) UpdateProgress(ctx context.Context, taskID int64) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// MaxPendingProgress 获取任务定义下进行中用户任务的最大进度
+func (r *TaskRepositoryMemory) MaxPendingProgress(ctx context.Context, taskDefID int64) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	task, exists := r.tasks[taskID]
-	if !exists {
-		return errors.New("task not found")
+	maxProgress := 0
+	for _, task := range r.tasks {
+		if task.TaskID == taskDefID && task.IsPending() {
+			maxProgress = max(maxProgress, task.Progress)
+		}
 	}
 
-	task.UpdateProgress()
-	return nil
+	return maxProgress, nil
 }
 
+// ListParticipants 分页获取活动参与用户
+func (r *TaskRepositoryMemory) ListParticipants(ctx context.Context, activityID int64, offset, limit int) ([]*repository.ActivityParticipant, int, error) {
+	if offset < 0 {
+		return nil, 0, fmt.Errorf("invalid offset: %d", offset)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	participants := make(map[int64]*repository.ActivityParticipant)
+	for _, task := range r.tasks {
+		if task.ActivityID != activityID {
+			continue
+		}
+
+		participant, exists := participants[task.UserID]
+		if !exists {
+			participant = &repository.ActivityParticipant{UserID: task.UserID, JoinedAt: task.CreatedAt}
+			participants[task.UserID] = participant
+		}
+		participant.TaskCount++
+		if task.IsCompleted() {
+			participant.CompletedCount++
+		}
+		if task.CreatedAt.Before(participant.JoinedAt) {
+			participant.JoinedAt = task.CreatedAt
+		}
+	}
+
+	result := make([]*repository.ActivityParticipant, 0, len(participants))
+	for _, participant := range participants {
+		result = append(result, participant)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+
+	total := len(result)
+	if offset >= total {
+		return []*repository.ActivityParticipant{}, total, nil
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+
+	return result[offset:end], total, nil
+}
+
+// StatsByActivityID 按任务定义统计活动的完成情况
+func (r *TaskRepositoryMemory) StatsByActivityID(ctx context.Context, activityID int64) ([]*repository.TaskCompletionStat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make(map[int64]*repository.TaskCompletionStat)
+	for _, task := range r.tasks {
+		if task.ActivityID != activityID {
+			continue
+		}
+
+		stat, exists := stats[task.TaskID]
+		if !exists {
+			stat = &repository.TaskCompletionStat{TaskDefID: task.TaskID}
+			stats[task.TaskID] = stat
+		}
+		stat.Participants++
+		switch {
+		case task.IsCompleted():
+			stat.Completed++
+		case task.IsLocked():
+			stat.Locked++
+		}
+	}
+
+	result := make([]*repository.TaskCompletionStat, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].TaskDefID < result[j].TaskDefID
+	})
+
+	return result, nil
+}
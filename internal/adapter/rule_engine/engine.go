@@ -0,0 +1,25 @@
+package rule_engine
+
+import (
+	"fmt"
+	"mini-sirus/internal/adapter/rule_engine/sandbox"
+	"mini-sirus/internal/usecase/port/output"
+)
+
+// 规则引擎名称
+const (
+	EngineGovaluate = "govaluate" // 基于 govaluate，兼容已有表达式
+	EngineSandbox   = "sandbox"   // 强类型沙箱表达式语言
+)
+
+// New 按引擎名称创建规则引擎，engine 为空时使用 govaluate
+func New(engine string, cacheSize int, limits sandbox.Limits) (output.RuleEngine, error) {
+	switch engine {
+	case "", EngineGovaluate:
+		return NewGovaluateAdapterWithCache(cacheSize), nil
+	case EngineSandbox:
+		return NewSandboxAdapter(limits, cacheSize), nil
+	default:
+		return nil, fmt.Errorf("unknown rule engine: %s", engine)
+	}
+}
@@ -0,0 +1,247 @@
+package rule_engine
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/domain/valueobject"
+	"strings"
+
+	"github.com/Knetic/govaluate"
+)
+
+// Explain 执行表达式求值并返回求值轨迹
+// 按顶层 ||、&&、! 拆分为子表达式，每个子表达式只求值一次（不短路）并记录其中的函数调用，
+// 逻辑运算节点由子节点的结果按短路语义合并，与 Evaluate 的结果一致。
+// 表达式无法解析时返回错误，求值错误记录在对应节点的 Error 中
+func (a *GovaluateAdapter) Explain(
+	ctx context.Context,
+	expr string,
+	functions valueobject.ExpressionFunctions,
+	args valueobject.ExpressionArguments,
+) (*valueobject.ExpressionTraceNode, error) {
+	if expr == "" {
+		// 与 Evaluate 一致，空表达式默认返回 true
+		return &valueobject.ExpressionTraceNode{Kind: valueobject.TraceNodeClause, Result: true}, nil
+	}
+
+	if _, err := a.compile(expr, functions); err != nil {
+		return nil, fmt.Errorf("parse expression failed: %w", err)
+	}
+
+	e := &explainer{args: args}
+	e.functions = make(map[string]govaluate.ExpressionFunction)
+	for name, fn := range a.mergedFunctions(functions) {
+		e.functions[name] = e.recordCall(name, fn)
+	}
+	return e.explainNode(expr), nil
+}
+
+// explainer 单次 Explain 的求值状态
+// 函数集在一次 Explain 中只包装一次，调用记录到当前正在求值的子表达式节点；
+// 包装后的函数集每次不同，因此子表达式不走编译缓存
+type explainer struct {
+	args      valueobject.ExpressionArguments
+	functions map[string]govaluate.ExpressionFunction
+	current   *valueobject.ExpressionTraceNode
+}
+
+// mergedFunctions 合并注册函数与传入函数，优先使用传入的函数
+func (a *GovaluateAdapter) mergedFunctions(functions valueobject.ExpressionFunctions) map[string]govaluate.ExpressionFunction {
+	merged := make(map[string]govaluate.ExpressionFunction, len(a.functions)+len(functions))
+	a.mu.RLock()
+	for name, fn := range a.functions {
+		merged[name] = fn.Call
+	}
+	a.mu.RUnlock()
+	for name, fn := range functions {
+		merged[name] = fn.Call
+	}
+	return merged
+}
+
+// explainNode 构建子表达式的求值轨迹
+func (e *explainer) explainNode(expr string) *valueobject.ExpressionTraceNode {
+	expr = strings.TrimSpace(expr)
+	if inner, ok := stripOuterParens(expr); ok {
+		return e.explainNode(inner)
+	}
+
+	// 三元运算优先级低于逻辑运算，整体作为子表达式处理
+	if len(splitTopLevel(expr, "?")) > 1 {
+		return e.explainClause(expr)
+	}
+
+	for _, op := range []struct {
+		token string
+		kind  valueobject.TraceNodeKind
+	}{
+		{"||", valueobject.TraceNodeOr},
+		{"&&", valueobject.TraceNodeAnd},
+	} {
+		parts := splitTopLevel(expr, op.token)
+		if len(parts) < 2 {
+			continue
+		}
+
+		node := &valueobject.ExpressionTraceNode{Kind: op.kind, Expr: expr}
+		for _, part := range parts {
+			node.Children = append(node.Children, e.explainNode(part))
+		}
+		node.Result, node.Error = combine(op.token, node.Children)
+		return node
+	}
+
+	if strings.HasPrefix(expr, "!") {
+		if inner, ok := stripOuterParens(strings.TrimSpace(expr[1:])); ok {
+			node := &valueobject.ExpressionTraceNode{Kind: valueobject.TraceNodeNot, Expr: expr}
+			child := e.explainNode(inner)
+			node.Children = append(node.Children, child)
+			if child.Error != "" {
+				node.Error = child.Error
+			} else if b, ok := child.Result.(bool); ok {
+				node.Result = !b
+			} else {
+				node.Error = fmt.Sprintf("operator ! requires bool, got %T", child.Result)
+			}
+			return node
+		}
+	}
+
+	return e.explainClause(expr)
+}
+
+// explainClause 求值不含顶层逻辑运算的子表达式，并记录其中的函数调用
+func (e *explainer) explainClause(expr string) *valueobject.ExpressionTraceNode {
+	node := &valueobject.ExpressionTraceNode{Kind: valueobject.TraceNodeClause, Expr: expr}
+
+	expression, err := govaluate.NewEvaluableExpressionWithFunctions(expr, e.functions)
+	if err != nil {
+		node.Error = err.Error()
+		return node
+	}
+
+	for _, name := range expression.Vars() {
+		if node.Inputs == nil {
+			node.Inputs = make(map[string]interface{})
+		}
+		node.Inputs[name] = e.args[name]
+	}
+
+	e.current = node
+	defer func() { e.current = nil }()
+
+	result, err := expression.Evaluate(map[string]interface{}(e.args))
+	node.Result = result
+	if err != nil {
+		node.Error = err.Error()
+	}
+	return node
+}
+
+// recordCall 包装函数，将每次调用的实参与结果记录为当前子表达式节点的子节点
+func (e *explainer) recordCall(name string, fn govaluate.ExpressionFunction) govaluate.ExpressionFunction {
+	return func(args ...interface{}) (interface{}, error) {
+		result, err := fn(args...)
+
+		call := &valueobject.ExpressionTraceNode{
+			Kind:   valueobject.TraceNodeFunction,
+			Expr:   name,
+			Args:   append([]interface{}(nil), args...),
+			Result: result,
+		}
+		if err != nil {
+			call.Error = err.Error()
+		}
+		if e.current != nil {
+			e.current.Children = append(e.current.Children, call)
+		}
+
+		return result, err
+	}
+}
+
+// combine 按短路语义合并逻辑运算数的求值结果，与 Evaluate 的结果一致
+func combine(op string, children []*valueobject.ExpressionTraceNode) (interface{}, string) {
+	for _, child := range children {
+		if child.Error != "" {
+			return nil, child.Error
+		}
+		b, ok := child.Result.(bool)
+		if !ok {
+			return nil, fmt.Sprintf("operator %s requires bool, got %T", op, child.Result)
+		}
+		if (op == "&&" && !b) || (op == "||" && b) {
+			return b, ""
+		}
+	}
+	return op == "&&", ""
+}
+
+// splitTopLevel 按不在括号或字符串内的运算符拆分表达式
+func splitTopLevel(expr string, op string) []string {
+	var (
+		parts []string
+		depth int
+		quote byte
+		start int
+	)
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case depth == 0 && strings.HasPrefix(expr[i:], op):
+			// "??" 为空值合并运算符，不是三元运算
+			if op == "?" && strings.HasPrefix(expr[i:], "??") {
+				i++
+				continue
+			}
+			parts = append(parts, expr[start:i])
+			i += len(op) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, expr[start:])
+}
+
+// stripOuterParens 去掉包裹整个表达式的一对括号
+func stripOuterParens(expr string) (string, bool) {
+	if len(expr) < 2 || expr[0] != '(' || expr[len(expr)-1] != ')' {
+		return expr, false
+	}
+
+	depth := 0
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 && i != len(expr)-1 {
+				// 首个括号在末尾之前闭合，如 (a) && (b)
+				return expr, false
+			}
+		}
+	}
+	return strings.TrimSpace(expr[1 : len(expr)-1]), true
+}
@@ -0,0 +1,52 @@
+package rule_engine
+
+import (
+	"context"
+	"mini-sirus/internal/domain/valueobject"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGovaluateExplainEvaluatesEachClauseOnce(t *testing.T) {
+	ctx := context.Background()
+	calls := make(map[string]int)
+	counted := func(name string) *valueobject.ExpressionFunction {
+		return &valueobject.ExpressionFunction{
+			Params:  []valueobject.ValueType{valueobject.ValueTypeBool},
+			Returns: valueobject.ValueTypeBool,
+			Call: func(args ...interface{}) (interface{}, error) {
+				calls[name]++
+				return args[0], nil
+			},
+		}
+	}
+	functions := valueobject.ExpressionFunctions{"A": counted("A"), "B": counted("B"), "C": counted("C")}
+	args := valueobject.ExpressionArguments{"yes": true, "no": false}
+	expr := "A(yes) && (B(no) || C(yes)) && !(B(no))"
+
+	adapter := NewGovaluateAdapter()
+	root, err := adapter.Explain(ctx, expr, functions, args)
+	require.NoError(t, err)
+
+	// 每个子表达式只求值一次，逻辑运算节点不再重新求值整棵子树
+	assert.Equal(t, map[string]int{"A": 1, "B": 2, "C": 1}, calls)
+	assert.Equal(t, true, root.Result)
+	require.Len(t, root.Children, 3)
+	assert.Equal(t, valueobject.TraceNodeOr, root.Children[1].Kind)
+	require.Len(t, root.Children[1].Children, 2)
+	require.Len(t, root.Children[1].Children[1].Children, 1, "函数调用记录在所在的子表达式下")
+	assert.Equal(t, "C", root.Children[1].Children[1].Children[0].Expr)
+
+	reach, err := adapter.Evaluate(ctx, expr, functions, args)
+	require.NoError(t, err)
+	assert.Equal(t, reach, root.Result)
+
+	// 不短路求值，但结果按短路语义合并：前面的运算数已决定结果时忽略后面的错误
+	root, err = adapter.Explain(ctx, "A(no) && missing > 1", functions, args)
+	require.NoError(t, err)
+	assert.Equal(t, false, root.Result)
+	assert.Empty(t, root.Error)
+	assert.NotEmpty(t, root.Children[1].Error)
+}
@@ -0,0 +1,127 @@
+package rule_engine
+
+import (
+	"container/list"
+	"mini-sirus/internal/domain/valueobject"
+	"sync"
+)
+
+// DefaultExpressionCacheSize 默认编译缓存容量
+const DefaultExpressionCacheSize = 1024
+
+// ExpressionCacheStats 编译缓存统计
+type ExpressionCacheStats struct {
+	Size      int    `json:"size"`
+	Capacity  int    `json:"capacity"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// expressionCacheKey 编译缓存键：表达式文本 + 函数集版本
+// 函数集版本由适配器注册函数的版本号和调用方传入的函数集 map 共同确定
+type expressionCacheKey struct {
+	expr        string
+	version     uint64
+	functionSet uintptr
+}
+
+// expressionCacheEntry 编译缓存项
+type expressionCacheEntry struct {
+	key      expressionCacheKey
+	compiled interface{} // 编译结果，类型由使用缓存的引擎决定
+	// 持有函数集引用，避免 map 被回收后地址被新的函数集复用
+	functions valueobject.ExpressionFunctions
+}
+
+// expressionCache 已编译表达式的 LRU 缓存
+type expressionCache struct {
+	mu        sync.Mutex
+	capacity  int
+	entries   map[expressionCacheKey]*list.Element
+	order     *list.List // 队头为最近使用
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// newExpressionCache 创建编译缓存，capacity <= 0 时不缓存
+func newExpressionCache(capacity int) *expressionCache {
+	return &expressionCache{
+		capacity: capacity,
+		entries:  make(map[expressionCacheKey]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get 获取已编译表达式
+func (c *expressionCache) get(key expressionCacheKey) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*expressionCacheEntry).compiled, true
+}
+
+// put 缓存已编译表达式，超出容量时淘汰最久未使用的表达式
+func (c *expressionCache) put(
+	key expressionCacheKey,
+	compiled interface{},
+	functions valueobject.ExpressionFunctions,
+) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*expressionCacheEntry).compiled = compiled
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&expressionCacheEntry{
+		key:       key,
+		compiled:  compiled,
+		functions: functions,
+	})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*expressionCacheEntry).key)
+		c.evictions++
+	}
+}
+
+// purge 清空缓存（注册函数变化后旧的编译结果不再有效）
+func (c *expressionCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[expressionCacheKey]*list.Element)
+	c.order.Init()
+}
+
+// stats 获取缓存统计
+func (c *expressionCache) stats() ExpressionCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return ExpressionCacheStats{
+		Size:      c.order.Len(),
+		Capacity:  c.capacity,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
@@ -0,0 +1,134 @@
+package rule_engine
+
+import (
+	"context"
+	"mini-sirus/internal/adapter/rule_engine/sandbox"
+	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/usecase/port/output"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// benchmarkFunctions 基准测试使用的表达式函数集
+var benchmarkFunctions = valueobject.ExpressionFunctions{
+	"LIKE_COUNT_GTE": {
+		Params:  []valueobject.ValueType{valueobject.ValueTypeNumber, valueobject.ValueTypeNumber},
+		Returns: valueobject.ValueTypeBool,
+		Call: func(args ...interface{}) (interface{}, error) {
+			return args[0].(float64) >= args[1].(float64), nil
+		},
+	},
+	"IS_AUDITED": {
+		Params:  []valueobject.ValueType{valueobject.ValueTypeBool},
+		Returns: valueobject.ValueTypeBool,
+		Call: func(args ...interface{}) (interface{}, error) {
+			return args[0].(bool), nil
+		},
+	},
+}
+
+const benchmarkCondExpr = "LIKE_COUNT_GTE(like_count, 10) && IS_AUDITED(is_audited) && comment_count >= 1"
+
+func TestExpressionCacheLRU(t *testing.T) {
+	cache := newExpressionCache(2)
+	a := expressionCacheKey{expr: "a"}
+	b := expressionCacheKey{expr: "b"}
+	c := expressionCacheKey{expr: "c"}
+
+	cache.put(a, 1, nil)
+	cache.put(b, 2, nil)
+	_, ok := cache.get(a)
+	require.True(t, ok)
+
+	// 超出容量时淘汰最久未使用的 b
+	cache.put(c, 3, nil)
+	_, ok = cache.get(b)
+	assert.False(t, ok)
+	compiled, ok := cache.get(a)
+	assert.True(t, ok)
+	assert.Equal(t, 1, compiled)
+
+	// 函数集版本不同视为不同的键
+	_, ok = cache.get(expressionCacheKey{expr: "a", version: 1})
+	assert.False(t, ok)
+
+	assert.Equal(t, ExpressionCacheStats{Size: 2, Capacity: 2, Hits: 2, Misses: 2, Evictions: 1}, cache.stats())
+
+	cache.purge()
+	assert.Equal(t, 0, cache.stats().Size)
+
+	// 容量为 0 时不缓存
+	disabled := newExpressionCache(0)
+	disabled.put(a, 1, nil)
+	_, ok = disabled.get(a)
+	assert.False(t, ok)
+}
+
+func TestGovaluateExpressionCache(t *testing.T) {
+	ctx := context.Background()
+	args := valueobject.ExpressionArguments{"like_count": 12.0, "is_audited": true, "comment_count": 1.0}
+
+	adapter := NewGovaluateAdapterWithCache(2)
+	for i := 0; i < 3; i++ {
+		reach, err := adapter.Evaluate(ctx, benchmarkCondExpr, benchmarkFunctions, args)
+		require.NoError(t, err)
+		assert.True(t, reach)
+	}
+	stats := adapter.CacheStats()
+	assert.Equal(t, uint64(1), stats.Misses, "只应解析一次")
+	assert.Equal(t, uint64(2), stats.Hits)
+
+	// 超出容量时淘汰最久未使用的表达式
+	for _, expr := range []string{"like_count > 1", "comment_count > 0"} {
+		_, err := adapter.Evaluate(ctx, expr, benchmarkFunctions, args)
+		require.NoError(t, err)
+	}
+	stats = adapter.CacheStats()
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, uint64(1), stats.Evictions)
+
+	// 新的函数集视为新版本，不复用旧的编译结果
+	functions := valueobject.ExpressionFunctions{
+		"LIKE_COUNT_GTE": {Returns: valueobject.ValueTypeBool, Call: func(args ...interface{}) (interface{}, error) { return false, nil }},
+		"IS_AUDITED":     benchmarkFunctions["IS_AUDITED"],
+	}
+	reach, err := adapter.Evaluate(ctx, benchmarkCondExpr, functions, args)
+	require.NoError(t, err)
+	assert.False(t, reach, "应使用新函数集中的函数")
+
+	// 注册函数后缓存失效
+	require.NoError(t, adapter.RegisterFunction("ALWAYS", &valueobject.ExpressionFunction{
+		Returns: valueobject.ValueTypeBool,
+		Call:    func(args ...interface{}) (interface{}, error) { return true, nil },
+	}))
+	assert.Equal(t, 0, adapter.CacheStats().Size)
+	reach, err = adapter.Evaluate(ctx, "ALWAYS()", nil, args)
+	require.NoError(t, err)
+	assert.True(t, reach)
+}
+
+func BenchmarkRuleEngineEvaluate(b *testing.B) {
+	ctx := context.Background()
+	args := valueobject.ExpressionArguments{"like_count": 12.0, "is_audited": true, "comment_count": 1.0}
+
+	run := func(b *testing.B, adapter output.RuleEngine) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := adapter.Evaluate(ctx, benchmarkCondExpr, benchmarkFunctions, args); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("cached", func(b *testing.B) {
+		run(b, NewGovaluateAdapter())
+	})
+	b.Run("uncached", func(b *testing.B) {
+		run(b, NewGovaluateAdapterWithCache(0))
+	})
+	b.Run("sandbox", func(b *testing.B) {
+		run(b, NewSandboxAdapter(sandbox.DefaultLimits(), 1024))
+	})
+}
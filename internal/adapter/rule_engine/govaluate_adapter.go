@@ -5,19 +5,33 @@ import (
 	"errors"
 	"fmt"
 	"mini-sirus/internal/domain/valueobject"
+	"reflect"
+	"sync"
 
 	"github.com/Knetic/govaluate"
 )
 
 // GovaluateAdapter 规则引擎适配器（基于 govaluate）
+// govaluate 不做静态类型检查，参数和返回值类型在求值时由函数自行校验
+// 已编译的表达式按"表达式文本 + 函数集版本"缓存，热路径上不再重复解析
+// 调用方应复用同一个函数集 map 且传入后不再修改；新建的 map 视为新的函数集版本
 type GovaluateAdapter struct {
-	functions map[string]govaluate.ExpressionFunction
+	mu        sync.RWMutex
+	functions valueobject.ExpressionFunctions
+	version   uint64 // 注册函数的版本号，每次注册递增
+	cache     *expressionCache
 }
 
-// NewGovaluateAdapter 创建规则引擎适配器
+// NewGovaluateAdapter 创建规则引擎适配器（使用默认编译缓存容量）
 func NewGovaluateAdapter() *GovaluateAdapter {
+	return NewGovaluateAdapterWithCache(DefaultExpressionCacheSize)
+}
+
+// NewGovaluateAdapterWithCache 创建指定编译缓存容量的规则引擎适配器，cacheSize <= 0 时不缓存
+func NewGovaluateAdapterWithCache(cacheSize int) *GovaluateAdapter {
 	return &GovaluateAdapter{
-		functions: make(map[string]govaluate.ExpressionFunction),
+		functions: make(valueobject.ExpressionFunctions),
+		cache:     newExpressionCache(cacheSize),
 	}
 }
 
@@ -25,7 +39,7 @@ func NewGovaluateAdapter() *GovaluateAdapter {
 func (a *GovaluateAdapter) Evaluate(
 	ctx context.Context,
 	expr string,
-	functions map[string]govaluate.ExpressionFunction,
+	functions valueobject.ExpressionFunctions,
 	args valueobject.ExpressionArguments,
 ) (bool, error) {
 	if expr == "" {
@@ -33,56 +47,166 @@ func (a *GovaluateAdapter) Evaluate(
 		return true, nil
 	}
 
-	// 合并函数（优先使用传入的函数）
-	mergedFunctions := make(map[string]govaluate.ExpressionFunction)
+	result, err := a.evaluate(expr, functions, args)
+	if err != nil {
+		return false, err
+	}
+
+	// 转换为布尔值
+	reach, ok := result.(bool)
+	if !ok {
+		return false, errors.New("expression result must be bool")
+	}
+
+	return reach, nil
+}
+
+// EvaluateNumber 执行数值表达式求值
+func (a *GovaluateAdapter) EvaluateNumber(
+	ctx context.Context,
+	expr string,
+	functions valueobject.ExpressionFunctions,
+	args valueobject.ExpressionArguments,
+) (float64, error) {
+	if expr == "" {
+		return 0, errors.New("expression cannot be empty")
+	}
+
+	result, err := a.evaluate(expr, functions, args)
+	if err != nil {
+		return 0, err
+	}
+
+	// 转换为数值
+	switch v := result.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	default:
+		return 0, errors.New("expression result must be number")
+	}
+}
+
+// Validate 编译并校验表达式，不执行求值
+func (a *GovaluateAdapter) Validate(
+	ctx context.Context,
+	expr string,
+	functions valueobject.ExpressionFunctions,
+	params map[string]valueobject.ValueType,
+) error {
+	if expr == "" {
+		return &valueobject.ExpressionError{Expr: expr, Reason: "expression cannot be empty"}
+	}
+
+	// 未注册的函数在解析阶段即报错
+	expression, err := a.compile(expr, functions)
+	if err != nil {
+		return &valueobject.ExpressionError{Expr: expr, Reason: err.Error()}
+	}
+
+	if params == nil {
+		return nil
+	}
+
+	for _, name := range expression.Vars() {
+		if _, declared := params[name]; !declared {
+			return &valueobject.ExpressionError{Expr: expr, Reason: fmt.Sprintf("undefined parameter %s", name)}
+		}
+	}
+
+	return nil
+}
+
+// compile 获取已编译的表达式，未命中缓存时合并函数并解析（优先使用传入的函数）
+func (a *GovaluateAdapter) compile(
+	expr string,
+	functions valueobject.ExpressionFunctions,
+) (*govaluate.EvaluableExpression, error) {
+	a.mu.RLock()
+	key := expressionCacheKey{
+		expr:        expr,
+		version:     a.version,
+		functionSet: reflect.ValueOf(functions).Pointer(),
+	}
+	if compiled, ok := a.cache.get(key); ok {
+		a.mu.RUnlock()
+		return compiled.(*govaluate.EvaluableExpression), nil
+	}
+
+	mergedFunctions := make(map[string]govaluate.ExpressionFunction, len(a.functions)+len(functions))
 	for k, v := range a.functions {
-		mergedFunctions[k] = v
+		mergedFunctions[k] = v.Call
 	}
+	a.mu.RUnlock()
 	for k, v := range functions {
-		mergedFunctions[k] = v
+		mergedFunctions[k] = v.Call
 	}
 
-	// 创建表达式
 	expression, err := govaluate.NewEvaluableExpressionWithFunctions(expr, mergedFunctions)
 	if err != nil {
-		return false, fmt.Errorf("parse expression failed: %w", err)
+		return nil, err
 	}
 
-	// 执行求值
-	result, err := expression.Evaluate(map[string]interface{}(args))
+	a.cache.put(key, expression, functions)
+	return expression, nil
+}
+
+// evaluate 解析并执行表达式
+func (a *GovaluateAdapter) evaluate(
+	expr string,
+	functions valueobject.ExpressionFunctions,
+	args valueobject.ExpressionArguments,
+) (interface{}, error) {
+	// 创建表达式
+	expression, err := a.compile(expr, functions)
 	if err != nil {
-		return false, fmt.Errorf("evaluate expression failed: %w", err)
+		return nil, fmt.Errorf("parse expression failed: %w", err)
 	}
 
-	// 转换为布尔值
-	reach, ok := result.(bool)
-	if !ok {
-		return false, errors.New("expression result must be bool")
+	// 执行求值
+	result, err := expression.Evaluate(map[string]interface{}(args))
+	if err != nil {
+		return nil, fmt.Errorf("evaluate expression failed: %w", err)
 	}
 
-	return reach, nil
+	return result, nil
 }
 
 // RegisterFunction 注册自定义函数
-func (a *GovaluateAdapter) RegisterFunction(name string, fn govaluate.ExpressionFunction) error {
+func (a *GovaluateAdapter) RegisterFunction(name string, fn *valueobject.ExpressionFunction) error {
 	if name == "" {
 		return errors.New("function name cannot be empty")
 	}
-	if fn == nil {
+	if fn == nil || fn.Call == nil {
 		return errors.New("function cannot be nil")
 	}
 
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	a.functions[name] = fn
+	a.version++
+	a.cache.purge()
 	return nil
 }
 
 // GetRegisteredFunctions 获取所有注册的函数
-func (a *GovaluateAdapter) GetRegisteredFunctions() map[string]govaluate.ExpressionFunction {
+func (a *GovaluateAdapter) GetRegisteredFunctions() valueobject.ExpressionFunctions {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
 	// 返回副本，避免外部修改
-	functions := make(map[string]govaluate.ExpressionFunction)
+	functions := make(valueobject.ExpressionFunctions)
 	for k, v := range a.functions {
 		functions[k] = v
 	}
 	return functions
 }
 
+// CacheStats 获取编译缓存统计
+func (a *GovaluateAdapter) CacheStats() ExpressionCacheStats {
+	return a.cache.stats()
+}
@@ -0,0 +1,118 @@
+package sandbox
+
+import (
+	"fmt"
+
+	"mini-sirus/internal/domain/valueobject"
+)
+
+// checker 静态类型检查
+// 参数类型未知（params 为 nil 或类型为 any）时放宽检查，由求值时的运行期检查兜底
+type checker struct {
+	functions valueobject.ExpressionFunctions
+	params    map[string]valueobject.ValueType
+}
+
+// check 检查节点类型，并为函数调用绑定函数
+func (c *checker) check(n *node) error {
+	for _, arg := range n.args {
+		if err := c.check(arg); err != nil {
+			return err
+		}
+	}
+
+	switch n.kind {
+	case nodeLiteral:
+		n.typ = valueobject.TypeOf(n.value)
+
+	case nodeIdent:
+		n.typ = valueobject.ValueTypeAny
+		if c.params != nil {
+			typ, ok := c.params[n.op]
+			if !ok {
+				return fmt.Errorf("undefined parameter %s", n.op)
+			}
+			n.typ = typ
+		}
+
+	case nodeUnary:
+		want := valueobject.ValueTypeBool
+		if n.op == "-" {
+			want = valueobject.ValueTypeNumber
+		}
+		if !compatible(want, n.args[0].typ) {
+			return fmt.Errorf("operator %s requires %s, got %s at position %d", n.op, want, n.args[0].typ, n.start)
+		}
+		n.typ = want
+
+	case nodeBinary:
+		typ, err := binaryType(n.op, n.args[0].typ, n.args[1].typ)
+		if err != nil {
+			return fmt.Errorf("%v at position %d", err, n.start)
+		}
+		n.typ = typ
+
+	case nodeCall:
+		fn, ok := c.functions[n.op]
+		if !ok || fn == nil {
+			return fmt.Errorf("undefined function %s", n.op)
+		}
+		if len(n.args) < len(fn.Params) || (!fn.Variadic && len(n.args) > len(fn.Params)) {
+			return fmt.Errorf("function %s expects %d arguments, got %d", n.op, len(fn.Params), len(n.args))
+		}
+		for i, arg := range n.args {
+			want, _ := fn.ParamType(i)
+			if !compatible(want, arg.typ) {
+				return fmt.Errorf("function %s argument %d must be %s, got %s", n.op, i+1, want, arg.typ)
+			}
+		}
+		n.fn = fn
+		n.typ = fn.Returns
+		if n.typ == "" {
+			n.typ = valueobject.ValueTypeAny
+		}
+	}
+
+	return nil
+}
+
+// compatible 判断实际类型是否满足期望类型，任一方为 any 时视为满足
+func compatible(want, got valueobject.ValueType) bool {
+	return want == valueobject.ValueTypeAny || got == valueobject.ValueTypeAny || want == got
+}
+
+// binaryType 推导二元运算的结果类型
+func binaryType(op string, x, y valueobject.ValueType) (valueobject.ValueType, error) {
+	switch op {
+	case "||", "&&":
+		if compatible(valueobject.ValueTypeBool, x) && compatible(valueobject.ValueTypeBool, y) {
+			return valueobject.ValueTypeBool, nil
+		}
+	case "==", "!=":
+		if compatible(x, y) && x != valueobject.ValueTypeIDList && y != valueobject.ValueTypeIDList {
+			return valueobject.ValueTypeBool, nil
+		}
+	case "<", "<=", ">", ">=":
+		if compatible(x, y) && orderable(x) && orderable(y) {
+			return valueobject.ValueTypeBool, nil
+		}
+	case "+":
+		// 数值相加或字符串拼接
+		if compatible(x, y) && orderable(x) && orderable(y) {
+			if x == valueobject.ValueTypeAny {
+				return y, nil
+			}
+			return x, nil
+		}
+	case "-", "*", "/", "%":
+		if compatible(valueobject.ValueTypeNumber, x) && compatible(valueobject.ValueTypeNumber, y) {
+			return valueobject.ValueTypeNumber, nil
+		}
+	}
+	return "", fmt.Errorf("operator %s not defined on %s and %s", op, x, y)
+}
+
+// orderable 判断类型是否可比较大小（数值、字符串或未知类型）
+func orderable(t valueobject.ValueType) bool {
+	return t == valueobject.ValueTypeNumber || t == valueobject.ValueTypeString || t == valueobject.ValueTypeAny
+}
@@ -0,0 +1,367 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"mini-sirus/internal/domain/valueobject"
+)
+
+// evaluator 单次求值的状态（步数、截止时间），不可并发使用
+type evaluator struct {
+	ctx      context.Context
+	source   string
+	args     valueobject.ExpressionArguments
+	maxSteps int
+	steps    int
+	deadline time.Time
+
+	// onCall 记录函数调用，Explain 模式下使用
+	onCall func(call *valueobject.ExpressionTraceNode)
+}
+
+// step 计数一步并检查限制
+// 截止时间每 8 步及每次函数调用后检查一次，控制热路径上读取时钟的开销
+func (e *evaluator) step() error {
+	e.steps++
+	if e.maxSteps > 0 && e.steps > e.maxSteps {
+		return fmt.Errorf("%w (%d steps)", ErrStepLimit, e.maxSteps)
+	}
+	if e.steps%8 == 0 {
+		return e.checkDeadline()
+	}
+	return nil
+}
+
+// checkDeadline 检查求值是否超时或被取消
+func (e *evaluator) checkDeadline() error {
+	if !e.deadline.IsZero() && time.Now().After(e.deadline) {
+		return ErrTimeout
+	}
+	if e.ctx != nil {
+		if err := e.ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eval 求值节点
+func (e *evaluator) eval(n *node) (interface{}, error) {
+	if err := e.step(); err != nil {
+		return nil, err
+	}
+
+	switch n.kind {
+	case nodeLiteral:
+		return n.value, nil
+
+	case nodeIdent:
+		value, ok := e.args[n.op]
+		if !ok {
+			return nil, errorf(n, "no parameter %s", n.op)
+		}
+		return normalize(value), nil
+
+	case nodeUnary:
+		operand, err := e.eval(n.args[0])
+		if err != nil {
+			return nil, err
+		}
+		switch v := operand.(type) {
+		case bool:
+			if n.op == "!" {
+				return !v, nil
+			}
+		case float64:
+			if n.op == "-" {
+				return -v, nil
+			}
+		}
+		return nil, errorf(n, "operator %s not defined on %T", n.op, operand)
+
+	case nodeBinary:
+		return e.evalBinary(n)
+
+	case nodeCall:
+		return e.evalCall(n)
+	}
+
+	return nil, errorf(n, "unknown expression")
+}
+
+// evalBinary 求值二元运算，逻辑运算短路
+func (e *evaluator) evalBinary(n *node) (interface{}, error) {
+	left, err := e.eval(n.args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, errorf(n, "operator %s requires bool, got %T", n.op, left)
+		}
+		if (n.op == "&&" && !l) || (n.op == "||" && l) {
+			return l, nil
+		}
+		right, err := e.eval(n.args[1])
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, errorf(n, "operator %s requires bool, got %T", n.op, right)
+		}
+		return r, nil
+	}
+
+	right, err := e.eval(n.args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			return numberOp(n, l, r)
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return stringOp(n, l, r)
+		}
+	case bool:
+		if r, ok := right.(bool); ok {
+			switch n.op {
+			case "==":
+				return l == r, nil
+			case "!=":
+				return l != r, nil
+			}
+		}
+	}
+	return nil, errorf(n, "operator %s not defined on %T and %T", n.op, left, right)
+}
+
+// numberOp 数值运算
+func numberOp(n *node, l, r float64) (interface{}, error) {
+	var result float64
+	switch n.op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	case "+":
+		result = l + r
+	case "-":
+		result = l - r
+	case "*":
+		result = l * r
+	case "/", "%":
+		if r == 0 {
+			return nil, errorf(n, "division by zero")
+		}
+		if n.op == "/" {
+			result = l / r
+		} else {
+			result = math.Mod(l, r)
+		}
+	default:
+		return nil, errorf(n, "operator %s not defined on numbers", n.op)
+	}
+
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return nil, errorf(n, "number overflow")
+	}
+	return result, nil
+}
+
+// stringOp 字符串运算
+func stringOp(n *node, l, r string) (interface{}, error) {
+	switch n.op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	case "+":
+		return l + r, nil
+	}
+	return nil, errorf(n, "operator %s not defined on strings", n.op)
+}
+
+// evalCall 求值函数调用，函数 panic 时转换为错误
+func (e *evaluator) evalCall(n *node) (interface{}, error) {
+	args := make([]interface{}, 0, len(n.args))
+	for _, arg := range n.args {
+		value, err := e.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	result, err := callSafely(n.fn, args)
+	if err == nil && n.fn.Returns != "" && !n.fn.Returns.Accepts(result) {
+		err = fmt.Errorf("must return %s, got %T", n.fn.Returns, result)
+	}
+	if e.onCall != nil {
+		call := &valueobject.ExpressionTraceNode{
+			Kind:   valueobject.TraceNodeFunction,
+			Expr:   n.op,
+			Args:   args,
+			Result: result,
+		}
+		if err != nil {
+			call.Error = err.Error()
+		}
+		e.onCall(call)
+	}
+	if err != nil {
+		return nil, errorf(n, "function %s: %v", n.op, err)
+	}
+
+	// 函数执行期间可能已超时
+	if err := e.checkDeadline(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// callSafely 调用函数，函数 panic 时返回错误
+func callSafely(fn *valueobject.ExpressionFunction, args []interface{}) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn.Call(args...)
+}
+
+// normalize 将整数参数统一为 float64
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	default:
+		return value
+	}
+}
+
+// explain 构建节点的求值轨迹
+func (e *evaluator) explain(n *node) *valueobject.ExpressionTraceNode {
+	switch {
+	case n.kind == nodeBinary && (n.op == "&&" || n.op == "||"):
+		kind := valueobject.TraceNodeAnd
+		if n.op == "||" {
+			kind = valueobject.TraceNodeOr
+		}
+		trace := &valueobject.ExpressionTraceNode{Kind: kind, Expr: e.text(n)}
+		for _, operand := range flatten(n, n.op) {
+			trace.Children = append(trace.Children, e.explain(operand))
+		}
+		trace.Result, trace.Error = combine(n.op, trace.Children)
+		return trace
+
+	case n.kind == nodeUnary && n.op == "!":
+		trace := &valueobject.ExpressionTraceNode{Kind: valueobject.TraceNodeNot, Expr: e.text(n)}
+		child := e.explain(n.args[0])
+		trace.Children = append(trace.Children, child)
+		if child.Error != "" {
+			trace.Error = child.Error
+		} else if b, ok := child.Result.(bool); ok {
+			trace.Result = !b
+		} else {
+			trace.Error = fmt.Sprintf("operator ! requires bool, got %T", child.Result)
+		}
+		return trace
+	}
+
+	return e.explainClause(n)
+}
+
+// explainClause 求值不含逻辑运算的子表达式，并记录其中的函数调用与引用的参数
+func (e *evaluator) explainClause(n *node) *valueobject.ExpressionTraceNode {
+	trace := &valueobject.ExpressionTraceNode{Kind: valueobject.TraceNodeClause, Expr: e.text(n)}
+
+	var collect func(n *node)
+	collect = func(n *node) {
+		if n.kind == nodeIdent {
+			if trace.Inputs == nil {
+				trace.Inputs = make(map[string]interface{})
+			}
+			trace.Inputs[n.op] = e.args[n.op]
+		}
+		for _, arg := range n.args {
+			collect(arg)
+		}
+	}
+	collect(n)
+
+	e.onCall = func(call *valueobject.ExpressionTraceNode) {
+		trace.Children = append(trace.Children, call)
+	}
+	defer func() { e.onCall = nil }()
+
+	result, err := e.eval(n)
+	trace.Result = result
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	return trace
+}
+
+// text 节点对应的表达式原文
+func (e *evaluator) text(n *node) string {
+	return e.source[n.start:n.end]
+}
+
+// flatten 展开同一逻辑运算的连续运算数，如 a && b && c 展开为 [a, b, c]
+// 带括号的运算数保持为一个整体
+func flatten(n *node, op string) []*node {
+	if n.kind != nodeBinary || n.op != op {
+		return []*node{n}
+	}
+	left, right := n.args[0], n.args[1]
+	operands := []*node{left}
+	if !left.paren && left.kind == nodeBinary && left.op == op {
+		operands = flatten(left, op)
+	}
+	return append(operands, right)
+}
+
+// combine 按短路语义合并逻辑运算数的求值结果，与 Eval 的结果一致
+func combine(op string, children []*valueobject.ExpressionTraceNode) (interface{}, string) {
+	for _, child := range children {
+		if child.Error != "" {
+			return nil, child.Error
+		}
+		b, ok := child.Result.(bool)
+		if !ok {
+			return nil, fmt.Sprintf("operator %s requires bool, got %T", op, child.Result)
+		}
+		if (op == "&&" && !b) || (op == "||" && b) {
+			return b, ""
+		}
+	}
+	return op == "&&", ""
+}
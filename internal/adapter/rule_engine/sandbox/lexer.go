@@ -0,0 +1,144 @@
+package sandbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenTrue
+	tokenFalse
+	tokenOperator // 运算符与分隔符：|| && ! == != < <= > >= + - * / % ( ) [ ] ,
+)
+
+// token 词法单元
+type token struct {
+	kind   tokenKind
+	text   string  // 运算符或标识符原文
+	number float64 // tokenNumber 的值
+	str    string  // tokenString 去掉引号和转义后的值
+	start  int     // 在表达式中的起始位置（字节）
+	end    int
+}
+
+// operators 运算符，长运算符在前以便优先匹配
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "!", "<", ">", "+", "-", "*", "/", "%", "(", ")", "[", "]", ","}
+
+// tokenize 将表达式拆分为词法单元
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case isDigit(c):
+			j := i
+			for j < len(expr) && isDigit(expr[j]) {
+				j++
+			}
+			if j < len(expr) && expr[j] == '.' {
+				j++
+				if j >= len(expr) || !isDigit(expr[j]) {
+					return nil, fmt.Errorf("invalid number at position %d", i)
+				}
+				for j < len(expr) && isDigit(expr[j]) {
+					j++
+				}
+			}
+			number, err := strconv.ParseFloat(expr[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", expr[i:j], i)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: expr[i:j], number: number, start: i, end: j})
+			i = j
+
+		case c == '\'' || c == '"':
+			str, end, err := scanString(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: expr[i:end], str: str, start: i, end: end})
+			i = end
+
+		case isIdentStart(c):
+			j := i
+			for j < len(expr) && (isIdentStart(expr[j]) || isDigit(expr[j])) {
+				j++
+			}
+			kind := tokenIdent
+			switch expr[i:j] {
+			case "true":
+				kind = tokenTrue
+			case "false":
+				kind = tokenFalse
+			}
+			tokens = append(tokens, token{kind: kind, text: expr[i:j], start: i, end: j})
+			i = j
+
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, start: i, end: i + len(op)})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, start: len(expr), end: len(expr)}), nil
+}
+
+// scanString 读取从 start 开始的字符串字面量，返回去掉引号和转义后的值与结束位置
+func scanString(expr string, start int) (string, int, error) {
+	quote := expr[start]
+	var b strings.Builder
+	for i := start + 1; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\':
+			i++
+			if i >= len(expr) {
+				break
+			}
+			switch expr[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '\'', '"':
+				b.WriteByte(expr[i])
+			default:
+				return "", 0, fmt.Errorf("invalid escape \\%c at position %d", expr[i], i-1)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at position %d", start)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
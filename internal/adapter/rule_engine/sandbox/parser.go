@@ -0,0 +1,260 @@
+package sandbox
+
+import (
+	"fmt"
+	"math"
+
+	"mini-sirus/internal/domain/valueobject"
+)
+
+// node 语法树节点
+type node struct {
+	kind  nodeKind
+	op    string // 运算符（unary/binary）、标识符名（ident）、函数名（call）
+	value interface{}
+	args  []*node // 运算数、函数实参或列表元素
+	start int     // 在表达式中的范围，用于错误信息和求值轨迹
+	end   int
+	paren bool // 是否被括号包裹
+
+	// 以下字段由类型检查填充
+	typ valueobject.ValueType
+	fn  *valueobject.ExpressionFunction // call 节点绑定的函数
+}
+
+// nodeKind 语法树节点类型
+type nodeKind int
+
+const (
+	nodeLiteral nodeKind = iota // 数值、字符串、布尔、ID 列表字面量
+	nodeIdent                   // 参数引用
+	nodeUnary                   // ! -
+	nodeBinary                  // || && 比较 算术
+	nodeCall                    // 函数调用
+)
+
+// parser 递归下降语法分析器
+// 优先级从低到高：|| → && → 比较 → + - → * / % → 一元 ! - → 基本表达式
+type parser struct {
+	tokens   []token
+	pos      int
+	depth    int
+	maxDepth int
+}
+
+// parse 解析表达式为语法树
+func parse(expr string, limits Limits) (*node, error) {
+	if limits.MaxLength > 0 && len(expr) > limits.MaxLength {
+		return nil, fmt.Errorf("expression longer than %d characters", limits.MaxLength)
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, maxDepth: limits.MaxDepth}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.start)
+	}
+	return root, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isOperator 判断下一个词法单元是否为指定运算符之一
+func (p *parser) isOperator(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+// expect 读取指定运算符
+func (p *parser) expect(op string) (token, error) {
+	if !p.isOperator(op) {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return tok, fmt.Errorf("expected %q at end of expression", op)
+		}
+		return tok, fmt.Errorf("expected %q at position %d, got %q", op, tok.start, tok.text)
+	}
+	return p.next(), nil
+}
+
+// enter 进入一层嵌套，超过深度限制时报错，避免恶意表达式耗尽栈空间
+func (p *parser) enter() error {
+	p.depth++
+	if p.maxDepth > 0 && p.depth > p.maxDepth {
+		return fmt.Errorf("expression nested deeper than %d levels", p.maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) parseExpr() (*node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	return p.parseBinary(0)
+}
+
+// binaryLevels 二元运算符优先级，从低到高
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// parseBinary 解析指定优先级的左结合二元运算
+func (p *parser) parseBinary(level int) (*node, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(binaryLevels[level]...) {
+		op := p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &node{kind: nodeBinary, op: op.text, args: []*node{left, right}, start: left.start, end: right.end}
+
+		// 比较运算不可连写，如 1 < x < 3
+		if level == 2 && p.isOperator(binaryLevels[level]...) {
+			return nil, fmt.Errorf("comparison operators cannot be chained at position %d", p.peek().start)
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (*node, error) {
+	if !p.isOperator("!", "-") {
+		return p.parsePrimary()
+	}
+
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	op := p.next()
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &node{kind: nodeUnary, op: op.text, args: []*node{operand}, start: op.start, end: operand.end}, nil
+}
+
+func (p *parser) parsePrimary() (*node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		return &node{kind: nodeLiteral, value: tok.number, start: tok.start, end: tok.end}, nil
+	case tokenString:
+		return &node{kind: nodeLiteral, value: tok.str, start: tok.start, end: tok.end}, nil
+	case tokenTrue, tokenFalse:
+		return &node{kind: nodeLiteral, value: tok.kind == tokenTrue, start: tok.start, end: tok.end}, nil
+	case tokenIdent:
+		if !p.isOperator("(") {
+			return &node{kind: nodeIdent, op: tok.text, start: tok.start, end: tok.end}, nil
+		}
+		p.next()
+		args, end, err := p.parseList(")")
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeCall, op: tok.text, args: args, start: tok.start, end: end}, nil
+	case tokenOperator:
+		switch tok.text {
+		case "(":
+			inner, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			closing, err := p.expect(")")
+			if err != nil {
+				return nil, err
+			}
+			// 括号只影响结构，节点范围包含括号以便轨迹展示原文
+			inner.start, inner.end, inner.paren = tok.start, closing.end, true
+			return inner, nil
+		case "[":
+			items, end, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return p.idList(items, tok.start, end)
+		}
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.start)
+	default:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+}
+
+// parseList 解析以 closing 结尾、逗号分隔的表达式列表（左括号已读取）
+func (p *parser) parseList(closing string) ([]*node, int, error) {
+	var items []*node
+	if p.isOperator(closing) {
+		return items, p.next().end, nil
+	}
+	for {
+		item, err := p.parseExpr()
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+		if p.isOperator(",") {
+			p.next()
+			continue
+		}
+		tok, err := p.expect(closing)
+		if err != nil {
+			return nil, 0, err
+		}
+		return items, tok.end, nil
+	}
+}
+
+// idList 将列表字面量转换为 ID 列表常量，元素必须是非负整数字面量
+func (p *parser) idList(items []*node, start, end int) (*node, error) {
+	ids := make([]uint64, 0, len(items))
+	for _, item := range items {
+		number, ok := item.value.(float64)
+		if item.kind != nodeLiteral || !ok || number < 0 || number != math.Trunc(number) {
+			return nil, fmt.Errorf("list items must be non-negative integers at position %d", item.start)
+		}
+		ids = append(ids, uint64(number))
+	}
+	return &node{kind: nodeLiteral, value: ids, start: start, end: end}, nil
+}
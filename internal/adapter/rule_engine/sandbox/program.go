@@ -0,0 +1,121 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"mini-sirus/internal/domain/valueobject"
+)
+
+var (
+	// ErrStepLimit 求值步数超过限制
+	ErrStepLimit = errors.New("expression step limit exceeded")
+	// ErrTimeout 求值耗时超过限制
+	ErrTimeout = errors.New("expression evaluation timed out")
+)
+
+// Limits 表达式解析与求值限制，各项为 0 表示不限制
+type Limits struct {
+	MaxLength int           // 表达式最大长度（字节）
+	MaxDepth  int           // 最大嵌套深度（括号、一元运算、函数实参）
+	MaxSteps  int           // 单次求值最大步数（每个语法树节点计一步）
+	Timeout   time.Duration // 单次求值最大耗时
+}
+
+// DefaultLimits 默认限制
+func DefaultLimits() Limits {
+	return Limits{
+		MaxLength: 4096,
+		MaxDepth:  64,
+		MaxSteps:  10000,
+		Timeout:   50 * time.Millisecond,
+	}
+}
+
+// Program 已解析并通过类型检查的表达式，可并发求值
+type Program struct {
+	source string
+	root   *node
+	limits Limits
+}
+
+// Compile 解析表达式并做类型检查
+// functions 为可调用的函数集；params 为参数名到类型的映射，为 nil 时不检查参数
+func Compile(
+	expr string,
+	functions valueobject.ExpressionFunctions,
+	params map[string]valueobject.ValueType,
+	limits Limits,
+) (*Program, error) {
+	root, err := parse(expr, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{functions: functions, params: params}
+	if err := c.check(root); err != nil {
+		return nil, err
+	}
+
+	return &Program{source: expr, root: root, limits: limits}, nil
+}
+
+// Type 表达式结果的静态类型，参数类型未知时可能为 any
+func (p *Program) Type() valueobject.ValueType {
+	return p.root.typ
+}
+
+// Vars 表达式引用的参数名（去重并排序）
+func (p *Program) Vars() []string {
+	seen := make(map[string]bool)
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n.kind == nodeIdent {
+			seen[n.op] = true
+		}
+		for _, arg := range n.args {
+			walk(arg)
+		}
+	}
+	walk(p.root)
+
+	vars := make([]string, 0, len(seen))
+	for name := range seen {
+		vars = append(vars, name)
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+// Eval 求值，超过步数或耗时限制、运行期类型不匹配、函数出错或 panic 时返回错误
+func (p *Program) Eval(ctx context.Context, args valueobject.ExpressionArguments) (interface{}, error) {
+	return p.newEvaluator(ctx, args).eval(p.root)
+}
+
+// Explain 求值并返回求值轨迹
+// 逻辑运算按运算数拆分为子节点且不短路，其余子表达式作为整体求值并记录其中的函数调用
+func (p *Program) Explain(ctx context.Context, args valueobject.ExpressionArguments) *valueobject.ExpressionTraceNode {
+	return p.newEvaluator(ctx, args).explain(p.root)
+}
+
+// newEvaluator 创建单次求值使用的求值器
+func (p *Program) newEvaluator(ctx context.Context, args valueobject.ExpressionArguments) *evaluator {
+	e := &evaluator{ctx: ctx, source: p.source, args: args, maxSteps: p.limits.MaxSteps}
+	if p.limits.Timeout > 0 {
+		e.deadline = time.Now().Add(p.limits.Timeout)
+	}
+	return e
+}
+
+// String 返回表达式原文
+func (p *Program) String() string {
+	return p.source
+}
+
+// errorf 生成带位置的求值错误
+func errorf(n *node, format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), n.start)
+}
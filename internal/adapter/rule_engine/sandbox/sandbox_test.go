@@ -0,0 +1,222 @@
+package sandbox
+
+import (
+	"context"
+	"mini-sirus/internal/domain/valueobject"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFunctions 测试使用的表达式函数集
+var testFunctions = valueobject.ExpressionFunctions{
+	"LIKE_COUNT_GTE": {
+		Params:  []valueobject.ValueType{valueobject.ValueTypeNumber, valueobject.ValueTypeNumber},
+		Returns: valueobject.ValueTypeBool,
+		Call: func(args ...interface{}) (interface{}, error) {
+			return args[0].(float64) >= args[1].(float64), nil
+		},
+	},
+	"IS_AUDITED": {
+		Params:  []valueobject.ValueType{valueobject.ValueTypeBool},
+		Returns: valueobject.ValueTypeBool,
+		Call: func(args ...interface{}) (interface{}, error) {
+			return args[0].(bool), nil
+		},
+	},
+}
+
+const testCondExpr = "LIKE_COUNT_GTE(like_count, 10) && IS_AUDITED(is_audited) && comment_count >= 1"
+
+var (
+	testArgs  = valueobject.ExpressionArguments{"like_count": 12.0, "is_audited": true, "comment_count": 1.0}
+	testTypes = map[string]valueobject.ValueType{
+		"like_count":    valueobject.ValueTypeNumber,
+		"is_audited":    valueobject.ValueTypeBool,
+		"comment_count": valueobject.ValueTypeNumber,
+	}
+)
+
+func TestTokenize(t *testing.T) {
+	tokens, err := tokenize(`LEN('a\'b') >= 1.5 || !x`)
+	require.NoError(t, err)
+	kinds := make([]tokenKind, 0, len(tokens))
+	for _, tok := range tokens {
+		kinds = append(kinds, tok.kind)
+	}
+	assert.Equal(t, []tokenKind{
+		tokenIdent, tokenOperator, tokenString, tokenOperator, tokenOperator,
+		tokenNumber, tokenOperator, tokenOperator, tokenIdent, tokenEOF,
+	}, kinds)
+	assert.Equal(t, "a'b", tokens[2].str)
+	assert.Equal(t, ">=", tokens[4].text, "长运算符优先匹配")
+	assert.Equal(t, 1.5, tokens[5].number)
+	assert.Equal(t, 23, tokens[8].start)
+
+	for _, expr := range []string{"1.", "'open", `'\x'`, "a # b"} {
+		_, err := tokenize(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestParse(t *testing.T) {
+	// 优先级：&& 高于 ||，* 高于 +
+	root, err := parse("a || b && c", DefaultLimits())
+	require.NoError(t, err)
+	assert.Equal(t, "||", root.op)
+	assert.Equal(t, "&&", root.args[1].op)
+	root, err = parse("1 + 2 * 3", DefaultLimits())
+	require.NoError(t, err)
+	assert.Equal(t, "+", root.op)
+	assert.Equal(t, "*", root.args[1].op)
+
+	// 括号节点的范围包含括号
+	root, err = parse("(a) && b", DefaultLimits())
+	require.NoError(t, err)
+	assert.True(t, root.args[0].paren)
+	assert.Equal(t, 0, root.args[0].start)
+	assert.Equal(t, 3, root.args[0].end)
+
+	// 列表字面量转换为 ID 列表
+	root, err = parse("[1, 2, 3]", DefaultLimits())
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, root.value)
+
+	for _, expr := range []string{"", "a &&", "(a", "a b", "1 < a < 3", "[1.5]", "[-1]", "[a]"} {
+		_, err := parse(expr, DefaultLimits())
+		assert.Error(t, err, expr)
+	}
+
+	// 长度与嵌套深度限制
+	limits := Limits{MaxLength: 16, MaxDepth: 4}
+	_, err = parse(strings.Repeat("a || ", 4)+"a", limits)
+	assert.Error(t, err)
+	_, err = parse("((((a))))", limits)
+	assert.Error(t, err)
+	_, err = parse("!!!!!a", limits)
+	assert.Error(t, err)
+}
+
+func TestCompile(t *testing.T) {
+	program, err := Compile(testCondExpr, testFunctions, testTypes, DefaultLimits())
+	require.NoError(t, err)
+	assert.Equal(t, valueobject.ValueTypeBool, program.Type())
+	assert.Equal(t, []string{"comment_count", "is_audited", "like_count"}, program.Vars())
+	assert.Equal(t, testCondExpr, program.String())
+
+	// 按函数签名与参数类型做静态检查
+	for _, expr := range []string{
+		"LIKE_COUNT_GTE(like_count, 'ten')",
+		"LIKE_COUNT_GTE(like_count)",
+		"like_count && is_audited",
+		"like_count + 'a' > 1",
+		"like_cnt > 1",
+		"UNKNOWN(like_count)",
+		"-is_audited",
+	} {
+		_, err := Compile(expr, testFunctions, testTypes, DefaultLimits())
+		assert.Error(t, err, expr)
+	}
+
+	// 参数类型未知时放宽检查，由运行期检查兜底
+	program, err = Compile("like_count && is_audited", testFunctions, nil, DefaultLimits())
+	require.NoError(t, err)
+	_, err = program.Eval(context.Background(), testArgs)
+	assert.Error(t, err)
+}
+
+func TestEval(t *testing.T) {
+	ctx := context.Background()
+	eval := func(expr string, functions valueobject.ExpressionFunctions) (interface{}, error) {
+		program, err := Compile(expr, functions, nil, DefaultLimits())
+		if err != nil {
+			return nil, err
+		}
+		return program.Eval(ctx, testArgs)
+	}
+
+	for expr, want := range map[string]interface{}{
+		testCondExpr: true,
+		"like_count > 10 && !(comment_count == 0 || is_audited == false)": true,
+		"(like_count - 2) * 2 % 7 >= 6":                                   true,
+		"like_count * 2 + comment_count":                                  25.0,
+		"-like_count + 2":                                                 -10.0,
+		"'ab' + 'c' == 'abc'":                                             true,
+		"false && missing > 1":                                            false,
+		"true || missing > 1":                                             true,
+	} {
+		got, err := eval(expr, testFunctions)
+		require.NoError(t, err, expr)
+		assert.Equal(t, want, got, expr)
+	}
+
+	// 运行期错误、函数 panic 均以错误返回
+	functions := valueobject.ExpressionFunctions{
+		"BOOM": {Returns: valueobject.ValueTypeBool, Call: func(args ...interface{}) (interface{}, error) {
+			panic("boom")
+		}},
+		"WRONG": {Returns: valueobject.ValueTypeBool, Call: func(args ...interface{}) (interface{}, error) {
+			return 1.0, nil
+		}},
+	}
+	for _, expr := range []string{"BOOM()", "WRONG()", "like_count / 0 > 1", "missing > 1", "is_audited > 1"} {
+		_, err := eval(expr, functions)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestEvalLimits(t *testing.T) {
+	ctx := context.Background()
+	limits := Limits{MaxSteps: 10, Timeout: 10 * time.Millisecond}
+
+	program, err := Compile(strings.Repeat("like_count > 1 && ", 5)+"true", nil, nil, limits)
+	require.NoError(t, err)
+	_, err = program.Eval(ctx, testArgs)
+	assert.ErrorIs(t, err, ErrStepLimit)
+
+	functions := valueobject.ExpressionFunctions{
+		"SLOW": {Returns: valueobject.ValueTypeBool, Call: func(args ...interface{}) (interface{}, error) {
+			time.Sleep(20 * time.Millisecond)
+			return true, nil
+		}},
+	}
+	program, err = Compile("SLOW() && SLOW()", functions, nil, limits)
+	require.NoError(t, err)
+	_, err = program.Eval(ctx, testArgs)
+	assert.ErrorIs(t, err, ErrTimeout)
+}
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+
+	program, err := Compile("(like_count > 10 || comment_count > 0) && !(is_audited)", nil, nil, DefaultLimits())
+	require.NoError(t, err)
+	root := program.Explain(ctx, testArgs)
+	assert.Equal(t, valueobject.TraceNodeAnd, root.Kind)
+	assert.Equal(t, false, root.Result)
+	require.Len(t, root.Children, 2)
+	assert.Equal(t, valueobject.TraceNodeOr, root.Children[0].Kind)
+	assert.Len(t, root.Children[0].Children, 2)
+	assert.Equal(t, "!(is_audited)", root.FirstFailed().Expr)
+
+	// 连续的同一逻辑运算合并为一个节点，函数调用记录在所在的子表达式下
+	program, err = Compile(testCondExpr, testFunctions, testTypes, DefaultLimits())
+	require.NoError(t, err)
+	root = program.Explain(ctx, testArgs)
+	assert.Equal(t, true, root.Result)
+	require.Len(t, root.Children, 3)
+	require.Len(t, root.Children[0].Children, 1)
+	assert.Equal(t, "LIKE_COUNT_GTE", root.Children[0].Children[0].Expr)
+	assert.Equal(t, []interface{}{12.0, 10.0}, root.Children[0].Children[0].Args)
+
+	// 不短路求值，但结果按短路语义合并
+	program, err = Compile("like_count < 1 && missing > 1", nil, nil, DefaultLimits())
+	require.NoError(t, err)
+	root = program.Explain(ctx, testArgs)
+	assert.Equal(t, false, root.Result)
+	assert.Empty(t, root.Error)
+	assert.NotEmpty(t, root.Children[1].Error)
+}
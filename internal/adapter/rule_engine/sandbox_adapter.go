@@ -0,0 +1,216 @@
+package rule_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/adapter/rule_engine/sandbox"
+	"mini-sirus/internal/domain/valueobject"
+	"reflect"
+	"sync"
+)
+
+// SandboxAdapter 规则引擎适配器（基于强类型沙箱表达式语言）
+// 表达式编译时按函数签名与参数类型做类型检查；求值受步数与耗时限制，
+// 运行期类型不匹配、除零、函数 panic 均以错误返回，适合执行运营配置的不可信表达式。
+// 编译缓存规则与 GovaluateAdapter 相同，调用方应复用同一个函数集 map
+type SandboxAdapter struct {
+	mu        sync.RWMutex
+	functions valueobject.ExpressionFunctions
+	version   uint64 // 注册函数的版本号，每次注册递增
+	cache     *expressionCache
+	limits    sandbox.Limits
+}
+
+// NewSandboxAdapter 创建沙箱规则引擎适配器，cacheSize <= 0 时不缓存
+func NewSandboxAdapter(limits sandbox.Limits, cacheSize int) *SandboxAdapter {
+	return &SandboxAdapter{
+		functions: make(valueobject.ExpressionFunctions),
+		cache:     newExpressionCache(cacheSize),
+		limits:    limits,
+	}
+}
+
+// Evaluate 执行表达式求值
+func (a *SandboxAdapter) Evaluate(
+	ctx context.Context,
+	expr string,
+	functions valueobject.ExpressionFunctions,
+	args valueobject.ExpressionArguments,
+) (bool, error) {
+	if expr == "" {
+		// 空表达式默认返回 true
+		return true, nil
+	}
+
+	result, err := a.evaluate(ctx, expr, functions, args)
+	if err != nil {
+		return false, err
+	}
+
+	reach, ok := result.(bool)
+	if !ok {
+		return false, errors.New("expression result must be bool")
+	}
+	return reach, nil
+}
+
+// EvaluateNumber 执行数值表达式求值
+func (a *SandboxAdapter) EvaluateNumber(
+	ctx context.Context,
+	expr string,
+	functions valueobject.ExpressionFunctions,
+	args valueobject.ExpressionArguments,
+) (float64, error) {
+	if expr == "" {
+		return 0, errors.New("expression cannot be empty")
+	}
+
+	result, err := a.evaluate(ctx, expr, functions, args)
+	if err != nil {
+		return 0, err
+	}
+
+	number, ok := result.(float64)
+	if !ok {
+		return 0, errors.New("expression result must be number")
+	}
+	return number, nil
+}
+
+// Explain 执行表达式求值并返回求值轨迹
+func (a *SandboxAdapter) Explain(
+	ctx context.Context,
+	expr string,
+	functions valueobject.ExpressionFunctions,
+	args valueobject.ExpressionArguments,
+) (*valueobject.ExpressionTraceNode, error) {
+	if expr == "" {
+		// 与 Evaluate 一致，空表达式默认返回 true
+		return &valueobject.ExpressionTraceNode{Kind: valueobject.TraceNodeClause, Result: true}, nil
+	}
+
+	program, err := a.compile(expr, functions)
+	if err != nil {
+		return nil, fmt.Errorf("parse expression failed: %w", err)
+	}
+	return program.Explain(ctx, args), nil
+}
+
+// Validate 编译并做类型检查，不执行求值
+// 表达式结果必须是布尔值或数值（参数类型未知时不限制）
+func (a *SandboxAdapter) Validate(
+	ctx context.Context,
+	expr string,
+	functions valueobject.ExpressionFunctions,
+	params map[string]valueobject.ValueType,
+) error {
+	if expr == "" {
+		return &valueobject.ExpressionError{Expr: expr, Reason: "expression cannot be empty"}
+	}
+
+	program, err := sandbox.Compile(expr, a.mergeFunctions(functions), params, a.limits)
+	if err != nil {
+		return &valueobject.ExpressionError{Expr: expr, Reason: err.Error()}
+	}
+
+	switch program.Type() {
+	case valueobject.ValueTypeBool, valueobject.ValueTypeNumber, valueobject.ValueTypeAny:
+		return nil
+	default:
+		return &valueobject.ExpressionError{Expr: expr, Reason: fmt.Sprintf("expression result must be bool or number, got %s", program.Type())}
+	}
+}
+
+// compile 获取已编译的表达式，未命中缓存时合并函数并编译（优先使用传入的函数）
+// 求值路径上参数类型未知，类型检查以函数签名为准，参数类型在求值时检查
+func (a *SandboxAdapter) compile(expr string, functions valueobject.ExpressionFunctions) (*sandbox.Program, error) {
+	a.mu.RLock()
+	key := expressionCacheKey{
+		expr:        expr,
+		version:     a.version,
+		functionSet: reflect.ValueOf(functions).Pointer(),
+	}
+	a.mu.RUnlock()
+	if compiled, ok := a.cache.get(key); ok {
+		return compiled.(*sandbox.Program), nil
+	}
+
+	program, err := sandbox.Compile(expr, a.mergeFunctions(functions), nil, a.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	a.cache.put(key, program, functions)
+	return program, nil
+}
+
+// mergeFunctions 合并注册的函数与传入的函数（优先使用传入的函数）
+func (a *SandboxAdapter) mergeFunctions(functions valueobject.ExpressionFunctions) valueobject.ExpressionFunctions {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	merged := make(valueobject.ExpressionFunctions, len(a.functions)+len(functions))
+	for name, fn := range a.functions {
+		merged[name] = fn
+	}
+	for name, fn := range functions {
+		merged[name] = fn
+	}
+	return merged
+}
+
+// evaluate 编译并执行表达式
+func (a *SandboxAdapter) evaluate(
+	ctx context.Context,
+	expr string,
+	functions valueobject.ExpressionFunctions,
+	args valueobject.ExpressionArguments,
+) (interface{}, error) {
+	program, err := a.compile(expr, functions)
+	if err != nil {
+		return nil, fmt.Errorf("parse expression failed: %w", err)
+	}
+
+	result, err := program.Eval(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate expression failed: %w", err)
+	}
+	return result, nil
+}
+
+// RegisterFunction 注册自定义函数
+func (a *SandboxAdapter) RegisterFunction(name string, fn *valueobject.ExpressionFunction) error {
+	if name == "" {
+		return errors.New("function name cannot be empty")
+	}
+	if fn == nil || fn.Call == nil {
+		return errors.New("function cannot be nil")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.functions[name] = fn
+	a.version++
+	a.cache.purge()
+	return nil
+}
+
+// GetRegisteredFunctions 获取所有注册的函数
+func (a *SandboxAdapter) GetRegisteredFunctions() valueobject.ExpressionFunctions {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	// 返回副本，避免外部修改
+	functions := make(valueobject.ExpressionFunctions, len(a.functions))
+	for name, fn := range a.functions {
+		functions[name] = fn
+	}
+	return functions
+}
+
+// CacheStats 获取编译缓存统计
+func (a *SandboxAdapter) CacheStats() ExpressionCacheStats {
+	return a.cache.stats()
+}
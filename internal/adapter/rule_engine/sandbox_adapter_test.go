@@ -0,0 +1,72 @@
+package rule_engine
+
+import (
+	"context"
+	"mini-sirus/internal/adapter/rule_engine/sandbox"
+	"mini-sirus/internal/domain/valueobject"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxAdapter(t *testing.T) {
+	ctx := context.Background()
+	functions := valueobject.ExpressionFunctions{
+		"LIKE_COUNT_GTE": {
+			Params:  []valueobject.ValueType{valueobject.ValueTypeNumber, valueobject.ValueTypeNumber},
+			Returns: valueobject.ValueTypeBool,
+			Call: func(args ...interface{}) (interface{}, error) {
+				return args[0].(float64) >= args[1].(float64), nil
+			},
+		},
+	}
+	args := valueobject.ExpressionArguments{"like_count": 12.0, "is_audited": true, "comment_count": 1.0}
+	adapter := NewSandboxAdapter(sandbox.DefaultLimits(), 16)
+
+	// 与 govaluate 求值结果一致
+	reference := NewGovaluateAdapter()
+	for _, expr := range []string{
+		"LIKE_COUNT_GTE(like_count, 10) && is_audited && comment_count >= 1",
+		"like_count > 10 && !(comment_count == 0 || is_audited == false)",
+		"(like_count - 2) * 2 % 7 >= 6",
+		"like_count >= 100 || LIKE_COUNT_GTE(like_count, 12)",
+	} {
+		want, err := reference.Evaluate(ctx, expr, functions, args)
+		require.NoError(t, err, expr)
+		got, err := adapter.Evaluate(ctx, expr, functions, args)
+		require.NoError(t, err, expr)
+		assert.Equal(t, want, got, expr)
+	}
+	number, err := adapter.EvaluateNumber(ctx, "like_count * 2 + comment_count", nil, args)
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, number)
+
+	// 校验失败统一包装为 ErrInvalidExpression，非布尔表达式不能作为条件
+	types := map[string]valueobject.ValueType{
+		"like_count":    valueobject.ValueTypeNumber,
+		"is_audited":    valueobject.ValueTypeBool,
+		"comment_count": valueobject.ValueTypeNumber,
+	}
+	require.NoError(t, adapter.Validate(ctx, "LIKE_COUNT_GTE(like_count, 10) && is_audited", functions, types))
+	for _, expr := range []string{"LIKE_COUNT_GTE(like_count, 'ten')", "1 < like_count < 3", "'result'"} {
+		assert.ErrorIs(t, adapter.Validate(ctx, expr, functions, types), valueobject.ErrInvalidExpression, expr)
+	}
+
+	// 运行期错误时条件视为未达成
+	reach, err := adapter.Evaluate(ctx, "like_count / 0 > 1", nil, args)
+	assert.Error(t, err)
+	assert.False(t, reach)
+
+	// 限制透传给沙箱
+	strict := NewSandboxAdapter(sandbox.Limits{MaxLength: 256, MaxDepth: 8, MaxSteps: 10, Timeout: 10 * time.Millisecond}, 0)
+	_, err = strict.Evaluate(ctx, strings.Repeat("like_count > 1 && ", 5)+"true", nil, args)
+	assert.ErrorIs(t, err, sandbox.ErrStepLimit)
+	_, err = strict.Evaluate(ctx, strings.Repeat("(", 10)+"true"+strings.Repeat(")", 10), nil, args)
+	assert.Error(t, err)
+
+	_, err = New("unknown", 0, sandbox.DefaultLimits())
+	assert.Error(t, err)
+}
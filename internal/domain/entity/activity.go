@@ -0,0 +1,162 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/valueobject"
+	"time"
+)
+
+// ErrActivityNotActive 活动未开始、已结束或未激活
+var ErrActivityNotActive = errors.New("activity is not active")
+
+// ErrActivityArchived 活动已归档
+var ErrActivityArchived = errors.New("activity is archived")
+
+// ErrInvalidActivityTransition 活动状态流转不合法
+var ErrInvalidActivityTransition = errors.New("invalid activity status transition")
+
+// activityTransitions 活动状态流转规则
+// inactive → active → expired，激活中的活动可暂停，暂停后可恢复或直接结束
+var activityTransitions = map[ActivityStatus][]ActivityStatus{
+	ActivityStatusInactive: {ActivityStatusActive, ActivityStatusExpired},
+	ActivityStatusActive:   {ActivityStatusPaused, ActivityStatusExpired},
+	ActivityStatusPaused:   {ActivityStatusActive, ActivityStatusExpired},
+}
+
+// ActivityTransitionError 活动状态流转错误，可通过 errors.Is(err, ErrInvalidActivityTransition) 判断
+type ActivityTransitionError struct {
+	ActivityID int64
+	From       ActivityStatus
+	To         ActivityStatus
+}
+
+// Error 实现 error 接口
+func (e *ActivityTransitionError) Error() string {
+	return fmt.Sprintf("%v: activity %d %s -> %s", ErrInvalidActivityTransition, e.ActivityID, e.From, e.To)
+}
+
+// Is 支持 errors.Is 判断
+func (e *ActivityTransitionError) Is(target error) bool {
+	return target == ErrInvalidActivityTransition
+}
+
+// ActActivity 活动实体
+type ActActivity struct {
+	ID        int64
+	Name      string
+	StartTime time.Time
+	EndTime   time.Time
+	Status    ActivityStatus
+	Published bool                     // 是否已发布，已发布但未到开始时间的活动由调度器按时激活
+	Archived  bool                     // 是否已归档，归档后不再参与调度且不可修改
+	Budget    valueobject.RewardBudget // 活动奖励预算，零值表示不限制
+	Version   int64                    // 乐观锁版本号，创建时为 1，每次更新递增
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// IsValid 验证活动是否有效
+func (a *ActActivity) IsValid() bool {
+	return a.Name != "" && !a.StartTime.IsZero() && a.EndTime.After(a.StartTime) && a.Budget.IsValid()
+}
+
+// IsActive 判断活动在 now 时是否激活
+func (a *ActActivity) IsActive(now time.Time) bool {
+	return a.Status == ActivityStatusActive && a.IsInTimeRange(now)
+}
+
+// IsInTimeRange 判断 now 是否在活动时间范围内
+func (a *ActActivity) IsInTimeRange(now time.Time) bool {
+	return now.After(a.StartTime) && now.Before(a.EndTime)
+}
+
+// IsExpired 判断活动是否已结束
+func (a *ActActivity) IsExpired() bool {
+	return a.Status == ActivityStatusExpired
+}
+
+// CanTransitionTo 判断能否流转到目标状态
+func (a *ActActivity) CanTransitionTo(to ActivityStatus) bool {
+	for _, allowed := range activityTransitions[a.Status] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo 流转到目标状态
+func (a *ActActivity) TransitionTo(to ActivityStatus, now time.Time) error {
+	if a.Archived {
+		return ErrActivityArchived
+	}
+	if !a.CanTransitionTo(to) {
+		return &ActivityTransitionError{ActivityID: a.ID, From: a.Status, To: to}
+	}
+	a.Status = to
+	a.UpdatedAt = now
+	return nil
+}
+
+// Publish 发布活动
+// 未激活的活动标记为已发布，已到开始时间则立即激活，否则等待调度器激活；暂停的活动恢复为激活中
+func (a *ActActivity) Publish(now time.Time) error {
+	if a.Archived {
+		return ErrActivityArchived
+	}
+
+	switch a.Status {
+	case ActivityStatusInactive:
+		if !now.Before(a.EndTime) {
+			return &ActivityTransitionError{ActivityID: a.ID, From: a.Status, To: ActivityStatusActive}
+		}
+		a.Published = true
+		a.UpdatedAt = now
+		if now.Before(a.StartTime) {
+			return nil
+		}
+		return a.TransitionTo(ActivityStatusActive, now)
+	case ActivityStatusPaused:
+		return a.TransitionTo(ActivityStatusActive, now)
+	default:
+		return &ActivityTransitionError{ActivityID: a.ID, From: a.Status, To: ActivityStatusActive}
+	}
+}
+
+// Archive 归档活动，进行中或暂停中的活动需先结束
+func (a *ActActivity) Archive(now time.Time) error {
+	if a.Archived {
+		return ErrActivityArchived
+	}
+	if a.Status == ActivityStatusActive || a.Status == ActivityStatusPaused {
+		return fmt.Errorf("%w: activity %d is %s, end it before archiving", ErrInvalidActivityTransition, a.ID, a.Status)
+	}
+	a.Archived = true
+	a.UpdatedAt = now
+	return nil
+}
+
+// ScheduledStatus 计算到达开始或结束时间时应自动流转到的状态
+// 返回 false 表示当前无需流转
+func (a *ActActivity) ScheduledStatus(now time.Time) (ActivityStatus, bool) {
+	if a.Archived {
+		return a.Status, false
+	}
+
+	switch a.Status {
+	case ActivityStatusInactive:
+		if !a.Published || now.Before(a.StartTime) {
+			return a.Status, false
+		}
+		if !now.Before(a.EndTime) {
+			return ActivityStatusExpired, true
+		}
+		return ActivityStatusActive, true
+	case ActivityStatusActive, ActivityStatusPaused:
+		if !now.Before(a.EndTime) {
+			return ActivityStatusExpired, true
+		}
+	}
+	return a.Status, false
+}
@@ -0,0 +1,106 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/valueobject"
+)
+
+// ErrBudgetExhausted 奖励预算已耗尽
+var ErrBudgetExhausted = errors.New("reward budget exhausted")
+
+// BudgetScopeType 预算范围类型
+type BudgetScopeType int
+
+const (
+	BudgetScopeActivity BudgetScopeType = 0 // 活动预算
+	BudgetScopeTask     BudgetScopeType = 1 // 任务预算（按任务定义）
+)
+
+// String 返回预算范围类型的字符串表示
+func (t BudgetScopeType) String() string {
+	switch t {
+	case BudgetScopeActivity:
+		return "activity"
+	case BudgetScopeTask:
+		return "task"
+	default:
+		return "unknown"
+	}
+}
+
+// BudgetScope 预算范围
+type BudgetScope struct {
+	Type BudgetScopeType
+	ID   int64 // 活动ID或任务定义ID
+}
+
+// String 返回预算范围的字符串表示
+func (s BudgetScope) String() string {
+	return fmt.Sprintf("%s:%d", s.Type, s.ID)
+}
+
+// NewActivityBudgetScope 创建活动预算范围
+func NewActivityBudgetScope(activityID int64) BudgetScope {
+	return BudgetScope{Type: BudgetScopeActivity, ID: activityID}
+}
+
+// NewTaskBudgetScope 创建任务预算范围
+func NewTaskBudgetScope(taskDefID int64) BudgetScope {
+	return BudgetScope{Type: BudgetScopeTask, ID: taskDefID}
+}
+
+// BudgetLine 预占时需要校验的一项预算
+type BudgetLine struct {
+	Scope  BudgetScope
+	Budget valueobject.RewardBudget
+}
+
+// BudgetReservation 预算预占
+// 同一次预占中的所有预算项要么全部预占成功，要么全部不预占
+type BudgetReservation struct {
+	ID          int64
+	UserID      int64
+	Lines       []BudgetLine
+	RewardUnits int64 // 本次达成消耗的激励值，即本次发放奖励的数量之和
+	Completion  bool  // 本次达成是否完成任务，完成时占用完成人数名额（同一用户只占用一次）
+}
+
+// BudgetUsage 预算已用量
+type BudgetUsage struct {
+	Scope       BudgetScope
+	RewardUnits int64 // 已预占的激励值
+	Completers  int64 // 已完成的用户数
+}
+
+// RemainingRewardUnits 剩余激励值，不限制时返回 -1
+func (u *BudgetUsage) RemainingRewardUnits(budget valueobject.RewardBudget) int64 {
+	if budget.MaxRewardUnits <= 0 {
+		return -1
+	}
+	return max(budget.MaxRewardUnits-u.RewardUnits, 0)
+}
+
+// RemainingCompleters 剩余完成人数名额，不限制时返回 -1
+func (u *BudgetUsage) RemainingCompleters(budget valueobject.RewardBudget) int64 {
+	if budget.MaxCompleters <= 0 {
+		return -1
+	}
+	return max(budget.MaxCompleters-u.Completers, 0)
+}
+
+// BudgetExhaustedError 预算耗尽错误，可通过 errors.Is(err, ErrBudgetExhausted) 判断
+type BudgetExhaustedError struct {
+	Scope  BudgetScope
+	Reason string // reward_units 或 completers
+}
+
+// Error 实现 error 接口
+func (e *BudgetExhaustedError) Error() string {
+	return fmt.Sprintf("%v: %s %s", ErrBudgetExhausted, e.Scope, e.Reason)
+}
+
+// Is 支持 errors.Is 判断
+func (e *BudgetExhaustedError) Is(target error) bool {
+	return target == ErrBudgetExhausted
+}
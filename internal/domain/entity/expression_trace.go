@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"mini-sirus/internal/domain/valueobject"
+	"time"
+)
+
+// ActExpressionTrace 任务达成条件的求值轨迹（按采样率记录）
+// 用于排查"事件为什么没有计入任务"
+type ActExpressionTrace struct {
+	ID         int64
+	UserID     int64
+	ActivityID int64
+	TaskID     int64 // 用户任务ID
+	TaskDefID  int64
+	UniqueFlag string // 触发事件的唯一标识
+	Expr       string
+	Reach      bool
+	Error      string
+	Root       *valueobject.ExpressionTraceNode
+	CreatedAt  time.Time
+}
+
+// IsValid 验证轨迹是否有效
+func (t *ActExpressionTrace) IsValid() bool {
+	return t.UserID > 0 && t.TaskID > 0 && t.Root != nil
+}
@@ -0,0 +1,149 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// RewardType 奖励类型
+type RewardType int
+
+const (
+	RewardTypePoints RewardType = 0 // 积分
+	RewardTypeCoupon RewardType = 1 // 优惠券
+	RewardTypeBadge  RewardType = 2 // 勋章
+)
+
+// String 返回奖励类型的字符串表示
+func (t RewardType) String() string {
+	switch t {
+	case RewardTypePoints:
+		return "points"
+	case RewardTypeCoupon:
+		return "coupon"
+	case RewardTypeBadge:
+		return "badge"
+	default:
+		return "unknown"
+	}
+}
+
+// RewardGrantTiming 奖励发放时机
+type RewardGrantTiming int
+
+const (
+	RewardGrantOnReach    RewardGrantTiming = 0 // 每次达成时发放
+	RewardGrantOnComplete RewardGrantTiming = 1 // 任务完成时发放（周期任务每个周期发放一次）
+)
+
+// ActTaskReward 任务奖励定义
+// 一个任务定义可以配置多个奖励，如每次达成送积分、完成后送优惠券和勋章
+type ActTaskReward struct {
+	ID          int64
+	TaskDefID   int64
+	Type        RewardType
+	Amount      int    // 积分数或优惠券张数，勋章固定为 1
+	ItemCode    string // 优惠券模板编码或勋章编码，积分为空
+	GrantTiming RewardGrantTiming
+	CreatedAt   time.Time
+}
+
+// IsValid 验证奖励定义是否有效
+func (r *ActTaskReward) IsValid() bool {
+	if r.TaskDefID <= 0 || r.Amount <= 0 {
+		return false
+	}
+
+	switch r.Type {
+	case RewardTypePoints:
+		return r.ItemCode == ""
+	case RewardTypeCoupon:
+		return r.ItemCode != ""
+	case RewardTypeBadge:
+		return r.ItemCode != "" && r.Amount == 1
+	default:
+		return false
+	}
+}
+
+// ShouldGrant 判断本次达成是否应发放该奖励
+// 按明细记录的达成结果判断，补发历史明细的奖励时不受任务当前状态影响
+func (r *ActTaskReward) ShouldGrant(detail *ActUserTaskDetail) bool {
+	if r.GrantTiming == RewardGrantOnComplete {
+		return detail.CompletesTask
+	}
+	return true
+}
+
+// ActRewardLedgerEntry 奖励流水实体
+// 流水只追加不修改，用户余额由流水累加得到
+type ActRewardLedgerEntry struct {
+	ID             int64
+	UserID         int64
+	ActivityID     int64
+	UserTaskID     int64
+	TaskDefID      int64
+	DetailID       int64
+	RewardID       int64
+	Type           RewardType
+	Amount         int
+	ItemCode       string
+	IdempotencyKey string // 幂等键，同一明细的同一奖励只记账一次
+	CreatedAt      time.Time
+}
+
+// RewardIdempotencyKey 生成奖励流水的幂等键
+// 基于任务明细的唯一标识，明细没有唯一标识时退化为明细ID
+func RewardIdempotencyKey(detail *ActUserTaskDetail, rewardID int64) string {
+	if detail.UniqueFlag != "" {
+		return fmt.Sprintf("%s#reward:%d", detail.UniqueFlag, rewardID)
+	}
+	return fmt.Sprintf("detail:%d#reward:%d", detail.ID, rewardID)
+}
+
+// ActUserWallet 用户奖励余额
+type ActUserWallet struct {
+	UserID    int64
+	Points    int64
+	Coupons   map[string]int       // 优惠券模板编码 -> 张数
+	Badges    map[string]time.Time // 勋章编码 -> 首次获得时间
+	UpdatedAt time.Time
+}
+
+// NewActUserWallet 创建空的用户余额
+func NewActUserWallet(userID int64) *ActUserWallet {
+	return &ActUserWallet{
+		UserID:  userID,
+		Coupons: make(map[string]int),
+		Badges:  make(map[string]time.Time),
+	}
+}
+
+// Apply 将奖励流水记入余额，勋章重复获得时保留首次获得时间
+func (w *ActUserWallet) Apply(entry *ActRewardLedgerEntry) {
+	switch entry.Type {
+	case RewardTypePoints:
+		w.Points += int64(entry.Amount)
+	case RewardTypeCoupon:
+		w.Coupons[entry.ItemCode] += entry.Amount
+	case RewardTypeBadge:
+		if _, owned := w.Badges[entry.ItemCode]; !owned {
+			w.Badges[entry.ItemCode] = entry.CreatedAt
+		}
+	}
+	w.UpdatedAt = entry.CreatedAt
+}
+
+// Clone 复制余额，避免调用方修改内部状态
+func (w *ActUserWallet) Clone() *ActUserWallet {
+	clone := NewActUserWallet(w.UserID)
+	clone.Points = w.Points
+	clone.UpdatedAt = w.UpdatedAt
+	for code, count := range w.Coupons {
+		clone.Coupons[code] = count
+	}
+	for code, obtainedAt := range w.Badges {
+		clone.Badges[code] = obtainedAt
+	}
+	return clone
+}
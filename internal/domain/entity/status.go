@@ -1,11 +1,14 @@
 package entity
 
+import "fmt"
+
 // TaskStatus 任务状态
 type TaskStatus int
 
 const (
 	TaskStatusPending TaskStatus = 0 // 进行中
 	TaskStatusDone    TaskStatus = 1 // 已完成
+	TaskStatusLocked  TaskStatus = 2 // 未解锁（前置任务未完成）
 )
 
 // String 返回状态的字符串表示
@@ -15,6 +18,8 @@ func (s TaskStatus) String() string {
 		return "pending"
 	case TaskStatusDone:
 		return "done"
+	case TaskStatusLocked:
+		return "locked"
 	default:
 		return "unknown"
 	}
@@ -47,6 +52,7 @@ const (
 	ActivityStatusInactive ActivityStatus = 0 // 未激活
 	ActivityStatusActive   ActivityStatus = 1 // 激活中
 	ActivityStatusExpired  ActivityStatus = 2 // 已过期
+	ActivityStatusPaused   ActivityStatus = 3 // 已暂停
 )
 
 // String 返回状态的字符串表示
@@ -58,8 +64,106 @@ func (s ActivityStatus) String() string {
 		return "active"
 	case ActivityStatusExpired:
 		return "expired"
+	case ActivityStatusPaused:
+		return "paused"
+	default:
+		return "unknown"
+	}
+}
+
+
+// TaskCreateMode 用户任务创建模式
+type TaskCreateMode int
+
+const (
+	TaskCreateModePreCreate TaskCreateMode = 0 // 预创建：通过批量预创建或显式创建生成用户任务
+	TaskCreateModeLazy      TaskCreateMode = 1 // 延迟创建：用户首次触发匹配事件时自动创建
+)
+
+// String 返回创建模式的字符串表示
+func (m TaskCreateMode) String() string {
+	switch m {
+	case TaskCreateModePreCreate:
+		return "pre_create"
+	case TaskCreateModeLazy:
+		return "lazy"
 	default:
 		return "unknown"
 	}
 }
 
+// ParseTaskCreateMode 解析创建模式，空字符串为预创建
+func ParseTaskCreateMode(s string) (TaskCreateMode, error) {
+	switch s {
+	case "", "pre_create":
+		return TaskCreateModePreCreate, nil
+	case "lazy":
+		return TaskCreateModeLazy, nil
+	default:
+		return TaskCreateModePreCreate, fmt.Errorf("unknown create mode: %s", s)
+	}
+}
+
+// TaskKind 任务计数方式
+type TaskKind int
+
+const (
+	TaskKindCount  TaskKind = 0 // 累计计数：每次达成进度 +1
+	TaskKindStreak TaskKind = 1 // 连续打卡：按自然日连续达成，断签后进度重置
+)
+
+// String 返回计数方式的字符串表示
+func (k TaskKind) String() string {
+	switch k {
+	case TaskKindCount:
+		return "count"
+	case TaskKindStreak:
+		return "streak"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseTaskKind 解析计数方式，空字符串为累计计数
+func ParseTaskKind(s string) (TaskKind, error) {
+	switch s {
+	case "", "count":
+		return TaskKindCount, nil
+	case "streak":
+		return TaskKindStreak, nil
+	default:
+		return TaskKindCount, fmt.Errorf("unknown task kind: %s", s)
+	}
+}
+
+// PrerequisiteMode 前置任务解锁方式
+type PrerequisiteMode int
+
+const (
+	PrerequisiteAllOf PrerequisiteMode = 0 // 全部前置任务完成后解锁
+	PrerequisiteAnyOf PrerequisiteMode = 1 // 任一前置任务完成后解锁
+)
+
+// String 返回解锁方式的字符串表示
+func (m PrerequisiteMode) String() string {
+	switch m {
+	case PrerequisiteAllOf:
+		return "all_of"
+	case PrerequisiteAnyOf:
+		return "any_of"
+	default:
+		return "unknown"
+	}
+}
+
+// ParsePrerequisiteMode 解析解锁方式，空字符串为全部完成后解锁
+func ParsePrerequisiteMode(s string) (PrerequisiteMode, error) {
+	switch s {
+	case "", "all_of":
+		return PrerequisiteAllOf, nil
+	case "any_of":
+		return PrerequisiteAnyOf, nil
+	default:
+		return PrerequisiteAllOf, fmt.Errorf("unknown prerequisite mode: %s", s)
+	}
+}
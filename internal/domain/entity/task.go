@@ -7,18 +7,32 @@ import (
 
 // ActUserTask 用户任务实体
 // 代表用户参与的活动任务，包含任务进度和状态
+// 任务的目标、条件等配置统一由 ActTaskDefinition 提供，TaskID 即任务定义ID
 type ActUserTask struct {
-	ID           int64
-	ActivityID   int64
-	TaskID       int64
-	UserID       int64
-	TaskType     valueobject.TaskType // 任务类型
-	Status       TaskStatus
-	Progress     int
-	Target       int
-	TaskCondExpr string // 任务条件表达式
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID         int64
+	ActivityID int64
+	TaskID     int64
+	UserID     int64
+	TaskType   valueobject.TaskType // 任务类型（冗余自任务定义，便于按类型检索）
+	Status     TaskStatus
+	Progress   int
+	PeriodKey  string // 当前进度所属周期，不重复任务为空
+
+	// 连续打卡任务状态，Progress 即当前连续天数
+	LastQualifiedDate string // 最近一次达成的自然日（2006-01-02）
+	BestStreak        int    // 历史最长连续天数
+	GraceUsed         int    // 已使用的补签卡数量
+
+	// FencingToken 最近一次写入时持有的用户任务锁令牌
+	// 仓储拒绝令牌小于已存储值的写入，避免锁过期后原持有者的迟到写入覆盖新数据
+	FencingToken uint64
+
+	// Version 乐观锁版本号，创建时为 1，每次更新递增
+	// 仓储仅在版本与已存储值一致时写入，读取后被其他写入修改过的任务不会被覆盖
+	Version int64
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 // IsCompleted 判断任务是否已完成
@@ -31,22 +45,166 @@ func (t *ActUserTask) IsPending() bool {
 	return t.Status == TaskStatusPending
 }
 
-// CanProgress 判断任务是否可以更新进度
-func (t *ActUserTask) CanProgress() bool {
-	return t.IsPending() && t.Progress < t.Target
+// IsLocked 判断任务是否未解锁
+func (t *ActUserTask) IsLocked() bool {
+	return t.Status == TaskStatusLocked
 }
 
-// UpdateProgress 更新任务进度
-func (t *ActUserTask) UpdateProgress() {
-	if !t.CanProgress() {
+// Unlock 解锁任务
+func (t *ActUserTask) Unlock() {
+	if !t.IsLocked() {
 		return
 	}
+	t.Status = TaskStatusPending
+	t.UpdatedAt = time.Now()
+}
+
+// CanProgress 判断任务是否可以更新进度
+func (t *ActUserTask) CanProgress(def *ActTaskDefinition) bool {
+	return t.IsPending() && t.Progress < def.Target
+}
+
+// UpdateProgress 更新任务进度（+1）
+func (t *ActUserTask) UpdateProgress(def *ActTaskDefinition) {
+	t.AddProgress(def, 1)
+}
+
+// AddProgress 按增量累加任务进度，超过目标时截断到目标值，返回实际增加的进度
+// 连续打卡任务不按增量累计，使用 CheckIn 推进
+func (t *ActUserTask) AddProgress(def *ActTaskDefinition, delta int) int {
+	if def.IsStreak() || !t.CanProgress(def) || delta <= 0 {
+		return 0
+	}
+
+	before := t.Progress
+	t.Progress += delta
+	t.completeIfReached(def)
+	t.UpdatedAt = time.Now()
+	return t.Progress - before
+}
+
+// CheckIn 连续打卡任务按打卡日推进连续天数，返回本次计入的天数
+// 打卡日必须是 now 在打卡规则时区下的当天；日期不符或当天已打卡时不计入，返回 0。
+// 断签重置时连续天数从 1 重新开始，本次计入 1 天
+func (t *ActUserTask) CheckIn(def *ActTaskDefinition, day string, now time.Time) int {
+	if !def.IsStreak() || !t.CanProgress(def) || day != def.Streak.DayKey(now) {
+		return 0
+	}
+
+	before := t.Progress
+	if !t.advanceStreak(def, day) {
+		return 0
+	}
+	t.completeIfReached(def)
+	t.UpdatedAt = now
+	if t.Progress > before {
+		return t.Progress - before
+	}
+	return t.Progress
+}
 
-	t.Progress++
-	if t.Progress >= t.Target {
+// completeIfReached 进度达到目标时截断到目标值并完成任务
+func (t *ActUserTask) completeIfReached(def *ActTaskDefinition) {
+	if t.Progress >= def.Target {
+		t.Progress = def.Target
 		t.Status = TaskStatusDone
 	}
+}
+
+// advanceStreak 推进连续打卡天数，同一天重复达成时返回 false
+// 断签天数不超过剩余补签卡时自动补签，补签的天数计入连续天数；否则从 1 重新开始
+func (t *ActUserTask) advanceStreak(def *ActTaskDefinition, today string) bool {
+	if t.LastQualifiedDate == today {
+		return false
+	}
+
+	missed := t.missedDays(today)
+	switch {
+	case t.LastQualifiedDate == "" || missed < 0:
+		t.Progress = 1
+	case missed == 0:
+		t.Progress++
+	case missed <= def.Streak.GraceCards-t.GraceUsed:
+		t.GraceUsed += missed
+		t.Progress += missed + 1
+	default:
+		t.Progress = 1
+	}
+
+	t.LastQualifiedDate = today
+	if t.Progress > t.BestStreak {
+		t.BestStreak = t.Progress
+	}
+	return true
+}
+
+// missedDays 计算最近一次达成到指定日期之间断签的天数，无法计算时返回 -1
+func (t *ActUserTask) missedDays(day string) int {
+	if t.LastQualifiedDate == "" {
+		return -1
+	}
+	days, err := valueobject.DaysBetween(t.LastQualifiedDate, day)
+	if err != nil || days < 1 {
+		return -1
+	}
+	return days - 1
+}
+
+// CurrentStreak 获取时间点 now 的连续天数
+// 已断签且剩余补签卡不足以补齐时返回 0
+func (t *ActUserTask) CurrentStreak(def *ActTaskDefinition, now time.Time) int {
+	if !def.IsStreak() || t.LastQualifiedDate == "" || t.IsCompleted() {
+		return t.Progress
+	}
+
+	today := def.Streak.DayKey(now)
+	if t.LastQualifiedDate == today {
+		return t.Progress
+	}
+
+	missed := t.missedDays(today)
+	if missed >= 0 && missed <= def.Streak.GraceCards-t.GraceUsed {
+		return t.Progress
+	}
+	return 0
+}
+
+// GraceCardsLeft 获取剩余补签卡数量
+func (t *ActUserTask) GraceCardsLeft(def *ActTaskDefinition) int {
+	if !def.IsStreak() {
+		return 0
+	}
+	return max(def.Streak.GraceCards-t.GraceUsed, 0)
+}
+
+// RollPeriod 按任务定义的周期切换到时间点 now 所处的周期
+// 进入新周期时重置进度和状态，返回是否发生切换以及上一周期的进度快照（无进度时为 nil）
+func (t *ActUserTask) RollPeriod(def *ActTaskDefinition, now time.Time) (*ActUserTaskPeriod, bool) {
+	periodKey := def.PeriodKeyAt(now)
+	if t.PeriodKey == periodKey {
+		return nil, false
+	}
+
+	var archived *ActUserTaskPeriod
+	if t.Progress > 0 {
+		archived = &ActUserTaskPeriod{
+			UserTaskID: t.ID,
+			TaskID:     t.TaskID,
+			UserID:     t.UserID,
+			PeriodKey:  t.PeriodKey,
+			Progress:   t.Progress,
+			Status:     t.Status,
+			CreatedAt:  time.Now(),
+		}
+	}
+
+	t.PeriodKey = periodKey
+	t.Progress = 0
+	if !t.IsLocked() {
+		t.Status = TaskStatusPending
+	}
 	t.UpdatedAt = time.Now()
+	return archived, true
 }
 
 // IsValid 验证任务实体是否有效
@@ -54,8 +212,7 @@ func (t *ActUserTask) IsValid() bool {
 	return t.ActivityID > 0 &&
 		t.TaskID > 0 &&
 		t.UserID > 0 &&
-		t.Target > 0 &&
-		t.TaskCondExpr != ""
+		t.TaskType.IsValid()
 }
 
 // IsExpired 判断任务是否过期（简化版本，实际应关联活动）
@@ -63,17 +220,33 @@ func (t *ActUserTask) IsExpired(validDays int) bool {
 	return time.Since(t.CreatedAt) > time.Duration(validDays)*24*time.Hour
 }
 
+// ActUserTaskPeriod 用户任务周期进度实体
+// 周期任务切换周期时归档的历史进度
+type ActUserTaskPeriod struct {
+	ID         int64
+	UserTaskID int64 // 用户任务ID
+	TaskID     int64 // 任务定义ID
+	UserID     int64
+	PeriodKey  string
+	Progress   int
+	Status     TaskStatus
+	CreatedAt  time.Time
+}
+
 // ActUserTaskDetail 用户任务明细实体
 // 代表任务的每次完成记录
 type ActUserTaskDetail struct {
-	ID          int64
-	TaskID      int64
-	UserID      int64
-	Status      TaskDetailStatus
-	UniqueFlag  string // 唯一标识，防止重复
-	RewardValue int    // 激励值
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID            int64
+	TaskID        int64
+	UserID        int64
+	Status        TaskDetailStatus
+	UniqueFlag    string // 唯一标识，防止重复
+	RewardValue   int    // 激励值
+	PeriodKey     string // 所属周期，不重复任务为空
+	ProgressDelta int    // 本次达成实际增加的进度
+	CompletesTask bool   // 本次达成是否使任务完成
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 // IsCompleted 判断明细是否已完成
@@ -91,27 +264,3 @@ func (d *ActUserTaskDetail) Complete() {
 	d.Status = TaskDetailStatusDone
 	d.UpdatedAt = time.Now()
 }
-
-// ActActivity 活动实体
-type ActActivity struct {
-	ID        int64
-	Name      string
-	StartTime time.Time
-	EndTime   time.Time
-	Status    ActivityStatus
-}
-
-// IsActive 判断活动是否激活
-func (a *ActActivity) IsActive() bool {
-	now := time.Now()
-	return a.Status == ActivityStatusActive &&
-		now.After(a.StartTime) &&
-		now.Before(a.EndTime)
-}
-
-// IsInTimeRange 判断是否在活动时间范围内
-func (a *ActActivity) IsInTimeRange() bool {
-	now := time.Now()
-	return now.After(a.StartTime) && now.Before(a.EndTime)
-}
-
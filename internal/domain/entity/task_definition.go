@@ -0,0 +1,178 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/valueobject"
+	"strings"
+	"time"
+)
+
+// ErrPrerequisiteCycle 前置任务之间存在循环依赖，相关任务都无法解锁
+var ErrPrerequisiteCycle = errors.New("prerequisite cycle")
+
+// ActTaskDefinition 任务定义实体（任务模板）
+// 描述活动下某个任务的配置，所有用户的任务实例共享同一份定义
+type ActTaskDefinition struct {
+	ID               int64
+	ActivityID       int64
+	Name             string
+	TaskType         valueobject.TaskType       // 任务类型
+	TaskCondExpr     string                     // 任务条件表达式
+	ProgressExpr     string                     // 进度增量表达式，为空时每次达成进度 +1
+	Parameters       valueobject.TaskParameters // 任务参数（如要求的话题），求值时合并到表达式参数中
+	Target           int                        // 目标次数
+	RewardValue      int                        // 每次达成的激励值
+	CreateMode       TaskCreateMode             // 用户任务创建模式
+	Recurrence       valueobject.Recurrence     // 任务周期（每日/每周/每月重置）
+	Kind             TaskKind                   // 计数方式
+	Streak           valueobject.StreakPolicy   // 连续打卡规则，仅 TaskKindStreak 生效
+	Prerequisites    []int64                    // 前置任务定义ID，为空表示无需解锁
+	PrerequisiteMode PrerequisiteMode           // 前置任务解锁方式
+	Budget           valueobject.RewardBudget   // 任务奖励预算，零值表示不限制
+	Archived         bool                       // 是否已归档，归档后不再创建用户任务也不再计入进度
+	StartTime        time.Time                  // 生效开始时间（零值表示不限制）
+	EndTime          time.Time                  // 生效结束时间（零值表示不限制）
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// IsValid 验证任务定义是否有效
+func (d *ActTaskDefinition) IsValid() bool {
+	if d.ActivityID <= 0 || d.Name == "" || d.Target <= 0 || d.TaskCondExpr == "" {
+		return false
+	}
+	if !d.TaskType.IsValid() || !d.Recurrence.IsValid() || !d.Budget.IsValid() {
+		return false
+	}
+	if d.Parameters.Validate() != nil {
+		return false
+	}
+	if d.IsStreak() && (!d.Streak.IsValid() || d.Recurrence.IsRecurring() || d.ProgressExpr != "") {
+		// 连续打卡任务按自然日计算，不能再叠加周期重置或加权进度
+		return false
+	}
+	for _, prereqID := range d.Prerequisites {
+		if prereqID <= 0 || prereqID == d.ID {
+			return false
+		}
+	}
+	if !d.StartTime.IsZero() && !d.EndTime.IsZero() && !d.EndTime.After(d.StartTime) {
+		return false
+	}
+	return true
+}
+
+// HasPrerequisites 判断是否配置了前置任务
+func (d *ActTaskDefinition) HasPrerequisites() bool {
+	return len(d.Prerequisites) > 0
+}
+
+// DependsOn 判断是否以指定任务定义为前置任务
+func (d *ActTaskDefinition) DependsOn(taskDefID int64) bool {
+	for _, prereqID := range d.Prerequisites {
+		if prereqID == taskDefID {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPrerequisiteCycle 校验任务定义的前置任务与同一活动下的其他任务定义不构成循环依赖
+// defs 中与 d 同ID的定义以 d 为准，新建的任务定义（ID 为 0）不会被其他任务依赖，不构成循环
+func (d *ActTaskDefinition) CheckPrerequisiteCycle(defs []*ActTaskDefinition) error {
+	if d.ID == 0 || !d.HasPrerequisites() {
+		return nil
+	}
+
+	prerequisites := make(map[int64][]int64, len(defs)+1)
+	for _, def := range defs {
+		prerequisites[def.ID] = def.Prerequisites
+	}
+	prerequisites[d.ID] = d.Prerequisites
+
+	// 深度优先搜索从 d 出发能否沿前置任务回到 d
+	visited := make(map[int64]bool)
+	var path []int64
+	var visit func(id int64) bool
+	visit = func(id int64) bool {
+		path = append(path, id)
+		for _, prereqID := range prerequisites[id] {
+			if prereqID == d.ID {
+				path = append(path, prereqID)
+				return true
+			}
+			if !visited[prereqID] {
+				visited[prereqID] = true
+				if visit(prereqID) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if !visit(d.ID) {
+		return nil
+	}
+
+	steps := make([]string, len(path))
+	for i, id := range path {
+		steps[i] = fmt.Sprint(id)
+	}
+	return fmt.Errorf("%w: %s", ErrPrerequisiteCycle, strings.Join(steps, " -> "))
+}
+
+// PrerequisitesMet 根据已完成的任务定义判断前置条件是否满足
+func (d *ActTaskDefinition) PrerequisitesMet(completed map[int64]bool) bool {
+	if !d.HasPrerequisites() {
+		return true
+	}
+
+	for _, prereqID := range d.Prerequisites {
+		done := completed[prereqID]
+		if d.PrerequisiteMode == PrerequisiteAnyOf && done {
+			return true
+		}
+		if d.PrerequisiteMode != PrerequisiteAnyOf && !done {
+			return false
+		}
+	}
+	return d.PrerequisiteMode != PrerequisiteAnyOf
+}
+
+// IsLazy 判断是否在首次触发事件时延迟创建用户任务
+func (d *ActTaskDefinition) IsLazy() bool {
+	return d.CreateMode == TaskCreateModeLazy
+}
+
+// IsWeighted 判断是否按表达式计算进度增量
+func (d *ActTaskDefinition) IsWeighted() bool {
+	return d.ProgressExpr != ""
+}
+
+// IsStreak 判断是否为连续打卡任务
+func (d *ActTaskDefinition) IsStreak() bool {
+	return d.Kind == TaskKindStreak
+}
+
+// PeriodKeyAt 获取时间点所处的周期标识，不重复任务返回空字符串
+func (d *ActTaskDefinition) PeriodKeyAt(now time.Time) string {
+	return d.Recurrence.PeriodKey(now)
+}
+
+// IsEffective 判断任务定义在 now 时是否生效（未归档且在生效时间范围内）
+func (d *ActTaskDefinition) IsEffective(now time.Time) bool {
+	return !d.Archived && d.IsInTimeRange(now)
+}
+
+// IsInTimeRange 判断 now 是否在任务定义的生效时间范围内
+func (d *ActTaskDefinition) IsInTimeRange(now time.Time) bool {
+	if !d.StartTime.IsZero() && now.Before(d.StartTime) {
+		return false
+	}
+	if !d.EndTime.IsZero() && !now.Before(d.EndTime) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,23 @@
+package event
+
+import (
+	"mini-sirus/internal/domain/entity"
+	"time"
+)
+
+// ActivityStatusTrigger 活动状态变更来源
+type ActivityStatusTrigger string
+
+const (
+	ActivityStatusTriggerManual    ActivityStatusTrigger = "manual"    // 运营手动操作
+	ActivityStatusTriggerScheduler ActivityStatusTrigger = "scheduler" // 调度器按开始/结束时间自动流转
+)
+
+// ActivityStatusChanged 活动状态变更事件
+type ActivityStatusChanged struct {
+	ActivityID int64
+	From       entity.ActivityStatus
+	To         entity.ActivityStatus
+	Trigger    ActivityStatusTrigger
+	ChangedAt  time.Time
+}
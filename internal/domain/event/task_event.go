@@ -12,23 +12,34 @@ type TaskCompleted struct {
 	CompletedAt time.Time
 }
 
+// TaskUnlocked 任务解锁事件
+type TaskUnlocked struct {
+	TaskID     int64 // 被解锁的用户任务ID
+	TaskDefID  int64 // 被解锁的任务定义ID
+	UserID     int64
+	ActivityID int64
+	UnlockedBy int64 // 触发解锁的用户任务ID
+	UnlockedAt time.Time
+}
+
 // TaskProgressUpdated 任务进度更新事件
 type TaskProgressUpdated struct {
-	TaskID      int64
-	UserID      int64
-	Progress    int
-	Target      int
-	UpdatedAt   time.Time
+	TaskID    int64
+	UserID    int64
+	Progress  int
+	Target    int
+	UpdatedAt time.Time
 }
 
 // TaskDetailCreated 任务明细创建事件
 type TaskDetailCreated struct {
-	DetailID    int64
-	TaskID      int64
-	UserID      int64
-	UniqueFlag  string
-	RewardValue int
-	CreatedAt   time.Time
+	DetailID      int64
+	TaskID        int64
+	UserID        int64
+	UniqueFlag    string
+	RewardValue   int
+	ProgressDelta int
+	CreatedAt     time.Time
 }
 
 // PublishEvent 发布事件（业务事件）
@@ -50,3 +61,29 @@ type CheckinEvent struct {
 	CheckinAt   time.Time
 }
 
+// ShareEvent 分享事件（业务事件）
+type ShareEvent struct {
+	UserID    int64
+	ContentID int64
+	AuthorID  int64
+	Channel   string
+	SharedAt  time.Time
+}
+
+// LikeEvent 点赞事件（业务事件）
+type LikeEvent struct {
+	UserID    int64
+	ContentID int64
+	AuthorID  int64
+	LikedAt   time.Time
+}
+
+// CommentEvent 评论事件（业务事件）
+type CommentEvent struct {
+	UserID      int64
+	ContentID   int64
+	CommentID   int64
+	AuthorID    int64
+	Text        string
+	CommentedAt time.Time
+}
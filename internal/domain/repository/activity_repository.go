@@ -2,20 +2,28 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"mini-sirus/internal/domain/entity"
 )
 
+// ErrActivityNotFound 活动不存在
+var ErrActivityNotFound = errors.New("activity not found")
+
 // ActivityRepository 活动仓储接口
 type ActivityRepository interface {
 	// Create 创建活动
 	Create(ctx context.Context, activity *entity.ActActivity) error
 
 	// Update 更新活动
+	// 活动的 Version 与已存储的版本号不一致时返回 *VersionConflictError，成功时 Version 递增
 	Update(ctx context.Context, activity *entity.ActActivity) error
 
 	// GetByID 根据ID获取活动
 	GetByID(ctx context.Context, activityID int64) (*entity.ActActivity, error)
 
+	// List 获取全部活动（按ID升序）
+	List(ctx context.Context) ([]*entity.ActActivity, error)
+
 	// ListActive 获取活动中的活动列表
 	ListActive(ctx context.Context) ([]*entity.ActActivity, error)
 }
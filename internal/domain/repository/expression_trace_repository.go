@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"mini-sirus/internal/domain/entity"
+)
+
+// ExpressionTraceRepository 表达式求值轨迹仓储接口
+type ExpressionTraceRepository interface {
+	// Create 保存求值轨迹
+	Create(ctx context.Context, trace *entity.ActExpressionTrace) error
+
+	// ListByUserID 获取用户最近的求值轨迹（按时间倒序），limit <= 0 时返回全部
+	ListByUserID(ctx context.Context, userID int64, limit int) ([]*entity.ActExpressionTrace, error)
+
+	// ListByTaskID 获取用户任务最近的求值轨迹（按时间倒序），limit <= 0 时返回全部
+	ListByTaskID(ctx context.Context, taskID int64, limit int) ([]*entity.ActExpressionTrace, error)
+}
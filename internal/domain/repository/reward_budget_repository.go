@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"mini-sirus/internal/domain/entity"
+)
+
+// RewardBudgetRepository 奖励预算仓储接口
+type RewardBudgetRepository interface {
+	// Reserve 原子地预占预算
+	// 任一预算项不足时全部不预占，返回 *entity.BudgetExhaustedError
+	Reserve(ctx context.Context, reservation *entity.BudgetReservation) error
+
+	// Release 释放预占（任务明细写入失败时回滚）
+	Release(ctx context.Context, reservationID int64) error
+
+	// GetUsage 获取预算范围的已用量
+	GetUsage(ctx context.Context, scope entity.BudgetScope) (*entity.BudgetUsage, error)
+}
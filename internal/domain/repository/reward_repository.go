@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"mini-sirus/internal/domain/entity"
+)
+
+// RewardRepository 任务奖励定义仓储接口
+type RewardRepository interface {
+	// Create 创建奖励定义
+	Create(ctx context.Context, reward *entity.ActTaskReward) error
+
+	// ListByTaskDefID 获取任务定义配置的奖励列表
+	ListByTaskDefID(ctx context.Context, taskDefID int64) ([]*entity.ActTaskReward, error)
+}
+
+// RewardLedgerRepository 奖励流水仓储接口
+type RewardLedgerRepository interface {
+	// Append 追加奖励流水并原子地更新用户余额
+	// 幂等键已存在时不重复记账，返回 false
+	Append(ctx context.Context, entry *entity.ActRewardLedgerEntry) (bool, error)
+
+	// ListByUserID 获取用户的奖励流水（按记账顺序）
+	ListByUserID(ctx context.Context, userID int64) ([]*entity.ActRewardLedgerEntry, error)
+
+	// GetWallet 获取用户奖励余额，没有流水时返回空余额
+	GetWallet(ctx context.Context, userID int64) (*entity.ActUserWallet, error)
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"mini-sirus/internal/domain/entity"
+)
+
+// ErrTaskDefinitionNotFound 任务定义不存在
+var ErrTaskDefinitionNotFound = errors.New("task definition not found")
+
+// TaskDefinitionRepository 任务定义仓储接口
+type TaskDefinitionRepository interface {
+	// Create 创建任务定义
+	Create(ctx context.Context, def *entity.ActTaskDefinition) error
+
+	// Update 更新任务定义
+	Update(ctx context.Context, def *entity.ActTaskDefinition) error
+
+	// GetByID 根据ID获取任务定义
+	GetByID(ctx context.Context, defID int64) (*entity.ActTaskDefinition, error)
+
+	// ListByActivityID 获取活动下的任务定义列表
+	ListByActivityID(ctx context.Context, activityID int64) ([]*entity.ActTaskDefinition, error)
+}
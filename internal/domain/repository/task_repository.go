@@ -2,19 +2,53 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"mini-sirus/internal/domain/entity"
 	"mini-sirus/internal/domain/valueobject"
+	"time"
 )
 
+// ErrUserTaskExists 同一用户同一任务定义的用户任务已存在
+var ErrUserTaskExists = errors.New("user task already exists")
+
+// ErrStaleFencingToken 写入携带的锁令牌早于已存储的令牌（锁已过期并被其他持有者获取）
+var ErrStaleFencingToken = errors.New("stale fencing token")
+
+// ActivityParticipant 活动参与用户（按用户聚合的用户任务）
+type ActivityParticipant struct {
+	UserID         int64
+	TaskCount      int       // 参与的任务数
+	CompletedCount int       // 已完成的任务数
+	JoinedAt       time.Time // 最早创建用户任务的时间
+}
+
+// TaskCompletionStat 任务定义维度的完成统计
+type TaskCompletionStat struct {
+	TaskDefID    int64
+	Participants int // 创建了该任务的用户数
+	Completed    int // 当前已完成的用户数
+	Locked       int // 尚未解锁的用户数
+}
+
 // TaskRepository 任务仓储接口
 // 定义任务数据访问的抽象，具体实现在 adapter 层
 type TaskRepository interface {
 	// Create 创建任务
+	// 同一用户同一任务定义已存在时返回 ErrUserTaskExists
 	Create(ctx context.Context, task *entity.ActUserTask) error
 
+	// BatchCreate 批量创建任务
+	// 同一用户同一任务定义已存在时跳过，返回实际创建的数量
+	BatchCreate(ctx context.Context, tasks []*entity.ActUserTask) (int, error)
+
 	// Update 更新任务
+	// 任务的 FencingToken 小于已存储的令牌时返回 ErrStaleFencingToken，
+	// Version 与已存储的版本号不一致时返回 *VersionConflictError，成功时 Version 递增
 	Update(ctx context.Context, task *entity.ActUserTask) error
 
+	// UpdateBatch 原子地更新多个任务，任一任务不存在、令牌过期或版本冲突时全部不更新
+	UpdateBatch(ctx context.Context, tasks []*entity.ActUserTask) error
+
 	// GetByID 根据ID获取任务
 	GetByID(ctx context.Context, taskID int64) (*entity.ActUserTask, error)
 
@@ -24,8 +58,14 @@ type TaskRepository interface {
 	// ListByUserIDAndType 根据用户ID和任务类型获取任务列表
 	ListByUserIDAndType(ctx context.Context, userID int64, taskType valueobject.TaskType) ([]*entity.ActUserTask, error)
 
-	// UpdateProgress 更新任务进度
-	UpdateProgress(ctx context.Context, taskID int64) error
+	// ListParticipants 分页获取活动参与用户（按用户ID升序），返回当前页和总人数
+	ListParticipants(ctx context.Context, activityID int64, offset, limit int) ([]*ActivityParticipant, int, error)
+
+	// StatsByActivityID 按任务定义统计活动的完成情况
+	StatsByActivityID(ctx context.Context, activityID int64) ([]*TaskCompletionStat, error)
+
+	// MaxPendingProgress 获取任务定义下进行中用户任务的最大进度，没有进行中的任务时返回 0
+	MaxPendingProgress(ctx context.Context, taskDefID int64) (int, error)
 }
 
 // TaskDetailRepository 任务明细仓储接口
@@ -39,7 +79,19 @@ type TaskDetailRepository interface {
 	// ListByTaskID 根据任务ID获取明细列表
 	ListByTaskID(ctx context.Context, taskID int64) ([]*entity.ActUserTaskDetail, error)
 
-	// ExistsByUniqueFlag 判断唯一标识是否已存在
-	ExistsByUniqueFlag(ctx context.Context, uniqueFlag string) (bool, error)
+	// FindByUniqueFlag 查找用户任务下指定唯一标识的明细，不存在时返回 nil。
+	// 同一事件可以同时推进多个任务，去重只在单个用户任务内生效
+	FindByUniqueFlag(ctx context.Context, taskID int64, uniqueFlag string) (*entity.ActUserTaskDetail, error)
+
+	// Delete 删除任务明细，用于任务进度未能持久化时撤销已写入的明细
+	Delete(ctx context.Context, detailID int64) error
 }
 
+// TaskPeriodRepository 任务周期进度仓储接口
+type TaskPeriodRepository interface {
+	// Create 归档周期进度
+	Create(ctx context.Context, period *entity.ActUserTaskPeriod) error
+
+	// ListByUserTaskID 获取用户任务的历史周期进度（按周期升序）
+	ListByUserTaskID(ctx context.Context, userTaskID int64) ([]*entity.ActUserTaskPeriod, error)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrVersionConflict 写入携带的版本号与已存储的版本号不一致（读取后已被其他写入修改）
+var ErrVersionConflict = errors.New("version conflict")
+
+// VersionConflictError 版本冲突错误，可通过 errors.Is(err, ErrVersionConflict) 判断
+type VersionConflictError struct {
+	Entity   string // 实体名称，如 user_task、activity
+	ID       int64
+	Expected int64 // 写入携带的版本号
+	Actual   int64 // 已存储的版本号
+}
+
+// Error 实现 error 接口
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%v: %s %d written with version %d, stored %d",
+		ErrVersionConflict, e.Entity, e.ID, e.Expected, e.Actual)
+}
+
+// Is 支持 errors.Is 判断
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
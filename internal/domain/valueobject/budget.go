@@ -0,0 +1,23 @@
+package valueobject
+
+// RewardBudget 奖励预算值对象
+// 各项上限为 0 表示不限制
+type RewardBudget struct {
+	MaxRewardUnits int64 // 激励值总量上限
+	MaxCompleters  int64 // 完成人数上限
+}
+
+// NewRewardBudget 创建奖励预算
+func NewRewardBudget(maxRewardUnits, maxCompleters int64) RewardBudget {
+	return RewardBudget{MaxRewardUnits: maxRewardUnits, MaxCompleters: maxCompleters}
+}
+
+// IsValid 验证预算是否有效
+func (b RewardBudget) IsValid() bool {
+	return b.MaxRewardUnits >= 0 && b.MaxCompleters >= 0
+}
+
+// IsLimited 判断是否配置了任一上限
+func (b RewardBudget) IsLimited() bool {
+	return b.MaxRewardUnits > 0 || b.MaxCompleters > 0
+}
@@ -1,5 +1,29 @@
 package valueobject
 
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidExpression 表达式不合法（语法错误、引用未声明的函数或参数）
+var ErrInvalidExpression = errors.New("invalid expression")
+
+// ExpressionError 表达式校验错误，可通过 errors.Is(err, ErrInvalidExpression) 判断
+type ExpressionError struct {
+	Expr   string
+	Reason string
+}
+
+// Error 实现 error 接口
+func (e *ExpressionError) Error() string {
+	return fmt.Sprintf("%v: %s, expression: %q", ErrInvalidExpression, e.Reason, e.Expr)
+}
+
+// Is 支持 errors.Is 判断
+func (e *ExpressionError) Is(target error) bool {
+	return target == ErrInvalidExpression
+}
+
 // Expression 表达式值对象
 type Expression struct {
 	value string
@@ -0,0 +1,60 @@
+package valueobject
+
+// ValueType 表达式值类型
+type ValueType string
+
+const (
+	ValueTypeNumber ValueType = "number"   // 数值（float64）
+	ValueTypeString ValueType = "string"   // 字符串
+	ValueTypeBool   ValueType = "bool"     // 布尔值
+	ValueTypeIDList ValueType = "[]uint64" // ID 列表，如话题ID列表
+	ValueTypeAny    ValueType = "any"      // 任意类型（类型未知时使用）
+)
+
+// Accepts 判断值是否符合类型
+func (t ValueType) Accepts(value interface{}) bool {
+	if t == ValueTypeAny {
+		return true
+	}
+	return TypeOf(value) == t
+}
+
+// TypeOf 获取值对应的表达式类型，无法识别时返回 ValueTypeAny
+func TypeOf(value interface{}) ValueType {
+	switch value.(type) {
+	case float64:
+		return ValueTypeNumber
+	case string:
+		return ValueTypeString
+	case bool:
+		return ValueTypeBool
+	case []uint64:
+		return ValueTypeIDList
+	default:
+		return ValueTypeAny
+	}
+}
+
+// ExpressionFunction 表达式函数，与具体规则引擎无关
+// 签名供规则引擎做静态类型检查，Call 在调用前应自行校验实参
+type ExpressionFunction struct {
+	Params   []ValueType
+	Variadic bool // 最后一个参数可重复出现
+	Returns  ValueType
+	Call     func(args ...interface{}) (interface{}, error)
+}
+
+// ParamType 获取第 i 个实参的声明类型，超出参数个数时返回 false
+func (f *ExpressionFunction) ParamType(i int) (ValueType, bool) {
+	switch {
+	case i < len(f.Params):
+		return f.Params[i], true
+	case f.Variadic && len(f.Params) > 0:
+		return f.Params[len(f.Params)-1], true
+	default:
+		return "", false
+	}
+}
+
+// ExpressionFunctions 表达式函数集（函数名 -> 函数）
+type ExpressionFunctions map[string]*ExpressionFunction
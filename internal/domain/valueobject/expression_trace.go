@@ -0,0 +1,47 @@
+package valueobject
+
+// TraceNodeKind 求值轨迹节点类型
+type TraceNodeKind string
+
+const (
+	TraceNodeAnd      TraceNodeKind = "and"      // 逻辑与
+	TraceNodeOr       TraceNodeKind = "or"       // 逻辑或
+	TraceNodeNot      TraceNodeKind = "not"      // 逻辑非
+	TraceNodeClause   TraceNodeKind = "clause"   // 不含顶层逻辑运算的子表达式
+	TraceNodeFunction TraceNodeKind = "function" // 函数调用
+)
+
+// ExpressionTraceNode 表达式求值轨迹节点
+// 逻辑运算节点的子节点为各操作数，子表达式节点的子节点为其中的函数调用
+type ExpressionTraceNode struct {
+	Kind     TraceNodeKind          `json:"kind"`
+	Expr     string                 `json:"expr"`             // 子表达式文本或函数名
+	Inputs   map[string]interface{} `json:"inputs,omitempty"` // 子表达式引用的参数值
+	Args     []interface{}          `json:"args,omitempty"`   // 函数调用的实参
+	Result   interface{}            `json:"result"`
+	Error    string                 `json:"error,omitempty"`
+	Children []*ExpressionTraceNode `json:"children,omitempty"`
+}
+
+// Passed 判断节点结果是否为 true
+func (n *ExpressionTraceNode) Passed() bool {
+	result, ok := n.Result.(bool)
+	return ok && result && n.Error == ""
+}
+
+// FirstFailed 查找第一个未通过的子表达式，用于快速定位未达成的原因
+// 全部通过时返回 nil
+func (n *ExpressionTraceNode) FirstFailed() *ExpressionTraceNode {
+	if n.Passed() {
+		return nil
+	}
+	for _, child := range n.Children {
+		if child.Kind == TraceNodeFunction {
+			continue
+		}
+		if failed := child.FirstFailed(); failed != nil {
+			return failed
+		}
+	}
+	return n
+}
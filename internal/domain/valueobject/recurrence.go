@@ -0,0 +1,89 @@
+package valueobject
+
+import (
+	"fmt"
+	"time"
+)
+
+// RecurrenceType 任务周期类型
+type RecurrenceType string
+
+const (
+	RecurrenceNone    RecurrenceType = "none"    // 不重复
+	RecurrenceDaily   RecurrenceType = "daily"   // 每日重置
+	RecurrenceWeekly  RecurrenceType = "weekly"  // 每周重置（周一为一周开始）
+	RecurrenceMonthly RecurrenceType = "monthly" // 每月重置
+)
+
+// Recurrence 任务周期值对象
+// 周期任务的进度按周期分桶统计，周期切换时进度重置
+type Recurrence struct {
+	Type     RecurrenceType
+	Timezone string // IANA 时区，如 Asia/Shanghai；为空时使用本地时区
+}
+
+// NewRecurrence 创建任务周期
+func NewRecurrence(recurrenceType RecurrenceType, timezone string) Recurrence {
+	return Recurrence{Type: recurrenceType, Timezone: timezone}
+}
+
+// IsRecurring 判断是否为周期任务
+func (r Recurrence) IsRecurring() bool {
+	return r.Type != "" && r.Type != RecurrenceNone
+}
+
+// IsValid 验证周期配置是否有效
+func (r Recurrence) IsValid() bool {
+	switch r.Type {
+	case "", RecurrenceNone, RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly:
+	default:
+		return false
+	}
+	_, err := r.Location()
+	return err == nil
+}
+
+// Location 获取周期计算使用的时区
+func (r Recurrence) Location() (*time.Location, error) {
+	if r.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(r.Timezone)
+}
+
+// PeriodKey 计算时间点所属的周期标识
+// daily: 2006-01-02，weekly: 2006-W01，monthly: 2006-01，不重复任务返回空字符串
+func (r Recurrence) PeriodKey(t time.Time) string {
+	if !r.IsRecurring() {
+		return ""
+	}
+
+	loc, err := r.Location()
+	if err != nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+
+	switch r.Type {
+	case RecurrenceDaily:
+		return t.Format("2006-01-02")
+	case RecurrenceWeekly:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case RecurrenceMonthly:
+		return t.Format("2006-01")
+	default:
+		return ""
+	}
+}
+
+// String 返回周期的字符串表示
+func (r Recurrence) String() string {
+	if !r.IsRecurring() {
+		return string(RecurrenceNone)
+	}
+	if r.Timezone == "" {
+		return string(r.Type)
+	}
+	return fmt.Sprintf("%s(%s)", r.Type, r.Timezone)
+}
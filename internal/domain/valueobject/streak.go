@@ -0,0 +1,39 @@
+package valueobject
+
+import (
+	"time"
+)
+
+// StreakPolicy 连续打卡规则值对象
+type StreakPolicy struct {
+	GraceCards int    // 可用补签卡数量，每张补签卡可补一天断签
+	Timezone   string // 按该时区划分自然日；为空时使用本地时区
+}
+
+// NewStreakPolicy 创建连续打卡规则
+func NewStreakPolicy(graceCards int, timezone string) StreakPolicy {
+	return StreakPolicy{GraceCards: graceCards, Timezone: timezone}
+}
+
+// IsValid 验证规则是否有效
+func (p StreakPolicy) IsValid() bool {
+	return p.GraceCards >= 0 && NewRecurrence(RecurrenceDaily, p.Timezone).IsValid()
+}
+
+// DayKey 计算时间点所属的自然日（2006-01-02）
+func (p StreakPolicy) DayKey(t time.Time) string {
+	return NewRecurrence(RecurrenceDaily, p.Timezone).PeriodKey(t)
+}
+
+// DaysBetween 计算两个自然日（2006-01-02）之间相差的天数
+func DaysBetween(from, to string) (int, error) {
+	fromDay, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return 0, err
+	}
+	toDay, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return 0, err
+	}
+	return int(toDay.Sub(fromDay).Hours() / 24), nil
+}
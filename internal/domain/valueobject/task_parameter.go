@@ -0,0 +1,157 @@
+package valueobject
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+)
+
+// TaskParameterType 任务参数类型
+type TaskParameterType string
+
+const (
+	TaskParameterNumber TaskParameterType = "number"  // 数值，如点赞数门槛
+	TaskParameterString TaskParameterType = "string"  // 字符串，如分享渠道
+	TaskParameterBool   TaskParameterType = "bool"    // 布尔值
+	TaskParameterIDList TaskParameterType = "id_list" // ID 列表，如要求的话题ID
+)
+
+// parameterNamePattern 参数名需可在表达式中直接引用
+var parameterNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// TaskParameter 任务参数值对象
+// 由任务定义配置，求值时与事件参数合并，使同类型的任务可以使用不同的条件取值
+type TaskParameter struct {
+	Name  string
+	Type  TaskParameterType
+	Value interface{} // number 为 float64，id_list 为 []uint64
+}
+
+// NewTaskParameter 创建任务参数，将 JSON 解码得到的取值转换为参数类型
+func NewTaskParameter(name string, paramType TaskParameterType, value interface{}) (TaskParameter, error) {
+	if !parameterNamePattern.MatchString(name) {
+		return TaskParameter{}, fmt.Errorf("invalid task parameter name %q", name)
+	}
+
+	normalized, ok := normalizeParameterValue(paramType, value)
+	if !ok {
+		return TaskParameter{}, fmt.Errorf("task parameter %s must be %s, got %T", name, paramType, value)
+	}
+	return TaskParameter{Name: name, Type: paramType, Value: normalized}, nil
+}
+
+// IsValid 验证任务参数是否有效（取值需已转换为参数类型）
+func (p TaskParameter) IsValid() bool {
+	if !parameterNamePattern.MatchString(p.Name) {
+		return false
+	}
+
+	var ok bool
+	switch p.Type {
+	case TaskParameterNumber:
+		_, ok = p.Value.(float64)
+	case TaskParameterString:
+		_, ok = p.Value.(string)
+	case TaskParameterBool:
+		_, ok = p.Value.(bool)
+	case TaskParameterIDList:
+		_, ok = p.Value.([]uint64)
+	}
+	return ok
+}
+
+// normalizeParameterValue 按参数类型转换取值
+func normalizeParameterValue(paramType TaskParameterType, value interface{}) (interface{}, bool) {
+	switch paramType {
+	case TaskParameterNumber:
+		return toFloat64(value)
+	case TaskParameterString:
+		v, ok := value.(string)
+		return v, ok
+	case TaskParameterBool:
+		v, ok := value.(bool)
+		return v, ok
+	case TaskParameterIDList:
+		switch v := value.(type) {
+		case []uint64:
+			return v, true
+		case []int64:
+			ids := make([]uint64, 0, len(v))
+			for _, id := range v {
+				if id < 0 {
+					return nil, false
+				}
+				ids = append(ids, uint64(id))
+			}
+			return ids, true
+		case []interface{}:
+			ids := make([]uint64, 0, len(v))
+			for _, item := range v {
+				f, ok := toFloat64(item)
+				if !ok || f < 0 || f != math.Trunc(f) {
+					return nil, false
+				}
+				ids = append(ids, uint64(f))
+			}
+			return ids, true
+		}
+	}
+	return nil, false
+}
+
+// toFloat64 转换数值类型
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// TaskParameters 任务参数列表
+type TaskParameters []TaskParameter
+
+// Validate 验证参数列表，参数需有效且不能重名
+func (ps TaskParameters) Validate() error {
+	names := make(map[string]bool, len(ps))
+	for _, p := range ps {
+		if !p.IsValid() {
+			return fmt.Errorf("invalid task parameter %q", p.Name)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate task parameter %q", p.Name)
+		}
+		names[p.Name] = true
+	}
+	return nil
+}
+
+// Names 获取参数名列表
+func (ps TaskParameters) Names() []string {
+	names := make([]string, 0, len(ps))
+	for _, p := range ps {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// Merge 将任务参数合并到事件的表达式参数中，返回新的参数集，不修改 args
+func (ps TaskParameters) Merge(args ExpressionArguments) ExpressionArguments {
+	if len(ps) == 0 {
+		return args
+	}
+
+	merged := make(ExpressionArguments, len(args)+len(ps))
+	for name, value := range args {
+		merged[name] = value
+	}
+	for _, p := range ps {
+		merged[p.Name] = p.Value
+	}
+	return merged
+}
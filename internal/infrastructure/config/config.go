@@ -6,9 +6,12 @@ import (
 
 // Config 应用配置
 type Config struct {
-	App      AppConfig
-	Task     TaskConfig
-	Database DatabaseConfig
+	App        AppConfig
+	Task       TaskConfig
+	Activity   ActivityConfig
+	RuleEngine RuleEngineConfig
+	Lock       LockConfig
+	Database   DatabaseConfig
 }
 
 // AppConfig 应用配置
@@ -24,6 +27,35 @@ type TaskConfig struct {
 	TaskExpireDays   int           // 任务过期天数
 	MaxRetry         int           // 最大重试次数
 	DefaultReward    int           // 默认奖励值
+	LockPolicy       string        // 锁竞争策略：wait、retry 或 queue
+	LockWaitTimeout  time.Duration // wait、queue 策略等待锁的最长时间
+	LockRetryBackoff time.Duration // retry 策略首次重试间隔，之后每次翻倍
+	LockQueueSize    int           // queue 策略单个用户排队中的最大事件数
+}
+
+// ActivityConfig 活动配置
+type ActivityConfig struct {
+	SchedulerInterval time.Duration // 活动状态调度间隔
+}
+
+// RuleEngineConfig 规则引擎配置
+type RuleEngineConfig struct {
+	Engine              string        // 表达式引擎：govaluate 或 sandbox
+	ExpressionCacheSize int           // 已编译表达式缓存容量，0 表示不缓存
+	TraceSampleRate     float64       // 求值轨迹采样率（0~1），0 表示不记录
+	MaxSteps            int           // 单次求值最大步数（仅 sandbox 引擎）
+	EvalTimeout         time.Duration // 单次求值最大耗时（仅 sandbox 引擎）
+}
+
+// LockConfig 分布式锁配置
+type LockConfig struct {
+	Backend       string        // 锁后端：memory 或 redis
+	RedisAddr     string        // Redis 地址（host:port）
+	RedisPassword string
+	RedisDB       int
+	DialTimeout   time.Duration // 建立连接超时
+	IOTimeout     time.Duration // 单次命令超时
+	PollInterval  time.Duration // 阻塞加锁时的重试间隔
 }
 
 // DatabaseConfig 数据库配置
@@ -49,6 +81,26 @@ func NewDefaultConfig() *Config {
 			TaskExpireDays: 30,
 			MaxRetry:       3,
 			DefaultReward:  1,
+			LockPolicy:       "wait",
+			LockWaitTimeout:  5 * time.Second,
+			LockRetryBackoff: 20 * time.Millisecond,
+			LockQueueSize:    100,
+		},
+		Activity: ActivityConfig{
+			SchedulerInterval: time.Minute,
+		},
+		RuleEngine: RuleEngineConfig{
+			Engine:              "govaluate",
+			ExpressionCacheSize: 1024,
+			TraceSampleRate:     0.01,
+			MaxSteps:            10000,
+			EvalTimeout:         50 * time.Millisecond,
+		},
+		Lock: LockConfig{
+			Backend:      "memory",
+			DialTimeout:  time.Second,
+			IOTimeout:    time.Second,
+			PollInterval: 50 * time.Millisecond,
 		},
 		Database: DatabaseConfig{
 			Type: "memory",
@@ -2,7 +2,10 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"mini-sirus/internal/usecase/port/output"
+	"time"
 )
 
 // DistributedLockAdapter 分布式锁适配器
@@ -20,18 +23,43 @@ func NewDistributedLockAdapter(memoryLock *MemoryLock) *DistributedLockAdapter {
 // 确保实现了接口
 var _ output.DistributedLock = (*DistributedLockAdapter)(nil)
 
-// Lock 加锁
-func (a *DistributedLockAdapter) Lock(ctx context.Context, key string, ttl int) (string, error) {
-	return a.memoryLock.Lock(key, ttl)
+// Lock 加锁（阻塞）
+func (a *DistributedLockAdapter) Lock(ctx context.Context, key string, ttl time.Duration) (*output.Lease, error) {
+	return a.memoryLock.Lock(ctx, key, ttl)
 }
 
-// Unlock 解锁
-func (a *DistributedLockAdapter) Unlock(ctx context.Context, key string, lockID string) error {
-	return a.memoryLock.Unlock(key, lockID)
+// TryLock 尝试加锁（非阻塞）
+func (a *DistributedLockAdapter) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, *output.Lease, error) {
+	return a.memoryLock.TryLock(ctx, key, ttl)
 }
 
-// TryLock 尝试加锁（非阻塞）
-func (a *DistributedLockAdapter) TryLock(ctx context.Context, key string, ttl int) (bool, string, error) {
-	return a.memoryLock.TryLock(key, ttl)
+// Renew 续期
+func (a *DistributedLockAdapter) Renew(ctx context.Context, lease *output.Lease, ttl time.Duration) error {
+	return a.memoryLock.Renew(ctx, lease, ttl)
+}
+
+// Unlock 解锁
+func (a *DistributedLockAdapter) Unlock(ctx context.Context, lease *output.Lease) error {
+	return a.memoryLock.Unlock(ctx, lease)
 }
 
+// 分布式锁后端
+const (
+	BackendMemory = "memory" // 进程内锁，仅适用于单实例部署
+	BackendRedis  = "redis"  // Redis 锁，多实例共享
+)
+
+// NewDistributedLock 按后端名称创建分布式锁，backend 为空时使用内存锁
+func NewDistributedLock(backend string, redisOpts RedisLockOptions) (output.DistributedLock, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewDistributedLockAdapter(NewMemoryLock()), nil
+	case BackendRedis:
+		if redisOpts.Addr == "" {
+			return nil, errors.New("redis lock requires an address")
+		}
+		return NewRedisLock(redisOpts), nil
+	default:
+		return nil, fmt.Errorf("unknown lock backend: %s", backend)
+	}
+}
@@ -0,0 +1,123 @@
+package infrastructure
+
+import (
+	"context"
+	"mini-sirus/internal/infrastructure/resp/resptest"
+	"mini-sirus/internal/usecase/port/output"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDistributedLock 校验分布式锁实现的通用语义：互斥、阻塞等待、过期、续期和隔离令牌
+func testDistributedLock(t *testing.T, lock output.DistributedLock) {
+	t.Helper()
+	ctx := context.Background()
+
+	// 非阻塞加锁：锁被占用时失败，锁标识随机
+	ok, first, err := lock.TryLock(ctx, "lock:a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, _, err = lock.TryLock(ctx, "lock:a", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, other, err := lock.TryLock(ctx, "lock:b", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Len(t, first.ID, 32)
+	assert.NotEqual(t, first.ID, other.ID)
+	assert.ErrorIs(t, lock.Unlock(ctx, &output.Lease{Key: "lock:a", ID: other.ID}), output.ErrLockNotHeld, "只有持有者可以解锁")
+
+	// 阻塞加锁：持有者解锁后获得锁
+	acquired := make(chan *output.Lease)
+	go func() {
+		lease, err := lock.Lock(ctx, "lock:a", time.Minute)
+		assert.NoError(t, err)
+		acquired <- lease
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("锁被占用时不应加锁成功")
+	case <-time.After(20 * time.Millisecond):
+	}
+	require.NoError(t, lock.Unlock(ctx, first))
+	second := <-acquired
+	assert.Greater(t, second.Token, first.Token, "同一个 key 的令牌应单调递增")
+
+	// 等待超时或取消时返回 ctx 错误
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = lock.Lock(waitCtx, "lock:a", time.Minute)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 过期：持有者未解锁时锁在 TTL 后可被重新获取，原持有者不能再续期或解锁
+	ok, expiring, err := lock.TryLock(ctx, "lock:c", 30*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, ok)
+	takeover, err := lock.Lock(ctx, "lock:c", time.Minute)
+	require.NoError(t, err)
+	assert.Greater(t, takeover.Token, expiring.Token)
+	assert.ErrorIs(t, lock.Renew(ctx, expiring, time.Minute), output.ErrLockNotHeld)
+	assert.ErrorIs(t, lock.Unlock(ctx, expiring), output.ErrLockNotHeld)
+
+	// 续期：续期后原过期时间到达时锁仍被持有
+	ok, renewed, err := lock.TryLock(ctx, "lock:d", 40*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, lock.Renew(ctx, renewed, time.Minute))
+	time.Sleep(60 * time.Millisecond)
+	ok, _, err = lock.TryLock(ctx, "lock:d", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "续期后锁不应过期")
+	require.NoError(t, lock.Unlock(ctx, renewed))
+}
+
+func TestMemoryLock(t *testing.T) {
+	testDistributedLock(t, NewMemoryLock())
+}
+
+func TestRedisLock(t *testing.T) {
+	ctx := context.Background()
+	server, err := resptest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	opts := RedisLockOptions{Addr: server.Addr(), PollInterval: 5 * time.Millisecond}
+	lock := NewRedisLock(opts)
+	defer lock.Close()
+	testDistributedLock(t, lock)
+
+	// 多个实例共享同一把锁，只有持有者标识匹配才能解锁
+	replica := NewRedisLock(opts)
+	defer replica.Close()
+	ok, lease, err := lock.TryLock(ctx, "lock:shared", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, _, err = replica.TryLock(ctx, "lock:shared", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "其他实例不应获得已被持有的锁")
+	assert.ErrorIs(t, replica.Unlock(ctx, &output.Lease{Key: "lock:shared", ID: "other"}), output.ErrLockNotHeld)
+	require.NoError(t, replica.Unlock(ctx, lease))
+	ok, lease, err = replica.TryLock(ctx, "lock:shared", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, replica.Unlock(ctx, lease))
+
+	// Redis 不可用时加锁失败
+	require.NoError(t, server.Close())
+	_, _, err = lock.TryLock(ctx, "lock:shared", time.Minute)
+	assert.Error(t, err)
+}
+
+func TestNewDistributedLock(t *testing.T) {
+	lock, err := NewDistributedLock(BackendMemory, RedisLockOptions{})
+	require.NoError(t, err)
+	assert.IsType(t, &DistributedLockAdapter{}, lock)
+
+	_, err = NewDistributedLock(BackendRedis, RedisLockOptions{})
+	assert.Error(t, err, "Redis 锁必须配置地址")
+	_, err = NewDistributedLock("unknown", RedisLockOptions{})
+	assert.Error(t, err)
+}
@@ -1,68 +1,160 @@
 package infrastructure
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"mini-sirus/internal/usecase/port/output"
 	"sync"
+	"time"
 )
 
 // MemoryLock 内存分布式锁（模拟实现，仅用于单机测试）
+// 锁按 ttl 过期，过期的锁在下次访问该 key 时回收
 type MemoryLock struct {
 	mu    sync.Mutex
-	locks map[string]string // key -> lockID
+	locks map[string]*memoryLockEntry // key -> 当前持有者
+	token uint64                      // 最近发放的隔离令牌
+}
+
+// memoryLockEntry 锁的持有状态
+type memoryLockEntry struct {
+	lease    output.Lease
+	released chan struct{} // 锁释放或被回收时关闭，唤醒等待者
 }
 
 // NewMemoryLock 创建内存锁
 func NewMemoryLock() *MemoryLock {
 	return &MemoryLock{
-		locks: make(map[string]string),
+		locks: make(map[string]*memoryLockEntry),
+	}
+}
+
+// Lock 加锁，锁被占用时等待其释放或过期，直到加锁成功或 ctx 取消
+func (l *MemoryLock) Lock(ctx context.Context, key string, ttl time.Duration) (*output.Lease, error) {
+	for {
+		lease, holder, err := l.acquire(key, ttl)
+		if err != nil || lease != nil {
+			return lease, err
+		}
+
+		// 等待持有者释放锁，或到达持有者的过期时间后重试（期间可能已续期）
+		timer := time.NewTimer(time.Until(holder.expiresAt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("wait for lock %s: %w", key, ctx.Err())
+		case <-holder.released:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// TryLock 尝试加锁（非阻塞）
+func (l *MemoryLock) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, *output.Lease, error) {
+	lease, _, err := l.acquire(key, ttl)
+	if err != nil || lease == nil {
+		return false, nil, err
 	}
+	return true, lease, nil
 }
 
-// Lock 加锁
-func (l *MemoryLock) Lock(key string, ttl int) (string, error) {
+// Renew 续期
+func (l *MemoryLock) Renew(ctx context.Context, lease *output.Lease, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("lock ttl must be positive")
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if _, exists := l.locks[key]; exists {
-		return "", fmt.Errorf("lock already exists for key: %s", key)
+	entry, err := l.heldLocked(lease)
+	if err != nil {
+		return err
 	}
 
-	lockID := fmt.Sprintf("lock_%s_%d", key, len(l.locks)+1)
-	l.locks[key] = lockID
-
-	return lockID, nil
+	entry.lease.ExpiresAt = time.Now().Add(ttl)
+	lease.ExpiresAt = entry.lease.ExpiresAt
+	return nil
 }
 
 // Unlock 解锁
-func (l *MemoryLock) Unlock(key string, lockID string) error {
+func (l *MemoryLock) Unlock(ctx context.Context, lease *output.Lease) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	existingLockID, exists := l.locks[key]
-	if !exists {
-		return fmt.Errorf("lock not found for key: %s", key)
-	}
-
-	if existingLockID != lockID {
-		return fmt.Errorf("lock id mismatch for key: %s", key)
+	entry, err := l.heldLocked(lease)
+	if err != nil {
+		return err
 	}
 
-	delete(l.locks, key)
+	delete(l.locks, lease.Key)
+	close(entry.released)
 	return nil
 }
 
-// TryLock 尝试加锁（非阻塞）
-func (l *MemoryLock) TryLock(key string, ttl int) (bool, string, error) {
+// lockHolder 锁被占用时当前持有者的快照
+type lockHolder struct {
+	expiresAt time.Time
+	released  <-chan struct{}
+}
+
+// acquire 尝试获取锁，锁被占用时返回当前持有者
+func (l *MemoryLock) acquire(key string, ttl time.Duration) (*output.Lease, *lockHolder, error) {
+	if ttl <= 0 {
+		return nil, nil, errors.New("lock ttl must be positive")
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if _, exists := l.locks[key]; exists {
-		return false, "", nil
+	now := time.Now()
+	if holder, exists := l.locks[key]; exists {
+		if now.Before(holder.lease.ExpiresAt) {
+			return nil, &lockHolder{expiresAt: holder.lease.ExpiresAt, released: holder.released}, nil
+		}
+		// 持有者未按时释放（如进程崩溃），回收过期的锁
+		close(holder.released)
+	}
+
+	id, err := newLockID()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	lockID := fmt.Sprintf("lock_%s_%d", key, len(l.locks)+1)
-	l.locks[key] = lockID
+	l.token++
+	entry := &memoryLockEntry{
+		lease: output.Lease{
+			Key:       key,
+			ID:        id,
+			Token:     l.token,
+			ExpiresAt: now.Add(ttl),
+		},
+		released: make(chan struct{}),
+	}
+	l.locks[key] = entry
 
-	return true, lockID, nil
+	lease := entry.lease
+	return &lease, nil, nil
 }
 
+// heldLocked 校验租约仍持有锁，调用方需持有 l.mu
+func (l *MemoryLock) heldLocked(lease *output.Lease) (*memoryLockEntry, error) {
+	entry, exists := l.locks[lease.Key]
+	if !exists || entry.lease.ID != lease.ID || !time.Now().Before(entry.lease.ExpiresAt) {
+		return nil, fmt.Errorf("%w: %s", output.ErrLockNotHeld, lease.Key)
+	}
+	return entry, nil
+}
+
+// newLockID 生成随机的锁持有者标识
+func newLockID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate lock id failed: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
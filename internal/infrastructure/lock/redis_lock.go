@@ -0,0 +1,260 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/infrastructure/resp"
+	"mini-sirus/internal/usecase/port/output"
+	"strconv"
+	"time"
+)
+
+// unlockScript 持有者标识匹配时删除锁
+const unlockScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+
+// renewScript 持有者标识匹配时重置锁的过期时间
+const renewScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`
+
+// RedisLockOptions Redis 锁配置
+type RedisLockOptions struct {
+	Addr         string
+	Password     string
+	DB           int
+	DialTimeout  time.Duration // 建立连接超时
+	IOTimeout    time.Duration // 单次命令超时
+	PoolSize     int           // 空闲连接池容量
+	PollInterval time.Duration // 阻塞加锁时的重试间隔
+}
+
+// withDefaults 补全未配置的选项
+func (o RedisLockOptions) withDefaults() RedisLockOptions {
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = time.Second
+	}
+	if o.IOTimeout <= 0 {
+		o.IOTimeout = time.Second
+	}
+	if o.PoolSize <= 0 {
+		o.PoolSize = 8
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 50 * time.Millisecond
+	}
+	return o
+}
+
+// RedisLock 基于 Redis 的分布式锁（RESP 协议）
+// 加锁：MULTI { SET key id NX PX ttl; INCR key:token } EXEC，SET 与令牌递增原子执行，
+// 令牌在加锁失败时也会递增，但仍单调递增；解锁与续期通过 Lua 脚本比较持有者标识后执行
+type RedisLock struct {
+	opts RedisLockOptions
+	idle chan *resp.Conn // 空闲连接
+}
+
+// NewRedisLock 创建 Redis 锁，连接在首次使用时建立
+func NewRedisLock(opts RedisLockOptions) *RedisLock {
+	opts = opts.withDefaults()
+	return &RedisLock{
+		opts: opts,
+		idle: make(chan *resp.Conn, opts.PoolSize),
+	}
+}
+
+// 确保实现了接口
+var _ output.DistributedLock = (*RedisLock)(nil)
+
+// Lock 加锁，锁被占用时按 PollInterval 重试，直到加锁成功或 ctx 取消
+func (l *RedisLock) Lock(ctx context.Context, key string, ttl time.Duration) (*output.Lease, error) {
+	for {
+		ok, lease, err := l.TryLock(ctx, key, ttl)
+		if err != nil || ok {
+			return lease, err
+		}
+
+		timer := time.NewTimer(l.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("wait for lock %s: %w", key, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// TryLock 尝试加锁（非阻塞）
+func (l *RedisLock) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, *output.Lease, error) {
+	ms, err := ttlMillis(ttl)
+	if err != nil {
+		return false, nil, err
+	}
+	id, err := newLockID()
+	if err != nil {
+		return false, nil, err
+	}
+
+	replies, err := l.pipeline(ctx,
+		[]string{"MULTI"},
+		[]string{"SET", key, id, "NX", "PX", ms},
+		[]string{"INCR", tokenKey(key)},
+		[]string{"EXEC"},
+	)
+	if err != nil {
+		return false, nil, fmt.Errorf("acquire lock %s: %w", key, err)
+	}
+
+	results, ok := replies[3].([]interface{})
+	if !ok || len(results) != 2 {
+		return false, nil, fmt.Errorf("acquire lock %s: unexpected reply %v", key, replies)
+	}
+	if respErr, ok := results[0].(resp.Error); ok {
+		return false, nil, fmt.Errorf("acquire lock %s: %w", key, respErr)
+	}
+	if results[0] == nil {
+		return false, nil, nil
+	}
+	token, ok := results[1].(int64)
+	if !ok {
+		return false, nil, fmt.Errorf("acquire lock %s: unexpected token reply %v", key, results[1])
+	}
+
+	return true, &output.Lease{
+		Key:       key,
+		ID:        id,
+		Token:     uint64(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// Renew 续期
+func (l *RedisLock) Renew(ctx context.Context, lease *output.Lease, ttl time.Duration) error {
+	ms, err := ttlMillis(ttl)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if err := l.evalGuarded(ctx, renewScript, lease, ms); err != nil {
+		return err
+	}
+	lease.ExpiresAt = start.Add(ttl)
+	return nil
+}
+
+// Unlock 解锁
+func (l *RedisLock) Unlock(ctx context.Context, lease *output.Lease) error {
+	return l.evalGuarded(ctx, unlockScript, lease)
+}
+
+// evalGuarded 执行比较持有者标识的脚本，脚本返回 0 表示锁已不属于该租约
+func (l *RedisLock) evalGuarded(ctx context.Context, script string, lease *output.Lease, args ...string) error {
+	cmd := append([]string{"EVAL", script, "1", lease.Key, lease.ID}, args...)
+	replies, err := l.pipeline(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	switch reply := replies[0].(type) {
+	case resp.Error:
+		return reply
+	case int64:
+		if reply == 0 {
+			return fmt.Errorf("%w: %s", output.ErrLockNotHeld, lease.Key)
+		}
+		return nil
+	}
+	return fmt.Errorf("unexpected reply %v", replies[0])
+}
+
+// pipeline 从连接池取连接执行命令，连接出错时丢弃连接
+func (l *RedisLock) pipeline(ctx context.Context, cmds ...[]string) ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.opts.IOTimeout)
+	defer cancel()
+
+	conn, err := l.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := conn.Pipeline(ctx, cmds...)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	select {
+	case l.idle <- conn:
+	default:
+		conn.Close()
+	}
+	return replies, nil
+}
+
+// conn 获取空闲连接，没有时新建连接并完成认证和选库
+func (l *RedisLock) conn(ctx context.Context) (*resp.Conn, error) {
+	select {
+	case conn := <-l.idle:
+		return conn, nil
+	default:
+	}
+
+	conn, err := resp.Dial(ctx, l.opts.Addr, l.opts.DialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("connect redis %s: %w", l.opts.Addr, err)
+	}
+
+	var setup [][]string
+	if l.opts.Password != "" {
+		setup = append(setup, []string{"AUTH", l.opts.Password})
+	}
+	if l.opts.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(l.opts.DB)})
+	}
+	if len(setup) > 0 {
+		replies, err := conn.Pipeline(ctx, setup...)
+		if err == nil {
+			for _, reply := range replies {
+				if respErr, ok := reply.(resp.Error); ok {
+					err = respErr
+					break
+				}
+			}
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("setup redis connection: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// Close 关闭空闲连接
+func (l *RedisLock) Close() error {
+	for {
+		select {
+		case conn := <-l.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// tokenKey 锁对应的隔离令牌计数器
+func tokenKey(key string) string {
+	return key + ":token"
+}
+
+// ttlMillis 将 ttl 转换为毫秒参数，不足 1 毫秒的 ttl 无效
+func ttlMillis(ttl time.Duration) (string, error) {
+	if ttl < time.Millisecond {
+		return "", errors.New("lock ttl must be at least 1ms")
+	}
+	return strconv.FormatInt(ttl.Milliseconds(), 10), nil
+}
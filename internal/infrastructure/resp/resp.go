@@ -0,0 +1,215 @@
+// Package resp 实现 Redis 序列化协议（RESP2）的编解码与客户端连接
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Status 简单字符串回复（如 +OK、+QUEUED）
+type Status string
+
+// Error 错误回复（如 -ERR unknown command）
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// ReadValue 读取一个 RESP 值
+// 简单字符串返回 Status，错误返回 Error，整数返回 int64，
+// 批量字符串返回 string，数组返回 []interface{}，空批量字符串和空数组返回 nil
+func ReadValue(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("resp: empty line")
+	}
+
+	switch line[0] {
+	case '+':
+		return Status(line[1:]), nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("resp: invalid integer %q", line)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("resp: invalid bulk length %q", line)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if buf[n] != '\r' || buf[n+1] != '\n' {
+			return nil, errors.New("resp: bulk string not terminated by CRLF")
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("resp: invalid array length %q", line)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = ReadValue(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("resp: unknown type %q", line[0])
+}
+
+// readLine 读取以 CRLF 结尾的一行（不含 CRLF）
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("resp: line not terminated by CRLF")
+	}
+	return line[:len(line)-2], nil
+}
+
+// WriteCommand 以批量字符串数组写入命令
+func WriteCommand(w *bufio.Writer, args ...string) error {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		values[i] = arg
+	}
+	return WriteValue(w, values)
+}
+
+// WriteValue 写入一个 RESP 值，类型与 ReadValue 的返回值对应
+func WriteValue(w *bufio.Writer, value interface{}) error {
+	var err error
+	switch v := value.(type) {
+	case nil:
+		_, err = w.WriteString("$-1\r\n")
+	case Status:
+		_, err = fmt.Fprintf(w, "+%s\r\n", v)
+	case Error:
+		_, err = fmt.Fprintf(w, "-%s\r\n", v)
+	case int64:
+		_, err = fmt.Fprintf(w, ":%d\r\n", v)
+	case int:
+		_, err = fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		_, err = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case []interface{}:
+		if _, err = fmt.Fprintf(w, "*%d\r\n", len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err = WriteValue(w, item); err != nil {
+				return err
+			}
+		}
+	default:
+		err = fmt.Errorf("resp: unsupported value type %T", value)
+	}
+	return err
+}
+
+// Conn RESP 客户端连接，不可并发使用
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// Dial 建立连接
+func Dial(ctx context.Context, addr string, timeout time.Duration) (*Conn, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}, nil
+}
+
+// Do 执行一条命令，错误回复以 Error 返回
+func (c *Conn) Do(ctx context.Context, args ...string) (interface{}, error) {
+	replies, err := c.Pipeline(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if respErr, ok := replies[0].(Error); ok {
+		return nil, respErr
+	}
+	return replies[0], nil
+}
+
+// Pipeline 一次写入多条命令并依次读取回复
+// 错误回复作为 Error 值放在对应位置；返回的 error 仅表示连接错误，此时连接不可再使用。
+// 读写期间 ctx 到期时返回 ctx.Err()
+func (c *Conn) Pipeline(ctx context.Context, cmds ...[]string) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	replies, err := c.pipeline(ctx, cmds)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		// 连接读写超时可能早于 ctx 的到期通知
+		if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+			return nil, context.DeadlineExceeded
+		}
+	}
+	return replies, err
+}
+
+func (c *Conn) pipeline(ctx context.Context, cmds [][]string) ([]interface{}, error) {
+	// ctx 未设置截止时间时不限制读写耗时
+	deadline, _ := ctx.Deadline()
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	for _, cmd := range cmds {
+		if err := WriteCommand(c.w, cmd...); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(cmds))
+	for i := range replies {
+		reply, err := ReadValue(c.r)
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// Close 关闭连接
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,371 @@
+// Package resptest 提供进程内的 RESP 替身服务器，用于在没有 Redis 的环境中测试 RESP 客户端
+package resptest
+
+import (
+	"bufio"
+	"fmt"
+	"mini-sirus/internal/infrastructure/resp"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server 进程内 RESP 替身服务器
+// 支持 PING、AUTH、SELECT、GET、SET（NX/XX/PX/EX）、DEL、INCR、PEXPIRE、PTTL、
+// MULTI/EXEC/DISCARD，以及 "GET 比较后执行" 形式的 EVAL 脚本：
+//
+//	if redis.call("GET", KEYS[1]) == ARGV[1] then
+//		return redis.call("DEL", KEYS[1])
+//	end
+//	return 0
+//
+// 与 Redis 一样串行执行命令，EVAL 与 EXEC 是原子的
+type Server struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	data   map[string]*item
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// item 键值及过期时间
+type item struct {
+	value     string
+	expiresAt time.Time // 零值表示不过期
+}
+
+// NewServer 在本地随机端口启动服务器
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		data:     make(map[string]*item),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr 服务器地址
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close 关闭服务器及所有连接
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// handle 处理单个连接的命令，MULTI 之后的命令排队到 EXEC 时一起执行
+func (s *Server) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	var queued [][]string
+	inMulti := false
+
+	for {
+		value, err := resp.ReadValue(r)
+		if err != nil {
+			return
+		}
+		args, ok := commandArgs(value)
+		if !ok {
+			return
+		}
+
+		var reply interface{}
+		switch name := strings.ToUpper(args[0]); {
+		case name == "MULTI":
+			inMulti, queued = true, nil
+			reply = resp.Status("OK")
+		case name == "DISCARD":
+			inMulti, queued = false, nil
+			reply = resp.Status("OK")
+		case name == "EXEC":
+			if !inMulti {
+				reply = resp.Error("ERR EXEC without MULTI")
+				break
+			}
+			s.mu.Lock()
+			replies := make([]interface{}, len(queued))
+			for i, cmd := range queued {
+				replies[i] = s.exec(cmd)
+			}
+			s.mu.Unlock()
+			inMulti, queued = false, nil
+			reply = replies
+		case inMulti:
+			queued = append(queued, args)
+			reply = resp.Status("QUEUED")
+		default:
+			s.mu.Lock()
+			reply = s.exec(args)
+			s.mu.Unlock()
+		}
+
+		if err := resp.WriteValue(w, reply); err != nil {
+			return
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// commandArgs 将命令数组转换为字符串参数
+func commandArgs(value interface{}) ([]string, bool) {
+	values, ok := value.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, false
+	}
+	args := make([]string, len(values))
+	for i, v := range values {
+		arg, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		args[i] = arg
+	}
+	return args, true
+}
+
+// exec 执行单条命令，调用方需持有 s.mu
+func (s *Server) exec(args []string) interface{} {
+	name, args := strings.ToUpper(args[0]), args[1:]
+	switch name {
+	case "PING":
+		return resp.Status("PONG")
+	case "AUTH", "SELECT":
+		return resp.Status("OK")
+	case "GET":
+		if len(args) != 1 {
+			return wrongArgs(name)
+		}
+		if it := s.get(args[0]); it != nil {
+			return it.value
+		}
+		return nil
+	case "SET":
+		return s.set(args)
+	case "DEL":
+		deleted := int64(0)
+		for _, key := range args {
+			if s.get(key) != nil {
+				delete(s.data, key)
+				deleted++
+			}
+		}
+		return deleted
+	case "INCR":
+		if len(args) != 1 {
+			return wrongArgs(name)
+		}
+		n := int64(0)
+		it := s.get(args[0])
+		if it != nil {
+			var err error
+			if n, err = strconv.ParseInt(it.value, 10, 64); err != nil {
+				return resp.Error("ERR value is not an integer or out of range")
+			}
+		} else {
+			it = &item{}
+			s.data[args[0]] = it
+		}
+		n++
+		it.value = strconv.FormatInt(n, 10)
+		return n
+	case "PEXPIRE":
+		if len(args) != 2 {
+			return wrongArgs(name)
+		}
+		ms, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return resp.Error("ERR value is not an integer or out of range")
+		}
+		it := s.get(args[0])
+		if it == nil {
+			return int64(0)
+		}
+		it.expiresAt = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return int64(1)
+	case "PTTL":
+		if len(args) != 1 {
+			return wrongArgs(name)
+		}
+		it := s.get(args[0])
+		switch {
+		case it == nil:
+			return int64(-2)
+		case it.expiresAt.IsZero():
+			return int64(-1)
+		}
+		return time.Until(it.expiresAt).Milliseconds()
+	case "EVAL":
+		return s.eval(args)
+	}
+	return resp.Error(fmt.Sprintf("ERR unknown command '%s'", strings.ToLower(name)))
+}
+
+// get 获取未过期的键，已过期的键被删除
+func (s *Server) get(key string) *item {
+	it, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	if !it.expiresAt.IsZero() && !time.Now().Before(it.expiresAt) {
+		delete(s.data, key)
+		return nil
+	}
+	return it
+}
+
+// set 执行 SET key value [NX|XX] [PX ms|EX s]
+func (s *Server) set(args []string) interface{} {
+	if len(args) < 2 {
+		return wrongArgs("SET")
+	}
+
+	key, value := args[0], args[1]
+	var nx, xx bool
+	var ttl time.Duration
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "PX", "EX":
+			if i+1 >= len(args) {
+				return resp.Error("ERR syntax error")
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return resp.Error("ERR invalid expire time in 'set' command")
+			}
+			unit := time.Millisecond
+			if opt == "EX" {
+				unit = time.Second
+			}
+			ttl = time.Duration(n) * unit
+			i++
+		default:
+			return resp.Error("ERR syntax error")
+		}
+	}
+
+	exists := s.get(key) != nil
+	if (nx && exists) || (xx && !exists) {
+		return nil
+	}
+
+	it := &item{value: value}
+	if ttl > 0 {
+		it.expiresAt = time.Now().Add(ttl)
+	}
+	s.data[key] = it
+	return resp.Status("OK")
+}
+
+// guardedScript "GET 比较后执行" 形式的脚本
+var guardedScript = regexp.MustCompile(`(?s)^\s*if\s+redis\.call\(\s*["']GET["']\s*,\s*KEYS\[1\]\s*\)\s*==\s*ARGV\[1\]\s*then\s*` +
+	`return\s+redis\.call\((.*?)\)\s*end\s*return\s+0\s*$`)
+
+// scriptArg 脚本中 redis.call 的参数：字符串字面量、KEYS[n] 或 ARGV[n]
+var scriptArg = regexp.MustCompile(`^\s*(?:["'](\w+)["']|(KEYS|ARGV)\[(\d+)\])\s*$`)
+
+// eval 执行 EVAL script numkeys key... arg...
+func (s *Server) eval(args []string) interface{} {
+	if len(args) < 2 {
+		return wrongArgs("EVAL")
+	}
+	numKeys, err := strconv.Atoi(args[1])
+	if err != nil || numKeys < 0 || 2+numKeys > len(args) {
+		return resp.Error("ERR Number of keys can't be greater than number of args")
+	}
+	keys, argv := args[2:2+numKeys], args[2+numKeys:]
+
+	match := guardedScript.FindStringSubmatch(args[0])
+	if match == nil || len(keys) < 1 || len(argv) < 1 {
+		return resp.Error("ERR unsupported script")
+	}
+
+	var call []string
+	for _, raw := range strings.Split(match[1], ",") {
+		parts := scriptArg.FindStringSubmatch(raw)
+		if parts == nil {
+			return resp.Error("ERR unsupported script")
+		}
+		if parts[1] != "" {
+			call = append(call, parts[1])
+			continue
+		}
+		index, _ := strconv.Atoi(parts[3])
+		source := keys
+		if parts[2] == "ARGV" {
+			source = argv
+		}
+		if index < 1 || index > len(source) {
+			return resp.Error("ERR script argument out of range")
+		}
+		call = append(call, source[index-1])
+	}
+
+	it := s.get(keys[0])
+	if it == nil || it.value != argv[0] {
+		return int64(0)
+	}
+	return s.exec(call)
+}
+
+// wrongArgs 参数个数错误
+func wrongArgs(name string) resp.Error {
+	return resp.Error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+}
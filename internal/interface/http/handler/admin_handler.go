@@ -0,0 +1,563 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/usecase/activity"
+	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/port/input"
+	"mini-sirus/internal/usecase/task"
+	"net/http"
+)
+
+// AdminHandler 运营管理处理器（活动与任务定义）
+type AdminHandler struct {
+	createActivityUC    *activity.CreateActivityUseCase
+	updateActivityUC    *activity.UpdateActivityUseCase
+	activityLifecycleUC *activity.ActivityLifecycleUseCase
+	queryActivityUC     *activity.QueryActivityUseCase
+	manageTaskDefUC     *task.ManageTaskDefinitionUseCase
+	dryRunExpressionUC  *task.DryRunExpressionUseCase
+	queryTraceUC        *task.QueryTraceUseCase
+	listFunctionUC      *task.ListFunctionUseCase
+}
+
+// NewAdminHandler 创建运营管理处理器
+func NewAdminHandler(
+	createActivityUC *activity.CreateActivityUseCase,
+	updateActivityUC *activity.UpdateActivityUseCase,
+	activityLifecycleUC *activity.ActivityLifecycleUseCase,
+	queryActivityUC *activity.QueryActivityUseCase,
+	manageTaskDefUC *task.ManageTaskDefinitionUseCase,
+	dryRunExpressionUC *task.DryRunExpressionUseCase,
+	queryTraceUC *task.QueryTraceUseCase,
+	listFunctionUC *task.ListFunctionUseCase,
+) *AdminHandler {
+	return &AdminHandler{
+		createActivityUC:    createActivityUC,
+		updateActivityUC:    updateActivityUC,
+		activityLifecycleUC: activityLifecycleUC,
+		queryActivityUC:     queryActivityUC,
+		manageTaskDefUC:     manageTaskDefUC,
+		dryRunExpressionUC:  dryRunExpressionUC,
+		queryTraceUC:        queryTraceUC,
+		listFunctionUC:      listFunctionUC,
+	}
+}
+
+// 确保实现了接口
+var _ input.AdminService = (*AdminServiceImpl)(nil)
+
+// AdminServiceImpl 运营管理服务实现
+type AdminServiceImpl struct {
+	createActivityUC    *activity.CreateActivityUseCase
+	updateActivityUC    *activity.UpdateActivityUseCase
+	activityLifecycleUC *activity.ActivityLifecycleUseCase
+	queryActivityUC     *activity.QueryActivityUseCase
+	manageTaskDefUC     *task.ManageTaskDefinitionUseCase
+	dryRunExpressionUC  *task.DryRunExpressionUseCase
+	queryTraceUC        *task.QueryTraceUseCase
+	listFunctionUC      *task.ListFunctionUseCase
+}
+
+// NewAdminServiceImpl 创建运营管理服务实现
+func NewAdminServiceImpl(
+	createActivityUC *activity.CreateActivityUseCase,
+	updateActivityUC *activity.UpdateActivityUseCase,
+	activityLifecycleUC *activity.ActivityLifecycleUseCase,
+	queryActivityUC *activity.QueryActivityUseCase,
+	manageTaskDefUC *task.ManageTaskDefinitionUseCase,
+	dryRunExpressionUC *task.DryRunExpressionUseCase,
+	queryTraceUC *task.QueryTraceUseCase,
+	listFunctionUC *task.ListFunctionUseCase,
+) *AdminServiceImpl {
+	return &AdminServiceImpl{
+		createActivityUC:    createActivityUC,
+		updateActivityUC:    updateActivityUC,
+		activityLifecycleUC: activityLifecycleUC,
+		queryActivityUC:     queryActivityUC,
+		manageTaskDefUC:     manageTaskDefUC,
+		dryRunExpressionUC:  dryRunExpressionUC,
+		queryTraceUC:        queryTraceUC,
+		listFunctionUC:      listFunctionUC,
+	}
+}
+
+// CreateActivity 创建活动
+func (s *AdminServiceImpl) CreateActivity(ctx context.Context, input dto.CreateActivityInput) (*dto.ActivityOutput, error) {
+	return s.createActivityUC.Execute(ctx, input)
+}
+
+// UpdateActivity 更新活动
+func (s *AdminServiceImpl) UpdateActivity(ctx context.Context, input dto.UpdateActivityInput) (*dto.ActivityOutput, error) {
+	return s.updateActivityUC.Execute(ctx, input)
+}
+
+// GetActivity 查询单个活动
+func (s *AdminServiceImpl) GetActivity(ctx context.Context, activityID int64) (*dto.ActivityOutput, error) {
+	return s.queryActivityUC.Execute(ctx, activityID)
+}
+
+// ListActivities 查询活动列表
+func (s *AdminServiceImpl) ListActivities(ctx context.Context, includeArchived bool) ([]*dto.ActivityOutput, error) {
+	return s.queryActivityUC.ExecuteList(ctx, includeArchived)
+}
+
+// PublishActivity 发布活动
+func (s *AdminServiceImpl) PublishActivity(ctx context.Context, activityID int64) (*dto.ActivityOutput, error) {
+	return s.activityLifecycleUC.ExecutePublish(ctx, activityID)
+}
+
+// PauseActivity 暂停活动
+func (s *AdminServiceImpl) PauseActivity(ctx context.Context, activityID int64) (*dto.ActivityOutput, error) {
+	return s.activityLifecycleUC.ExecutePause(ctx, activityID)
+}
+
+// EndActivity 提前结束活动
+func (s *AdminServiceImpl) EndActivity(ctx context.Context, activityID int64) (*dto.ActivityOutput, error) {
+	return s.activityLifecycleUC.ExecuteEnd(ctx, activityID)
+}
+
+// ArchiveActivity 归档活动
+func (s *AdminServiceImpl) ArchiveActivity(ctx context.Context, activityID int64) (*dto.ActivityOutput, error) {
+	return s.activityLifecycleUC.ExecuteArchive(ctx, activityID)
+}
+
+// ListParticipants 分页查询活动参与用户
+func (s *AdminServiceImpl) ListParticipants(ctx context.Context, input dto.ListParticipantsInput) (*dto.ParticipantPageOutput, error) {
+	return s.queryActivityUC.ExecuteParticipants(ctx, input)
+}
+
+// QueryActivityStats 查询活动完成统计
+func (s *AdminServiceImpl) QueryActivityStats(ctx context.Context, activityID int64) (*dto.ActivityStatsOutput, error) {
+	return s.queryActivityUC.ExecuteStats(ctx, activityID)
+}
+
+// CreateTaskDefinition 创建任务定义
+func (s *AdminServiceImpl) CreateTaskDefinition(ctx context.Context, input dto.TaskDefinitionInput) (*dto.TaskDefinitionOutput, error) {
+	return s.manageTaskDefUC.ExecuteCreate(ctx, input)
+}
+
+// UpdateTaskDefinition 更新任务定义
+func (s *AdminServiceImpl) UpdateTaskDefinition(ctx context.Context, input dto.TaskDefinitionInput) (*dto.TaskDefinitionOutput, error) {
+	return s.manageTaskDefUC.ExecuteUpdate(ctx, input)
+}
+
+// ListTaskDefinitions 查询活动下的任务定义
+func (s *AdminServiceImpl) ListTaskDefinitions(ctx context.Context, activityID int64, includeArchived bool) ([]*dto.TaskDefinitionOutput, error) {
+	return s.manageTaskDefUC.ExecuteList(ctx, activityID, includeArchived)
+}
+
+// ArchiveTaskDefinition 归档任务定义
+func (s *AdminServiceImpl) ArchiveTaskDefinition(ctx context.Context, taskDefID int64) (*dto.TaskDefinitionOutput, error) {
+	return s.manageTaskDefUC.ExecuteArchive(ctx, taskDefID)
+}
+
+// DryRunExpression 使用样例事件试运行任务表达式
+func (s *AdminServiceImpl) DryRunExpression(ctx context.Context, input dto.DryRunExpressionInput) (*dto.DryRunExpressionOutput, error) {
+	return s.dryRunExpressionUC.Execute(ctx, input)
+}
+
+// QueryExpressionTraces 查询任务达成条件的求值轨迹
+func (s *AdminServiceImpl) QueryExpressionTraces(ctx context.Context, input dto.QueryTraceInput) ([]*dto.ExpressionTraceOutput, error) {
+	return s.queryTraceUC.Execute(ctx, input)
+}
+
+// ListExpressionFunctions 查询表达式函数
+func (s *AdminServiceImpl) ListExpressionFunctions(ctx context.Context, taskType valueobject.TaskType) ([]*dto.FunctionOutput, error) {
+	return s.listFunctionUC.Execute(ctx, taskType)
+}
+
+// activityActionRequest 活动操作请求体（发布、暂停、结束、归档）
+type activityActionRequest struct {
+	ActivityID int64 `json:"activity_id"`
+}
+
+// taskDefinitionActionRequest 任务定义操作请求体（归档）
+type taskDefinitionActionRequest struct {
+	TaskDefID int64 `json:"task_def_id"`
+}
+
+// HTTP Handler methods
+
+// HandleCreateActivity 处理创建活动请求
+func (h *AdminHandler) HandleCreateActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input dto.CreateActivityInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	output, err := h.createActivityUC.Execute(r.Context(), input)
+	if err != nil {
+		writeAdminError(w, "Create activity failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleUpdateActivity 处理更新活动请求
+func (h *AdminHandler) HandleUpdateActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input dto.UpdateActivityInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	output, err := h.updateActivityUC.Execute(r.Context(), input)
+	if err != nil {
+		writeAdminError(w, "Update activity failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleGetActivity 处理查询单个活动请求
+func (h *AdminHandler) HandleGetActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	activityIDStr := r.URL.Query().Get("activity_id")
+	if activityIDStr == "" {
+		http.Error(w, "activity_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var activityID int64
+	fmt.Sscanf(activityIDStr, "%d", &activityID)
+
+	output, err := h.queryActivityUC.Execute(r.Context(), activityID)
+	if err != nil {
+		writeAdminError(w, "Query activity failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleListActivities 处理查询活动列表请求
+// include_archived=true 时包含已归档的活动
+func (h *AdminHandler) HandleListActivities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	includeArchived := r.URL.Query().Get("include_archived") == "true"
+
+	output, err := h.queryActivityUC.ExecuteList(r.Context(), includeArchived)
+	if err != nil {
+		writeAdminError(w, "List activities failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandlePublishActivity 处理发布活动请求
+func (h *AdminHandler) HandlePublishActivity(w http.ResponseWriter, r *http.Request) {
+	h.handleActivityAction(w, r, "Publish activity failed", h.activityLifecycleUC.ExecutePublish)
+}
+
+// HandlePauseActivity 处理暂停活动请求
+func (h *AdminHandler) HandlePauseActivity(w http.ResponseWriter, r *http.Request) {
+	h.handleActivityAction(w, r, "Pause activity failed", h.activityLifecycleUC.ExecutePause)
+}
+
+// HandleEndActivity 处理提前结束活动请求
+func (h *AdminHandler) HandleEndActivity(w http.ResponseWriter, r *http.Request) {
+	h.handleActivityAction(w, r, "End activity failed", h.activityLifecycleUC.ExecuteEnd)
+}
+
+// HandleArchiveActivity 处理归档活动请求
+func (h *AdminHandler) HandleArchiveActivity(w http.ResponseWriter, r *http.Request) {
+	h.handleActivityAction(w, r, "Archive activity failed", h.activityLifecycleUC.ExecuteArchive)
+}
+
+// handleActivityAction 处理请求体为 {"activity_id":...} 的活动状态操作
+func (h *AdminHandler) handleActivityAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	failedMsg string,
+	action func(ctx context.Context, activityID int64) (*dto.ActivityOutput, error),
+) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req activityActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	output, err := action(r.Context(), req.ActivityID)
+	if err != nil {
+		writeAdminError(w, failedMsg, err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleListParticipants 处理分页查询活动参与用户请求
+func (h *AdminHandler) HandleListParticipants(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("activity_id") == "" {
+		http.Error(w, "activity_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var input dto.ListParticipantsInput
+	fmt.Sscanf(query.Get("activity_id"), "%d", &input.ActivityID)
+	fmt.Sscanf(query.Get("page"), "%d", &input.Page)
+	fmt.Sscanf(query.Get("page_size"), "%d", &input.PageSize)
+
+	output, err := h.queryActivityUC.ExecuteParticipants(r.Context(), input)
+	if err != nil {
+		writeAdminError(w, "List participants failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleQueryActivityStats 处理查询活动完成统计请求
+func (h *AdminHandler) HandleQueryActivityStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	activityIDStr := r.URL.Query().Get("activity_id")
+	if activityIDStr == "" {
+		http.Error(w, "activity_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var activityID int64
+	fmt.Sscanf(activityIDStr, "%d", &activityID)
+
+	output, err := h.queryActivityUC.ExecuteStats(r.Context(), activityID)
+	if err != nil {
+		writeAdminError(w, "Query activity stats failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleCreateTaskDefinition 处理创建任务定义请求
+func (h *AdminHandler) HandleCreateTaskDefinition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input dto.TaskDefinitionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	output, err := h.manageTaskDefUC.ExecuteCreate(r.Context(), input)
+	if err != nil {
+		writeAdminError(w, "Create task definition failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleUpdateTaskDefinition 处理更新任务定义请求
+func (h *AdminHandler) HandleUpdateTaskDefinition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input dto.TaskDefinitionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	output, err := h.manageTaskDefUC.ExecuteUpdate(r.Context(), input)
+	if err != nil {
+		writeAdminError(w, "Update task definition failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleListTaskDefinitions 处理查询活动下任务定义请求
+// include_archived=true 时包含已归档的任务定义
+func (h *AdminHandler) HandleListTaskDefinitions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	activityIDStr := r.URL.Query().Get("activity_id")
+	if activityIDStr == "" {
+		http.Error(w, "activity_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var activityID int64
+	fmt.Sscanf(activityIDStr, "%d", &activityID)
+	includeArchived := r.URL.Query().Get("include_archived") == "true"
+
+	output, err := h.manageTaskDefUC.ExecuteList(r.Context(), activityID, includeArchived)
+	if err != nil {
+		writeAdminError(w, "List task definitions failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleArchiveTaskDefinition 处理归档任务定义请求
+func (h *AdminHandler) HandleArchiveTaskDefinition(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req taskDefinitionActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	output, err := h.manageTaskDefUC.ExecuteArchive(r.Context(), req.TaskDefID)
+	if err != nil {
+		writeAdminError(w, "Archive task definition failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleDryRunExpression 处理表达式试运行请求
+// 请求体：{"event":{"event_type":"publish","payload":{...}},"task_cond_expr":"...","progress_expr":"..."}
+func (h *AdminHandler) HandleDryRunExpression(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var input dto.DryRunExpressionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	output, err := h.dryRunExpressionUC.Execute(r.Context(), input)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Dry run expression failed: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleQueryExpressionTraces 处理查询求值轨迹请求
+// 通过 task_id（用户任务ID）或 user_id 查询，limit 默认 20
+func (h *AdminHandler) HandleQueryExpressionTraces(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("user_id") == "" && query.Get("task_id") == "" {
+		http.Error(w, "user_id or task_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var input dto.QueryTraceInput
+	fmt.Sscanf(query.Get("user_id"), "%d", &input.UserID)
+	fmt.Sscanf(query.Get("task_id"), "%d", &input.TaskID)
+	fmt.Sscanf(query.Get("limit"), "%d", &input.Limit)
+
+	output, err := h.queryTraceUC.Execute(r.Context(), input)
+	if err != nil {
+		writeAdminError(w, "Query expression traces failed", err)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// HandleListExpressionFunctions 处理查询表达式函数请求
+// 指定 task_type 时只返回该任务类型可用的函数
+func (h *AdminHandler) HandleListExpressionFunctions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskType := valueobject.TaskType(r.URL.Query().Get("task_type"))
+
+	output, err := h.listFunctionUC.Execute(r.Context(), taskType)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("List expression functions failed: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeSuccess(w, output)
+}
+
+// writeAdminError 按错误类型返回状态码：表达式或前置任务不合法为 400，活动不存在为 404，
+// 状态、版本冲突或目标值低于用户进度为 409
+func writeAdminError(w http.ResponseWriter, failedMsg string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, dto.ErrInvalidInput), errors.Is(err, valueobject.ErrInvalidExpression),
+		errors.Is(err, entity.ErrPrerequisiteCycle):
+		status = http.StatusBadRequest
+	case errors.Is(err, repository.ErrActivityNotFound), errors.Is(err, repository.ErrTaskDefinitionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, entity.ErrInvalidActivityTransition), errors.Is(err, entity.ErrActivityArchived),
+		errors.Is(err, repository.ErrVersionConflict), errors.Is(err, task.ErrTargetBelowProgress):
+		status = http.StatusConflict
+	}
+	http.Error(w, fmt.Sprintf("%s: %v", failedMsg, err), status)
+}
+
+// writeSuccess 返回统一的成功响应
+func writeSuccess(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": data,
+	})
+}
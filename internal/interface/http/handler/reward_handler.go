@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/port/input"
+	"mini-sirus/internal/usecase/task"
+	"net/http"
+)
+
+// RewardHandler 奖励处理器
+type RewardHandler struct {
+	queryRewardUC *task.QueryRewardUseCase
+	queryBudgetUC *task.QueryBudgetUseCase
+}
+
+// NewRewardHandler 创建奖励处理器
+func NewRewardHandler(queryRewardUC *task.QueryRewardUseCase, queryBudgetUC *task.QueryBudgetUseCase) *RewardHandler {
+	return &RewardHandler{
+		queryRewardUC: queryRewardUC,
+		queryBudgetUC: queryBudgetUC,
+	}
+}
+
+// 确保实现了接口
+var _ input.RewardService = (*RewardServiceImpl)(nil)
+
+// RewardServiceImpl 奖励服务实现
+type RewardServiceImpl struct {
+	queryRewardUC *task.QueryRewardUseCase
+	queryBudgetUC *task.QueryBudgetUseCase
+}
+
+// NewRewardServiceImpl 创建奖励服务实现
+func NewRewardServiceImpl(queryRewardUC *task.QueryRewardUseCase, queryBudgetUC *task.QueryBudgetUseCase) *RewardServiceImpl {
+	return &RewardServiceImpl{
+		queryRewardUC: queryRewardUC,
+		queryBudgetUC: queryBudgetUC,
+	}
+}
+
+// QueryWallet 查询用户奖励余额
+func (s *RewardServiceImpl) QueryWallet(ctx context.Context, userID int64) (*dto.WalletOutput, error) {
+	return s.queryRewardUC.ExecuteWallet(ctx, userID)
+}
+
+// QueryLedger 查询用户奖励流水
+func (s *RewardServiceImpl) QueryLedger(ctx context.Context, userID int64) ([]*dto.RewardLedgerOutput, error) {
+	return s.queryRewardUC.ExecuteLedger(ctx, userID)
+}
+
+// QueryActivityBudget 查询活动的剩余奖励预算
+func (s *RewardServiceImpl) QueryActivityBudget(ctx context.Context, activityID int64) (*dto.BudgetOutput, error) {
+	return s.queryBudgetUC.ExecuteActivity(ctx, activityID)
+}
+
+// QueryTaskBudget 查询任务定义的剩余奖励预算
+func (s *RewardServiceImpl) QueryTaskBudget(ctx context.Context, taskDefID int64) (*dto.BudgetOutput, error) {
+	return s.queryBudgetUC.ExecuteTask(ctx, taskDefID)
+}
+
+// HTTP Handler methods
+
+// HandleQueryWallet 处理查询用户奖励余额请求
+func (h *RewardHandler) HandleQueryWallet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userIDStr := r.URL.Query().Get("user_id")
+	if userIDStr == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var userID int64
+	fmt.Sscanf(userIDStr, "%d", &userID)
+
+	output, err := h.queryRewardUC.ExecuteWallet(r.Context(), userID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Query wallet failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": output,
+	})
+}
+
+// HandleQueryLedger 处理查询用户奖励流水请求
+func (h *RewardHandler) HandleQueryLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userIDStr := r.URL.Query().Get("user_id")
+	if userIDStr == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var userID int64
+	fmt.Sscanf(userIDStr, "%d", &userID)
+
+	output, err := h.queryRewardUC.ExecuteLedger(r.Context(), userID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Query ledger failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": output,
+	})
+}
+
+// HandleQueryBudget 处理查询剩余奖励预算请求
+// 通过 activity_id 查询活动预算，或通过 task_def_id 查询任务预算
+func (h *RewardHandler) HandleQueryBudget(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	activityIDStr := r.URL.Query().Get("activity_id")
+	taskDefIDStr := r.URL.Query().Get("task_def_id")
+
+	var (
+		output *dto.BudgetOutput
+		err    error
+	)
+	switch {
+	case activityIDStr != "":
+		var activityID int64
+		fmt.Sscanf(activityIDStr, "%d", &activityID)
+		output, err = h.queryBudgetUC.ExecuteActivity(r.Context(), activityID)
+	case taskDefIDStr != "":
+		var taskDefID int64
+		fmt.Sscanf(taskDefIDStr, "%d", &taskDefID)
+		output, err = h.queryBudgetUC.ExecuteTask(r.Context(), taskDefID)
+	default:
+		http.Error(w, "activity_id or task_def_id is required", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Query budget failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": output,
+	})
+}
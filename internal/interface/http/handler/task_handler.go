@@ -3,7 +3,10 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
 	"mini-sirus/internal/usecase/dto"
 	"mini-sirus/internal/usecase/port/input"
 	"mini-sirus/internal/usecase/task"
@@ -12,9 +15,10 @@ import (
 
 // TaskHandler 任务处理器
 type TaskHandler struct {
-	triggerTaskUC *task.TriggerTaskUseCase
-	createTaskUC  *task.CreateTaskUseCase
-	queryTaskUC   *task.QueryTaskUseCase
+	triggerTaskUC    *task.TriggerTaskUseCase
+	createTaskUC     *task.CreateTaskUseCase
+	queryTaskUC      *task.QueryTaskUseCase
+	taskModeRegistry *dto.TaskModeRegistry
 }
 
 // NewTaskHandler 创建任务处理器
@@ -22,11 +26,13 @@ func NewTaskHandler(
 	triggerTaskUC *task.TriggerTaskUseCase,
 	createTaskUC *task.CreateTaskUseCase,
 	queryTaskUC *task.QueryTaskUseCase,
+	taskModeRegistry *dto.TaskModeRegistry,
 ) *TaskHandler {
 	return &TaskHandler{
-		triggerTaskUC: triggerTaskUC,
-		createTaskUC:  createTaskUC,
-		queryTaskUC:   queryTaskUC,
+		triggerTaskUC:    triggerTaskUC,
+		createTaskUC:     createTaskUC,
+		queryTaskUC:      queryTaskUC,
+		taskModeRegistry: taskModeRegistry,
 	}
 }
 
@@ -73,6 +79,11 @@ func (s *TaskServiceImpl) QueryTasksByUser(ctx context.Context, userID int64) ([
 	return s.queryTaskUC.ExecuteList(ctx, userID)
 }
 
+// QueryTaskHistory 查询周期任务的历史周期进度
+func (s *TaskServiceImpl) QueryTaskHistory(ctx context.Context, taskID int64) ([]*dto.TaskPeriodOutput, error) {
+	return s.queryTaskUC.ExecuteHistory(ctx, taskID)
+}
+
 // HTTP Handler methods
 
 // HandleCreateTask 处理创建任务请求
@@ -90,6 +101,10 @@ func (h *TaskHandler) HandleCreateTask(w http.ResponseWriter, r *http.Request) {
 
 	output, err := h.createTaskUC.Execute(r.Context(), input)
 	if err != nil {
+		if errors.Is(err, repository.ErrUserTaskExists) {
+			http.Error(w, fmt.Sprintf("Create task failed: %v", err), http.StatusConflict)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Create task failed: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -137,20 +152,69 @@ func (h *TaskHandler) HandleQueryTask(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// HandleQueryTaskHistory 处理查询任务历史周期进度请求
+func (h *TaskHandler) HandleQueryTaskHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	taskIDStr := r.URL.Query().Get("task_id")
+	if taskIDStr == "" {
+		http.Error(w, "task_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var taskID int64
+	fmt.Sscanf(taskIDStr, "%d", &taskID)
+
+	output, err := h.queryTaskUC.ExecuteHistory(r.Context(), taskID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Query task history failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"msg":  "success",
+		"data": output,
+	})
+}
+
 // HandleTriggerTask 处理触发任务请求
+// 请求体为事件信封：{"event_type":"publish","payload":{...}}
 func (h *TaskHandler) HandleTriggerTask(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var input dto.TriggerTaskInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	var envelope dto.TriggerEventEnvelope
+	if err := json.NewDecoder(r.Body).Decode(&envelope); err != nil {
 		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
 
+	taskMode, err := h.taskModeRegistry.Decode(envelope)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid event: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	input := dto.TriggerTaskInput{TaskMode: taskMode}
 	if err := h.triggerTaskUC.Execute(r.Context(), input); err != nil {
+		if errors.Is(err, entity.ErrBudgetExhausted) || errors.Is(err, entity.ErrActivityNotActive) ||
+			errors.Is(err, repository.ErrVersionConflict) {
+			http.Error(w, fmt.Sprintf("Trigger task rejected: %v", err), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, task.ErrTaskLockBusy) {
+			// 同一用户的事件处理繁忙，调用方稍后重试
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, fmt.Sprintf("Trigger task busy: %v", err), http.StatusTooManyRequests)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Trigger task failed: %v", err), http.StatusInternalServerError)
 		return
 	}
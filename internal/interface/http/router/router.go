@@ -7,15 +7,23 @@ import (
 
 // Router 路由器
 type Router struct {
-	mux         *http.ServeMux
-	taskHandler *handler.TaskHandler
+	mux           *http.ServeMux
+	taskHandler   *handler.TaskHandler
+	rewardHandler *handler.RewardHandler
+	adminHandler  *handler.AdminHandler
 }
 
 // NewRouter 创建路由器
-func NewRouter(taskHandler *handler.TaskHandler) *Router {
+func NewRouter(
+	taskHandler *handler.TaskHandler,
+	rewardHandler *handler.RewardHandler,
+	adminHandler *handler.AdminHandler,
+) *Router {
 	router := &Router{
-		mux:         http.NewServeMux(),
-		taskHandler: taskHandler,
+		mux:           http.NewServeMux(),
+		taskHandler:   taskHandler,
+		rewardHandler: rewardHandler,
+		adminHandler:  adminHandler,
 	}
 
 	router.registerRoutes()
@@ -27,8 +35,35 @@ func (r *Router) registerRoutes() {
 	// 任务相关路由
 	r.mux.HandleFunc("/api/v1/task/create", r.taskHandler.HandleCreateTask)
 	r.mux.HandleFunc("/api/v1/task/query", r.taskHandler.HandleQueryTask)
+	r.mux.HandleFunc("/api/v1/task/history", r.taskHandler.HandleQueryTaskHistory)
 	r.mux.HandleFunc("/api/v1/task/trigger", r.taskHandler.HandleTriggerTask)
 
+	// 奖励相关路由
+	r.mux.HandleFunc("/api/v1/reward/wallet", r.rewardHandler.HandleQueryWallet)
+	r.mux.HandleFunc("/api/v1/reward/ledger", r.rewardHandler.HandleQueryLedger)
+	r.mux.HandleFunc("/api/v1/reward/budget", r.rewardHandler.HandleQueryBudget)
+
+	// 运营管理：活动
+	r.mux.HandleFunc("/api/v1/admin/activity/create", r.adminHandler.HandleCreateActivity)
+	r.mux.HandleFunc("/api/v1/admin/activity/update", r.adminHandler.HandleUpdateActivity)
+	r.mux.HandleFunc("/api/v1/admin/activity/get", r.adminHandler.HandleGetActivity)
+	r.mux.HandleFunc("/api/v1/admin/activity/list", r.adminHandler.HandleListActivities)
+	r.mux.HandleFunc("/api/v1/admin/activity/publish", r.adminHandler.HandlePublishActivity)
+	r.mux.HandleFunc("/api/v1/admin/activity/pause", r.adminHandler.HandlePauseActivity)
+	r.mux.HandleFunc("/api/v1/admin/activity/end", r.adminHandler.HandleEndActivity)
+	r.mux.HandleFunc("/api/v1/admin/activity/archive", r.adminHandler.HandleArchiveActivity)
+	r.mux.HandleFunc("/api/v1/admin/activity/participants", r.adminHandler.HandleListParticipants)
+	r.mux.HandleFunc("/api/v1/admin/activity/stats", r.adminHandler.HandleQueryActivityStats)
+
+	// 运营管理：任务定义
+	r.mux.HandleFunc("/api/v1/admin/task_definition/create", r.adminHandler.HandleCreateTaskDefinition)
+	r.mux.HandleFunc("/api/v1/admin/task_definition/update", r.adminHandler.HandleUpdateTaskDefinition)
+	r.mux.HandleFunc("/api/v1/admin/task_definition/list", r.adminHandler.HandleListTaskDefinitions)
+	r.mux.HandleFunc("/api/v1/admin/task_definition/archive", r.adminHandler.HandleArchiveTaskDefinition)
+	r.mux.HandleFunc("/api/v1/admin/expression/dry_run", r.adminHandler.HandleDryRunExpression)
+	r.mux.HandleFunc("/api/v1/admin/expression/trace", r.adminHandler.HandleQueryExpressionTraces)
+	r.mux.HandleFunc("/api/v1/admin/expression/functions", r.adminHandler.HandleListExpressionFunctions)
+
 	// 健康检查
 	r.mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,117 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/event"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
+	"mini-sirus/internal/usecase/port/output"
+	"time"
+)
+
+// ActivityLifecycleUseCase 活动生命周期用例（发布、暂停、结束、归档）
+type ActivityLifecycleUseCase struct {
+	activityRepo     repository.ActivityRepository
+	observerRegistry output.ActivityObserverRegistry
+	clock            function.Clock
+}
+
+// NewActivityLifecycleUseCase 创建活动生命周期用例
+func NewActivityLifecycleUseCase(
+	activityRepo repository.ActivityRepository,
+	observerRegistry output.ActivityObserverRegistry,
+	clock function.Clock,
+) *ActivityLifecycleUseCase {
+	return &ActivityLifecycleUseCase{
+		activityRepo:     activityRepo,
+		observerRegistry: observerRegistry,
+		clock:            clock,
+	}
+}
+
+// ExecutePublish 发布活动
+// 已到开始时间的活动立即激活，否则等待调度器在开始时间激活；暂停中的活动恢复为激活中
+func (uc *ActivityLifecycleUseCase) ExecutePublish(ctx context.Context, activityID int64) (*dto.ActivityOutput, error) {
+	return uc.change(ctx, activityID, func(activity *entity.ActActivity, now time.Time) error {
+		return activity.Publish(now)
+	})
+}
+
+// ExecutePause 暂停活动
+func (uc *ActivityLifecycleUseCase) ExecutePause(ctx context.Context, activityID int64) (*dto.ActivityOutput, error) {
+	return uc.change(ctx, activityID, func(activity *entity.ActActivity, now time.Time) error {
+		return activity.TransitionTo(entity.ActivityStatusPaused, now)
+	})
+}
+
+// ExecuteEnd 提前结束活动
+func (uc *ActivityLifecycleUseCase) ExecuteEnd(ctx context.Context, activityID int64) (*dto.ActivityOutput, error) {
+	return uc.change(ctx, activityID, func(activity *entity.ActActivity, now time.Time) error {
+		return activity.TransitionTo(entity.ActivityStatusExpired, now)
+	})
+}
+
+// ExecuteArchive 归档活动，仅未激活或已结束的活动可以归档
+func (uc *ActivityLifecycleUseCase) ExecuteArchive(ctx context.Context, activityID int64) (*dto.ActivityOutput, error) {
+	return uc.change(ctx, activityID, func(activity *entity.ActActivity, now time.Time) error {
+		return activity.Archive(now)
+	})
+}
+
+// change 加载活动、执行状态变更并持久化，状态发生变化时通知观察者
+func (uc *ActivityLifecycleUseCase) change(
+	ctx context.Context,
+	activityID int64,
+	apply func(activity *entity.ActActivity, now time.Time) error,
+) (*dto.ActivityOutput, error) {
+	if activityID <= 0 {
+		return nil, fmt.Errorf("%w: activity_id is required", dto.ErrInvalidInput)
+	}
+
+	activity, err := uc.activityRepo.GetByID(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.clock.Now()
+	from := activity.Status
+	if err := apply(activity, now); err != nil {
+		return nil, err
+	}
+
+	if err := uc.activityRepo.Update(ctx, activity); err != nil {
+		return nil, fmt.Errorf("update activity failed: %w", err)
+	}
+
+	if activity.Status != from {
+		notifyStatusChanged(ctx, uc.observerRegistry, activity, from, event.ActivityStatusTriggerManual, now)
+	}
+
+	return toActivityOutput(activity), nil
+}
+
+// notifyStatusChanged 发布活动状态变更事件，通知失败不影响状态变更
+func notifyStatusChanged(
+	ctx context.Context,
+	observerRegistry output.ActivityObserverRegistry,
+	activity *entity.ActActivity,
+	from entity.ActivityStatus,
+	trigger event.ActivityStatusTrigger,
+	changedAt time.Time,
+) {
+	fmt.Printf("[Activity] Activity %d %s -> %s (%s)\n", activity.ID, from, activity.Status, trigger)
+
+	changed := &event.ActivityStatusChanged{
+		ActivityID: activity.ID,
+		From:       from,
+		To:         activity.Status,
+		Trigger:    trigger,
+		ChangedAt:  changedAt,
+	}
+	if err := observerRegistry.NotifyStatusChanged(ctx, changed); err != nil {
+		fmt.Printf("[Activity] Notify activity status changed failed: %v\n", err)
+	}
+}
@@ -0,0 +1,89 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/event"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/usecase/function"
+	"mini-sirus/internal/usecase/port/output"
+	"time"
+)
+
+// ActivityScheduler 活动状态调度器
+// 定期扫描活动，在开始时间激活已发布的活动，在结束时间将活动置为已过期
+type ActivityScheduler struct {
+	activityRepo     repository.ActivityRepository
+	observerRegistry output.ActivityObserverRegistry
+	interval         time.Duration
+	clock            function.Clock
+}
+
+// NewActivityScheduler 创建活动状态调度器
+func NewActivityScheduler(
+	activityRepo repository.ActivityRepository,
+	observerRegistry output.ActivityObserverRegistry,
+	interval time.Duration,
+	clock function.Clock,
+) *ActivityScheduler {
+	return &ActivityScheduler{
+		activityRepo:     activityRepo,
+		observerRegistry: observerRegistry,
+		interval:         interval,
+		clock:            clock,
+	}
+}
+
+// Run 在后台按固定间隔执行调度，直到 ctx 取消；调度时间取自注入的时钟
+func (s *ActivityScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RunOnce(ctx, s.clock.Now()); err != nil {
+				fmt.Printf("[ActivityScheduler] Run failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// RunOnce 按给定时间执行一次调度，返回发生状态变更的活动数量
+// 单个活动变更失败（如与管理操作的版本冲突）时记录后继续处理其他活动，最后返回汇总的错误，
+// 失败的活动在下一次调度时重试
+func (s *ActivityScheduler) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	activities, err := s.activityRepo.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list activities failed: %w", err)
+	}
+
+	changed := 0
+	var errs []error
+	for _, activity := range activities {
+		to, due := activity.ScheduledStatus(now)
+		if !due {
+			continue
+		}
+
+		from := activity.Status
+		if err := activity.TransitionTo(to, now); err != nil {
+			fmt.Printf("[ActivityScheduler] Transition activity %d failed: %v\n", activity.ID, err)
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.activityRepo.Update(ctx, activity); err != nil {
+			fmt.Printf("[ActivityScheduler] Update activity %d failed: %v\n", activity.ID, err)
+			errs = append(errs, fmt.Errorf("update activity %d failed: %w", activity.ID, err))
+			continue
+		}
+
+		notifyStatusChanged(ctx, s.observerRegistry, activity, from, event.ActivityStatusTriggerScheduler, now)
+		changed++
+	}
+
+	return changed, errors.Join(errs...)
+}
@@ -0,0 +1,78 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/usecase/dto"
+	"time"
+)
+
+// CreateActivityUseCase 创建活动用例
+type CreateActivityUseCase struct {
+	activityRepo repository.ActivityRepository
+}
+
+// NewCreateActivityUseCase 创建活动用例
+func NewCreateActivityUseCase(activityRepo repository.ActivityRepository) *CreateActivityUseCase {
+	return &CreateActivityUseCase{
+		activityRepo: activityRepo,
+	}
+}
+
+// Execute 执行创建活动用例
+// 新建活动处于未激活状态，需发布后才会生效
+func (uc *CreateActivityUseCase) Execute(ctx context.Context, input dto.CreateActivityInput) (*dto.ActivityOutput, error) {
+	activity := &entity.ActActivity{
+		Name:      input.Name,
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+		Status:    entity.ActivityStatusInactive,
+		Budget:    valueobject.NewRewardBudget(input.MaxRewardUnits, input.MaxCompleters),
+	}
+	if err := validateActivity(activity); err != nil {
+		return nil, err
+	}
+
+	if err := uc.activityRepo.Create(ctx, activity); err != nil {
+		return nil, fmt.Errorf("create activity failed: %w", err)
+	}
+
+	return toActivityOutput(activity), nil
+}
+
+// validateActivity 验证活动配置
+func validateActivity(activity *entity.ActActivity) error {
+	if activity.Name == "" {
+		return fmt.Errorf("%w: name is required", dto.ErrInvalidInput)
+	}
+	if activity.StartTime.IsZero() || activity.EndTime.IsZero() {
+		return fmt.Errorf("%w: start_time and end_time are required", dto.ErrInvalidInput)
+	}
+	if !activity.EndTime.After(activity.StartTime) {
+		return fmt.Errorf("%w: end_time must be after start_time", dto.ErrInvalidInput)
+	}
+	if !activity.Budget.IsValid() {
+		return fmt.Errorf("%w: budget must not be negative", dto.ErrInvalidInput)
+	}
+	return nil
+}
+
+// toActivityOutput 转换为活动输出DTO
+func toActivityOutput(activity *entity.ActActivity) *dto.ActivityOutput {
+	return &dto.ActivityOutput{
+		ID:             activity.ID,
+		Name:           activity.Name,
+		StartTime:      activity.StartTime.Format(time.RFC3339),
+		EndTime:        activity.EndTime.Format(time.RFC3339),
+		Status:         activity.Status.String(),
+		Published:      activity.Published,
+		Archived:       activity.Archived,
+		MaxRewardUnits: activity.Budget.MaxRewardUnits,
+		MaxCompleters:  activity.Budget.MaxCompleters,
+		CreatedAt:      activity.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      activity.UpdatedAt.Format(time.RFC3339),
+	}
+}
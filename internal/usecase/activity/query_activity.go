@@ -0,0 +1,164 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/usecase/dto"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// QueryActivityUseCase 查询活动用例（活动列表、参与用户、完成统计）
+type QueryActivityUseCase struct {
+	activityRepo repository.ActivityRepository
+	taskRepo     repository.TaskRepository
+	taskDefRepo  repository.TaskDefinitionRepository
+}
+
+// NewQueryActivityUseCase 创建查询活动用例
+func NewQueryActivityUseCase(
+	activityRepo repository.ActivityRepository,
+	taskRepo repository.TaskRepository,
+	taskDefRepo repository.TaskDefinitionRepository,
+) *QueryActivityUseCase {
+	return &QueryActivityUseCase{
+		activityRepo: activityRepo,
+		taskRepo:     taskRepo,
+		taskDefRepo:  taskDefRepo,
+	}
+}
+
+// Execute 查询单个活动
+func (uc *QueryActivityUseCase) Execute(ctx context.Context, activityID int64) (*dto.ActivityOutput, error) {
+	if activityID <= 0 {
+		return nil, fmt.Errorf("%w: activity_id is required", dto.ErrInvalidInput)
+	}
+
+	activity, err := uc.activityRepo.GetByID(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toActivityOutput(activity), nil
+}
+
+// ExecuteList 查询活动列表，默认不包含已归档的活动
+func (uc *QueryActivityUseCase) ExecuteList(ctx context.Context, includeArchived bool) ([]*dto.ActivityOutput, error) {
+	activities, err := uc.activityRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list activities failed: %w", err)
+	}
+
+	outputs := make([]*dto.ActivityOutput, 0, len(activities))
+	for _, activity := range activities {
+		if activity.Archived && !includeArchived {
+			continue
+		}
+		outputs = append(outputs, toActivityOutput(activity))
+	}
+
+	return outputs, nil
+}
+
+// ExecuteParticipants 分页查询活动参与用户
+func (uc *QueryActivityUseCase) ExecuteParticipants(ctx context.Context, input dto.ListParticipantsInput) (*dto.ParticipantPageOutput, error) {
+	if input.ActivityID <= 0 {
+		return nil, fmt.Errorf("%w: activity_id is required", dto.ErrInvalidInput)
+	}
+	if _, err := uc.activityRepo.GetByID(ctx, input.ActivityID); err != nil {
+		return nil, err
+	}
+
+	pageSize := input.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+	// 页码过大时截断，避免偏移量溢出
+	page := min(max(input.Page, 1), math.MaxInt/pageSize)
+
+	participants, total, err := uc.taskRepo.ListParticipants(ctx, input.ActivityID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("list participants failed: %w", err)
+	}
+
+	output := &dto.ParticipantPageOutput{
+		ActivityID: input.ActivityID,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		Items:      make([]*dto.ParticipantOutput, 0, len(participants)),
+	}
+	for _, participant := range participants {
+		output.Items = append(output.Items, &dto.ParticipantOutput{
+			UserID:         participant.UserID,
+			TaskCount:      participant.TaskCount,
+			CompletedCount: participant.CompletedCount,
+			JoinedAt:       participant.JoinedAt.Format(time.RFC3339),
+		})
+	}
+
+	return output, nil
+}
+
+// ExecuteStats 查询活动的完成统计
+func (uc *QueryActivityUseCase) ExecuteStats(ctx context.Context, activityID int64) (*dto.ActivityStatsOutput, error) {
+	if activityID <= 0 {
+		return nil, fmt.Errorf("%w: activity_id is required", dto.ErrInvalidInput)
+	}
+	if _, err := uc.activityRepo.GetByID(ctx, activityID); err != nil {
+		return nil, err
+	}
+
+	participants, total, err := uc.taskRepo.ListParticipants(ctx, activityID, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list participants failed: %w", err)
+	}
+	stats, err := uc.taskRepo.StatsByActivityID(ctx, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("stats tasks failed: %w", err)
+	}
+	defs, err := uc.taskDefRepo.ListByActivityID(ctx, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("list task definitions failed: %w", err)
+	}
+
+	names := make(map[int64]string, len(defs))
+	for _, def := range defs {
+		names[def.ID] = def.Name
+	}
+
+	output := &dto.ActivityStatsOutput{
+		ActivityID:   activityID,
+		Participants: total,
+		Tasks:        make([]*dto.TaskStatOutput, 0, len(stats)),
+	}
+	for _, participant := range participants {
+		if participant.CompletedCount > 0 {
+			output.CompletedUsers++
+		}
+	}
+	for _, stat := range stats {
+		output.TaskCompletions += stat.Completed
+
+		taskStat := &dto.TaskStatOutput{
+			TaskDefID:    stat.TaskDefID,
+			Name:         names[stat.TaskDefID],
+			Participants: stat.Participants,
+			Completed:    stat.Completed,
+			Locked:       stat.Locked,
+		}
+		if stat.Participants > 0 {
+			taskStat.CompletionRate = float64(stat.Completed) / float64(stat.Participants)
+		}
+		output.Tasks = append(output.Tasks, taskStat)
+	}
+
+	return output, nil
+}
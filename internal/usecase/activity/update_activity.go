@@ -0,0 +1,57 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/usecase/dto"
+	"time"
+)
+
+// UpdateActivityUseCase 更新活动用例
+type UpdateActivityUseCase struct {
+	activityRepo repository.ActivityRepository
+}
+
+// NewUpdateActivityUseCase 创建更新活动用例
+func NewUpdateActivityUseCase(activityRepo repository.ActivityRepository) *UpdateActivityUseCase {
+	return &UpdateActivityUseCase{
+		activityRepo: activityRepo,
+	}
+}
+
+// Execute 执行更新活动用例
+// 已结束的活动不允许修改，状态只能通过发布/暂停/结束流转
+func (uc *UpdateActivityUseCase) Execute(ctx context.Context, input dto.UpdateActivityInput) (*dto.ActivityOutput, error) {
+	if input.ActivityID <= 0 {
+		return nil, fmt.Errorf("%w: activity_id is required", dto.ErrInvalidInput)
+	}
+
+	activity, err := uc.activityRepo.GetByID(ctx, input.ActivityID)
+	if err != nil {
+		return nil, err
+	}
+	if activity.Archived {
+		return nil, entity.ErrActivityArchived
+	}
+	if activity.IsExpired() {
+		return nil, fmt.Errorf("activity %d has ended and cannot be updated", activity.ID)
+	}
+
+	activity.Name = input.Name
+	activity.StartTime = input.StartTime
+	activity.EndTime = input.EndTime
+	activity.Budget = valueobject.NewRewardBudget(input.MaxRewardUnits, input.MaxCompleters)
+	if err := validateActivity(activity); err != nil {
+		return nil, err
+	}
+
+	activity.UpdatedAt = time.Now()
+	if err := uc.activityRepo.Update(ctx, activity); err != nil {
+		return nil, fmt.Errorf("update activity failed: %w", err)
+	}
+
+	return toActivityOutput(activity), nil
+}
@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"time"
+)
+
+// CreateActivityInput 创建活动输入
+type CreateActivityInput struct {
+	Name           string    `json:"name"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	MaxRewardUnits int64     `json:"max_reward_units"` // 活动激励值总量上限，0 表示不限制
+	MaxCompleters  int64     `json:"max_completers"`   // 活动完成人数上限，0 表示不限制
+}
+
+// UpdateActivityInput 更新活动输入
+type UpdateActivityInput struct {
+	ActivityID     int64     `json:"activity_id"`
+	Name           string    `json:"name"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	MaxRewardUnits int64     `json:"max_reward_units"`
+	MaxCompleters  int64     `json:"max_completers"`
+}
+
+// ActivityOutput 活动输出
+type ActivityOutput struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	StartTime      string `json:"start_time"`
+	EndTime        string `json:"end_time"`
+	Status         string `json:"status"`
+	Published      bool   `json:"published"`
+	Archived       bool   `json:"archived"`
+	MaxRewardUnits int64  `json:"max_reward_units"`
+	MaxCompleters  int64  `json:"max_completers"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
+
+// ListParticipantsInput 分页查询活动参与用户输入
+type ListParticipantsInput struct {
+	ActivityID int64 `json:"activity_id"`
+	Page       int   `json:"page"`      // 从 1 开始，默认 1
+	PageSize   int   `json:"page_size"` // 默认 20，最大 100
+}
+
+// ParticipantOutput 活动参与用户输出
+type ParticipantOutput struct {
+	UserID         int64  `json:"user_id"`
+	TaskCount      int    `json:"task_count"`
+	CompletedCount int    `json:"completed_count"`
+	JoinedAt       string `json:"joined_at"`
+}
+
+// ParticipantPageOutput 活动参与用户分页输出
+type ParticipantPageOutput struct {
+	ActivityID int64                `json:"activity_id"`
+	Page       int                  `json:"page"`
+	PageSize   int                  `json:"page_size"`
+	Total      int                  `json:"total"`
+	Items      []*ParticipantOutput `json:"items"`
+}
+
+// TaskStatOutput 任务完成统计输出
+type TaskStatOutput struct {
+	TaskDefID      int64   `json:"task_def_id"`
+	Name           string  `json:"name"`
+	Participants   int     `json:"participants"`
+	Completed      int     `json:"completed"`
+	Locked         int     `json:"locked"`
+	CompletionRate float64 `json:"completion_rate"` // 已完成用户数 / 参与用户数
+}
+
+// ActivityStatsOutput 活动完成统计输出
+type ActivityStatsOutput struct {
+	ActivityID      int64             `json:"activity_id"`
+	Participants    int               `json:"participants"`     // 参与用户数
+	CompletedUsers  int               `json:"completed_users"`  // 至少完成一个任务的用户数
+	TaskCompletions int               `json:"task_completions"` // 已完成的用户任务总数
+	Tasks           []*TaskStatOutput `json:"tasks"`
+}
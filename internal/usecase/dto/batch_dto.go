@@ -0,0 +1,50 @@
+package dto
+
+// UserIDSourceType 用户ID来源类型
+type UserIDSourceType string
+
+const (
+	UserIDSourceList  UserIDSourceType = "list"  // 用户ID列表
+	UserIDSourceRange UserIDSourceType = "range" // 用户ID区间
+	UserIDSourceFile  UserIDSourceType = "file"  // 用户ID文件（每行一个）
+)
+
+// 批量预创建状态
+const (
+	BatchStatusRunning   = "running"
+	BatchStatusCompleted = "completed"
+	BatchStatusFailed    = "failed"
+)
+
+// UserIDSourceInput 用户ID来源
+type UserIDSourceInput struct {
+	Type     UserIDSourceType
+	UserIDs  []int64 // list 模式
+	StartID  int64   // range 模式，包含
+	EndID    int64   // range 模式，包含
+	FilePath string  // file 模式
+}
+
+// BatchCreateTaskInput 批量预创建任务输入
+type BatchCreateTaskInput struct {
+	JobID      string // 批次ID，为空时根据活动ID生成；相同批次ID会从断点续跑
+	ActivityID int64
+	Source     UserIDSourceInput
+	ChunkSize  int                                // 每批用户数
+	Workers    int                                // 并发数
+	OnProgress func(progress BatchCreateProgress) // 进度回调（可选）
+}
+
+// BatchCreateProgress 批量预创建进度
+type BatchCreateProgress struct {
+	JobID      string `json:"job_id"`
+	ActivityID int64  `json:"activity_id"`
+	Status     string `json:"status"`
+	Total      int64  `json:"total"` // 用户总数，未知时为 -1
+	Processed  int64  `json:"processed"`
+	Created    int64  `json:"created"`
+	Skipped    int64  `json:"skipped"`
+	LastError  string `json:"last_error,omitempty"`
+	StartedAt  string `json:"started_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+import "errors"
+
+// ErrInvalidInput 请求参数校验失败，由接口层映射为 400
+var ErrInvalidInput = errors.New("invalid input")
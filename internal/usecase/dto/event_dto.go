@@ -1,8 +1,11 @@
 package dto
 
 import (
+	"errors"
 	"fmt"
 	"mini-sirus/internal/domain/valueobject"
+	"time"
+	"unicode/utf8"
 )
 
 // TaskModeDTO 任务模式数据传输对象接口
@@ -25,13 +28,27 @@ type TaskModeDTO interface {
 
 // PublishEventDTO 发布事件DTO
 type PublishEventDTO struct {
-	UserID       int64
-	ContentID    int64
-	TopicIDs     []uint64
-	LikeCount    int
-	CommentCount int
-	IsAudited    bool
-	AuditStatus  int
+	UserID       int64    `json:"user_id"`
+	ContentID    int64    `json:"content_id"`
+	TopicIDs     []uint64 `json:"topic_ids"`
+	LikeCount    int      `json:"like_count"`
+	CommentCount int      `json:"comment_count"`
+	IsAudited    bool     `json:"is_audited"`
+	AuditStatus  int      `json:"audit_status"`
+}
+
+// Validate 实现 Validatable 接口
+func (p *PublishEventDTO) Validate() error {
+	if p.UserID <= 0 {
+		return errors.New("user_id is required")
+	}
+	if p.ContentID <= 0 {
+		return errors.New("content_id is required")
+	}
+	if p.LikeCount < 0 || p.CommentCount < 0 {
+		return errors.New("like_count and comment_count must not be negative")
+	}
+	return nil
 }
 
 // GetTaskType 实现 TaskModeDTO 接口
@@ -54,14 +71,13 @@ func (p *PublishEventDTO) GetUniqueFlag() string {
 // GetExpressionArguments 实现 TaskModeDTO 接口
 func (p *PublishEventDTO) GetExpressionArguments() valueobject.ExpressionArguments {
 	return valueobject.ExpressionArguments{
-		"user_id":          float64(p.UserID),
-		"content_id":       float64(p.ContentID),
-		"tag_ids":          p.TopicIDs,
-		"required_tag_ids": []uint64{1001, 1002}, // 任务要求的话题ID列表
-		"like_count":       float64(p.LikeCount),
-		"comment_count":    float64(p.CommentCount),
-		"is_audited":       p.IsAudited,
-		"audit_status":     float64(p.AuditStatus),
+		"user_id":       float64(p.UserID),
+		"content_id":    float64(p.ContentID),
+		"tag_ids":       p.TopicIDs,
+		"like_count":    float64(p.LikeCount),
+		"comment_count": float64(p.CommentCount),
+		"is_audited":    p.IsAudited,
+		"audit_status":  float64(p.AuditStatus),
 	}
 }
 
@@ -72,8 +88,25 @@ func (p *PublishEventDTO) GetExpressionFunctions() []string {
 
 // CheckinEventDTO 签到事件DTO
 type CheckinEventDTO struct {
-	UserID int64
-	Date   string
+	UserID   int64  `json:"user_id"`
+	Date     string `json:"date"`     // 格式：2006-01-02
+	Timezone string `json:"timezone"` // 用户所在时区（IANA），为空时使用服务器时区
+}
+
+// Validate 实现 Validatable 接口
+func (c *CheckinEventDTO) Validate() error {
+	if c.UserID <= 0 {
+		return errors.New("user_id is required")
+	}
+	if _, err := time.Parse("2006-01-02", c.Date); err != nil {
+		return errors.New("date must be in YYYY-MM-DD format")
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return errors.New("timezone must be a valid IANA timezone")
+		}
+	}
+	return nil
 }
 
 // GetTaskType 实现 TaskModeDTO 接口
@@ -95,12 +128,169 @@ func (c *CheckinEventDTO) GetUniqueFlag() string {
 // GetExpressionArguments 实现 TaskModeDTO 接口
 func (c *CheckinEventDTO) GetExpressionArguments() valueobject.ExpressionArguments {
 	return valueobject.ExpressionArguments{
-		"user_id": float64(c.UserID),
-		"date":    c.Date,
+		"user_id":  float64(c.UserID),
+		"date":     c.Date,
+		"timezone": c.Timezone,
 	}
 }
 
 // GetExpressionFunctions 实现 TaskModeDTO 接口
 func (c *CheckinEventDTO) GetExpressionFunctions() []string {
-	return []string{"IS_TODAY"}
+	return []string{"IS_TODAY", "WITHIN_ACTIVITY", "HOUR_BETWEEN", "WEEKDAY_IN", "DAYS_SINCE"}
+}
+
+// ShareEventDTO 分享事件DTO
+type ShareEventDTO struct {
+	UserID    int64  `json:"user_id"`
+	ContentID int64  `json:"content_id"`
+	AuthorID  int64  `json:"author_id"` // 被分享内容的作者
+	Channel   string `json:"channel"`   // 分享渠道，如 wechat、weibo、qq
+}
+
+// Validate 实现 Validatable 接口
+func (s *ShareEventDTO) Validate() error {
+	if s.UserID <= 0 {
+		return errors.New("user_id is required")
+	}
+	if s.ContentID <= 0 {
+		return errors.New("content_id is required")
+	}
+	if s.Channel == "" {
+		return errors.New("channel is required")
+	}
+	return nil
+}
+
+// GetTaskType 实现 TaskModeDTO 接口
+func (s *ShareEventDTO) GetTaskType() valueobject.TaskType {
+	return valueobject.TaskTypeShareTimes
+}
+
+// GetUserID 实现 TaskModeDTO 接口
+func (s *ShareEventDTO) GetUserID() int64 {
+	return s.UserID
+}
+
+// GetUniqueFlag 实现 TaskModeDTO 接口
+func (s *ShareEventDTO) GetUniqueFlag() string {
+	// 同一内容分享到同一渠道只计一次，分享到不同渠道分别计数
+	return fmt.Sprintf("share:%d:%d:%s", s.UserID, s.ContentID, s.Channel)
+}
+
+// GetExpressionArguments 实现 TaskModeDTO 接口
+func (s *ShareEventDTO) GetExpressionArguments() valueobject.ExpressionArguments {
+	return valueobject.ExpressionArguments{
+		"user_id":    float64(s.UserID),
+		"content_id": float64(s.ContentID),
+		"author_id":  float64(s.AuthorID),
+		"channel":    s.Channel,
+	}
+}
+
+// GetExpressionFunctions 实现 TaskModeDTO 接口
+func (s *ShareEventDTO) GetExpressionFunctions() []string {
+	return []string{"CHANNEL_IN", "AUTHOR_IN", "IS_OTHERS_CONTENT"}
+}
+
+// LikeEventDTO 点赞事件DTO
+type LikeEventDTO struct {
+	UserID    int64 `json:"user_id"`
+	ContentID int64 `json:"content_id"`
+	AuthorID  int64 `json:"author_id"` // 被点赞内容的作者
+}
+
+// Validate 实现 Validatable 接口
+func (l *LikeEventDTO) Validate() error {
+	if l.UserID <= 0 {
+		return errors.New("user_id is required")
+	}
+	if l.ContentID <= 0 {
+		return errors.New("content_id is required")
+	}
+	return nil
+}
+
+// GetTaskType 实现 TaskModeDTO 接口
+func (l *LikeEventDTO) GetTaskType() valueobject.TaskType {
+	return valueobject.TaskTypeLikeTimes
+}
+
+// GetUserID 实现 TaskModeDTO 接口
+func (l *LikeEventDTO) GetUserID() int64 {
+	return l.UserID
+}
+
+// GetUniqueFlag 实现 TaskModeDTO 接口
+func (l *LikeEventDTO) GetUniqueFlag() string {
+	// 同一内容只计一次，避免取消点赞后重复点赞刷任务
+	return fmt.Sprintf("like:%d:%d", l.UserID, l.ContentID)
+}
+
+// GetExpressionArguments 实现 TaskModeDTO 接口
+func (l *LikeEventDTO) GetExpressionArguments() valueobject.ExpressionArguments {
+	return valueobject.ExpressionArguments{
+		"user_id":    float64(l.UserID),
+		"content_id": float64(l.ContentID),
+		"author_id":  float64(l.AuthorID),
+	}
+}
+
+// GetExpressionFunctions 实现 TaskModeDTO 接口
+func (l *LikeEventDTO) GetExpressionFunctions() []string {
+	return []string{"AUTHOR_IN", "IS_OTHERS_CONTENT"}
+}
+
+// CommentEventDTO 评论事件DTO
+type CommentEventDTO struct {
+	UserID    int64  `json:"user_id"`
+	ContentID int64  `json:"content_id"`
+	CommentID int64  `json:"comment_id"`
+	AuthorID  int64  `json:"author_id"` // 被评论内容的作者
+	Text      string `json:"text"`
+}
+
+// Validate 实现 Validatable 接口
+func (c *CommentEventDTO) Validate() error {
+	if c.UserID <= 0 {
+		return errors.New("user_id is required")
+	}
+	if c.ContentID <= 0 {
+		return errors.New("content_id is required")
+	}
+	if c.CommentID <= 0 {
+		return errors.New("comment_id is required")
+	}
+	return nil
+}
+
+// GetTaskType 实现 TaskModeDTO 接口
+func (c *CommentEventDTO) GetTaskType() valueobject.TaskType {
+	return valueobject.TaskTypeCommentTimes
+}
+
+// GetUserID 实现 TaskModeDTO 接口
+func (c *CommentEventDTO) GetUserID() int64 {
+	return c.UserID
+}
+
+// GetUniqueFlag 实现 TaskModeDTO 接口
+func (c *CommentEventDTO) GetUniqueFlag() string {
+	// 每条评论计一次
+	return fmt.Sprintf("comment:%d:%d", c.UserID, c.CommentID)
+}
+
+// GetExpressionArguments 实现 TaskModeDTO 接口
+func (c *CommentEventDTO) GetExpressionArguments() valueobject.ExpressionArguments {
+	return valueobject.ExpressionArguments{
+		"user_id":        float64(c.UserID),
+		"content_id":     float64(c.ContentID),
+		"comment_id":     float64(c.CommentID),
+		"author_id":      float64(c.AuthorID),
+		"comment_length": float64(utf8.RuneCountInString(c.Text)),
+	}
+}
+
+// GetExpressionFunctions 实现 TaskModeDTO 接口
+func (c *CommentEventDTO) GetExpressionFunctions() []string {
+	return []string{"AUTHOR_IN", "IS_OTHERS_CONTENT", "LENGTH_GTE"}
 }
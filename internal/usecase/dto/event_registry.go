@@ -0,0 +1,205 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/valueobject"
+	"sort"
+	"sync"
+)
+
+// 事件类型
+const (
+	EventTypePublish = "publish"
+	EventTypeCheckin = "checkin"
+	EventTypeShare   = "share"
+	EventTypeLike    = "like"
+	EventTypeComment = "comment"
+)
+
+// ErrUnknownEventType 未注册的事件类型
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// TriggerEventEnvelope 触发事件信封
+// 例如：{"event_type":"publish","payload":{"user_id":1,"content_id":2}}
+type TriggerEventEnvelope struct {
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// Validatable 可校验的事件DTO
+type Validatable interface {
+	// Validate 校验事件字段
+	Validate() error
+}
+
+// ExpressionSchema 任务类型的表达式可引用的参数与函数
+// 由该任务类型对应的事件DTO声明（GetExpressionArguments 的参数名与 GetExpressionFunctions），
+// 参数类型取自空事件DTO的参数值
+type ExpressionSchema struct {
+	TaskType   valueobject.TaskType             `json:"task_type"`
+	Parameters []string                         `json:"parameters"`
+	Types      map[string]valueobject.ValueType `json:"types"`
+	Functions  []string                         `json:"functions"`
+}
+
+// TaskModeFactory 创建空的事件DTO，用于反序列化 payload
+type TaskModeFactory func() TaskModeDTO
+
+// TaskModeRegistry 事件类型注册表
+// 维护事件类型到具体事件DTO的映射，新增事件类型只需注册，无需修改 HTTP 处理器
+type TaskModeRegistry struct {
+	mu        sync.RWMutex
+	factories map[string]TaskModeFactory
+}
+
+// NewTaskModeRegistry 创建空的事件类型注册表
+func NewTaskModeRegistry() *TaskModeRegistry {
+	return &TaskModeRegistry{
+		factories: make(map[string]TaskModeFactory),
+	}
+}
+
+// NewDefaultTaskModeRegistry 创建注册了内置事件类型的注册表
+func NewDefaultTaskModeRegistry() *TaskModeRegistry {
+	registry := NewTaskModeRegistry()
+	_ = registry.Register(EventTypePublish, func() TaskModeDTO { return &PublishEventDTO{} })
+	_ = registry.Register(EventTypeCheckin, func() TaskModeDTO { return &CheckinEventDTO{} })
+	_ = registry.Register(EventTypeShare, func() TaskModeDTO { return &ShareEventDTO{} })
+	_ = registry.Register(EventTypeLike, func() TaskModeDTO { return &LikeEventDTO{} })
+	_ = registry.Register(EventTypeComment, func() TaskModeDTO { return &CommentEventDTO{} })
+	return registry
+}
+
+// Register 注册事件类型
+func (r *TaskModeRegistry) Register(eventType string, factory TaskModeFactory) error {
+	if eventType == "" {
+		return errors.New("event type cannot be empty")
+	}
+	if factory == nil {
+		return errors.New("factory cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.factories[eventType]; exists {
+		return fmt.Errorf("event type %s already registered", eventType)
+	}
+
+	r.factories[eventType] = factory
+	return nil
+}
+
+// EventTypes 获取所有已注册的事件类型
+func (r *TaskModeRegistry) EventTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.factories))
+	for eventType := range r.factories {
+		types = append(types, eventType)
+	}
+	return types
+}
+
+// Decode 将事件信封解码为具体的事件DTO并校验
+func (r *TaskModeRegistry) Decode(envelope TriggerEventEnvelope) (TaskModeDTO, error) {
+	if envelope.EventType == "" {
+		return nil, errors.New("event_type is required")
+	}
+
+	r.mu.RLock()
+	factory, exists := r.factories[envelope.EventType]
+	r.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, envelope.EventType)
+	}
+
+	if len(envelope.Payload) == 0 {
+		return nil, errors.New("payload is required")
+	}
+
+	taskMode := factory()
+	if err := json.Unmarshal(envelope.Payload, taskMode); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	if v, ok := taskMode.(Validatable); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return taskMode, nil
+}
+
+// Schema 获取任务类型的表达式 schema
+// 多个事件类型对应同一任务类型时合并其参数与函数
+func (r *TaskModeRegistry) Schema(taskType valueobject.TaskType) (*ExpressionSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	params := make(map[string]bool)
+	types := make(map[string]valueobject.ValueType)
+	functions := make(map[string]bool)
+	found := false
+	for _, factory := range r.factories {
+		taskMode := factory()
+		if taskMode.GetTaskType() != taskType {
+			continue
+		}
+		found = true
+		for name, value := range taskMode.GetExpressionArguments() {
+			params[name] = true
+			// 同名参数在不同事件中类型不一致时不限定类型
+			valueType := valueobject.TypeOf(value)
+			if existing, ok := types[name]; ok && existing != valueType {
+				valueType = valueobject.ValueTypeAny
+			}
+			types[name] = valueType
+		}
+		for _, name := range taskMode.GetExpressionFunctions() {
+			functions[name] = true
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	return &ExpressionSchema{
+		TaskType:   taskType,
+		Parameters: sortedKeys(params),
+		Types:      types,
+		Functions:  sortedKeys(functions),
+	}, true
+}
+
+// Schemas 获取所有已注册任务类型的表达式 schema（按任务类型排序）
+func (r *TaskModeRegistry) Schemas() []*ExpressionSchema {
+	r.mu.RLock()
+	taskTypes := make(map[string]bool)
+	for _, factory := range r.factories {
+		taskTypes[string(factory().GetTaskType())] = true
+	}
+	r.mu.RUnlock()
+
+	schemas := make([]*ExpressionSchema, 0, len(taskTypes))
+	for _, taskType := range sortedKeys(taskTypes) {
+		if schema, ok := r.Schema(valueobject.TaskType(taskType)); ok {
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas
+}
+
+// sortedKeys 返回排序后的集合元素
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
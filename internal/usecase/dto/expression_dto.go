@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"mini-sirus/internal/domain/valueobject"
+)
+
+// DryRunExpressionInput 表达式试运行输入
+// 使用样例事件对表达式求值，不会创建或更新任何任务
+type DryRunExpressionInput struct {
+	Event        TriggerEventEnvelope `json:"event"`
+	TaskCondExpr string               `json:"task_cond_expr"`
+	ProgressExpr string               `json:"progress_expr,omitempty"`
+	Parameters   []TaskParameterDTO   `json:"parameters,omitempty"` // 任务参数，与样例事件参数合并后求值
+}
+
+// DryRunExpressionOutput 表达式试运行输出
+type DryRunExpressionOutput struct {
+	TaskType  valueobject.TaskType            `json:"task_type"`
+	Valid     bool                            `json:"valid"`           // 是否通过校验
+	Error     string                          `json:"error,omitempty"` // 校验或求值失败原因
+	Reach     bool                            `json:"reach"`           // 达成条件求值结果
+	Progress  float64                         `json:"progress"`        // 进度表达式求值结果，未配置时为 1
+	Schema    *ExpressionSchema               `json:"schema"`
+	Arguments valueobject.ExpressionArguments `json:"arguments"`
+}
+
+// QueryTraceInput 查询表达式求值轨迹输入
+type QueryTraceInput struct {
+	UserID int64 `json:"user_id"`
+	TaskID int64 `json:"task_id"` // 用户任务ID
+	Limit  int   `json:"limit"`   // 默认 20，最大 100
+}
+
+// ExpressionTraceOutput 表达式求值轨迹输出
+type ExpressionTraceOutput struct {
+	ID           int64                            `json:"id"`
+	UserID       int64                            `json:"user_id"`
+	ActivityID   int64                            `json:"activity_id"`
+	TaskID       int64                            `json:"task_id"`
+	TaskDefID    int64                            `json:"task_def_id"`
+	UniqueFlag   string                           `json:"unique_flag"`
+	Expr         string                           `json:"expr"`
+	Reach        bool                             `json:"reach"`
+	Error        string                           `json:"error,omitempty"`
+	FailedClause string                           `json:"failed_clause,omitempty"` // 第一个未通过的子表达式
+	Trace        *valueobject.ExpressionTraceNode `json:"trace"`
+	CreatedAt    string                           `json:"created_at"`
+}
+
+// FunctionParamOutput 表达式函数参数输出
+type FunctionParamOutput struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// FunctionOutput 表达式函数输出
+type FunctionOutput struct {
+	Name      string                 `json:"name"`
+	Signature string                 `json:"signature"`
+	Params    []FunctionParamOutput  `json:"params"`
+	Variadic  bool                   `json:"variadic"`
+	Returns   string                 `json:"returns"`
+	Doc       string                 `json:"doc"`
+	Example   string                 `json:"example"`
+	TaskTypes []valueobject.TaskType `json:"task_types"` // 可以使用该函数的任务类型
+}
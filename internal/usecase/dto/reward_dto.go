@@ -0,0 +1,39 @@
+package dto
+
+// WalletOutput 用户奖励余额输出
+type WalletOutput struct {
+	UserID    int64             `json:"user_id"`
+	Points    int64             `json:"points"`
+	Coupons   map[string]int    `json:"coupons"`
+	Badges    map[string]string `json:"badges"` // 勋章编码 -> 首次获得时间
+	UpdatedAt string            `json:"updated_at,omitempty"`
+}
+
+// RewardLedgerOutput 奖励流水输出
+type RewardLedgerOutput struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	ActivityID int64  `json:"activity_id"`
+	UserTaskID int64  `json:"user_task_id"`
+	TaskDefID  int64  `json:"task_def_id"`
+	DetailID   int64  `json:"detail_id"`
+	RewardID   int64  `json:"reward_id"`
+	RewardType string `json:"reward_type"`
+	Amount     int    `json:"amount"`
+	ItemCode   string `json:"item_code,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// BudgetOutput 奖励预算输出
+// 上限为 0 表示不限制，此时剩余量为 -1
+type BudgetOutput struct {
+	Scope                string `json:"scope"` // activity 或 task
+	ScopeID              int64  `json:"scope_id"`
+	MaxRewardUnits       int64  `json:"max_reward_units"`
+	UsedRewardUnits      int64  `json:"used_reward_units"`
+	RemainingRewardUnits int64  `json:"remaining_reward_units"`
+	MaxCompleters        int64  `json:"max_completers"`
+	Completers           int64  `json:"completers"`
+	RemainingCompleters  int64  `json:"remaining_completers"`
+	Exhausted            bool   `json:"exhausted"`
+}
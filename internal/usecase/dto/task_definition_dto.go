@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"mini-sirus/internal/domain/valueobject"
+	"time"
+)
+
+// TaskParameterDTO 任务参数
+// type 为 number/string/bool/id_list，value 为对应类型的 JSON 值
+type TaskParameterDTO struct {
+	Name  string                        `json:"name"`
+	Type  valueobject.TaskParameterType `json:"type"`
+	Value interface{}                   `json:"value"`
+}
+
+// TaskDefinitionInput 创建/更新任务定义输入
+// 更新时需指定 ID，任务类型、计数方式和周期创建后不可修改
+type TaskDefinitionInput struct {
+	ID               int64                `json:"id,omitempty"`
+	ActivityID       int64                `json:"activity_id"`
+	Name             string               `json:"name"`
+	TaskType         valueobject.TaskType `json:"task_type"`
+	TaskCondExpr     string               `json:"task_cond_expr"`
+	ProgressExpr     string               `json:"progress_expr,omitempty"`
+	Parameters       []TaskParameterDTO   `json:"parameters,omitempty"`
+	Target           int                  `json:"target"`
+	RewardValue      int                  `json:"reward_value"`
+	CreateMode       string               `json:"create_mode,omitempty"` // pre_create 或 lazy
+	Recurrence       string               `json:"recurrence,omitempty"`  // none/daily/weekly/monthly
+	Timezone         string               `json:"timezone,omitempty"`
+	Kind             string               `json:"kind,omitempty"` // count 或 streak
+	GraceCards       int                  `json:"grace_cards,omitempty"`
+	Prerequisites    []int64              `json:"prerequisites,omitempty"`
+	PrerequisiteMode string               `json:"prerequisite_mode,omitempty"` // all_of 或 any_of
+	MaxRewardUnits   int64                `json:"max_reward_units,omitempty"`
+	MaxCompleters    int64                `json:"max_completers,omitempty"`
+	StartTime        time.Time            `json:"start_time,omitempty"`
+	EndTime          time.Time            `json:"end_time,omitempty"`
+}
+
+// TaskDefinitionOutput 任务定义输出
+type TaskDefinitionOutput struct {
+	ID               int64                `json:"id"`
+	ActivityID       int64                `json:"activity_id"`
+	Name             string               `json:"name"`
+	TaskType         valueobject.TaskType `json:"task_type"`
+	TaskCondExpr     string               `json:"task_cond_expr"`
+	ProgressExpr     string               `json:"progress_expr,omitempty"`
+	Parameters       []TaskParameterDTO   `json:"parameters,omitempty"`
+	Target           int                  `json:"target"`
+	RewardValue      int                  `json:"reward_value"`
+	CreateMode       string               `json:"create_mode"`
+	Recurrence       string               `json:"recurrence"`
+	Kind             string               `json:"kind"`
+	Prerequisites    []int64              `json:"prerequisites,omitempty"`
+	PrerequisiteMode string               `json:"prerequisite_mode,omitempty"`
+	MaxRewardUnits   int64                `json:"max_reward_units"`
+	MaxCompleters    int64                `json:"max_completers"`
+	StartTime        string               `json:"start_time,omitempty"`
+	EndTime          string               `json:"end_time,omitempty"`
+	Archived         bool                 `json:"archived"`
+	CreatedAt        string               `json:"created_at"`
+	UpdatedAt        string               `json:"updated_at"`
+}
@@ -10,13 +10,11 @@ type TriggerTaskInput struct {
 }
 
 // CreateTaskInput 创建任务输入
+// TaskID 为任务定义ID，目标与条件表达式从任务定义中获取
 type CreateTaskInput struct {
-	ActivityID   int64
-	TaskID       int64
-	UserID       int64
-	Target       int
-	TaskType     valueobject.TaskType
-	TaskCondExpr string
+	ActivityID int64
+	TaskID     int64
+	UserID     int64
 }
 
 // QueryTaskInput 查询任务输入
@@ -28,27 +26,44 @@ type QueryTaskInput struct {
 
 // TaskOutput 任务输出
 type TaskOutput struct {
-	ID           int64                 `json:"id"`
-	ActivityID   int64                 `json:"activity_id"`
-	TaskID       int64                 `json:"task_id"`
-	UserID       int64                 `json:"user_id"`
-	TaskType     valueobject.TaskType  `json:"task_type"`
-	Status       string                `json:"status"`
-	Progress     int                   `json:"progress"`
-	Target       int                   `json:"target"`
-	TaskCondExpr string                `json:"task_cond_expr"`
-	CreatedAt    string                `json:"created_at"`
-	UpdatedAt    string                `json:"updated_at"`
+	ID             int64                `json:"id"`
+	ActivityID     int64                `json:"activity_id"`
+	TaskID         int64                `json:"task_id"`
+	UserID         int64                `json:"user_id"`
+	TaskType       valueobject.TaskType `json:"task_type"`
+	Status         string               `json:"status"`
+	Progress       int                  `json:"progress"`
+	Target         int                  `json:"target"`
+	TaskCondExpr   string               `json:"task_cond_expr"`
+	Recurrence     string               `json:"recurrence"`
+	PeriodKey      string               `json:"period_key,omitempty"` // 当前周期，不重复任务为空
+	Kind           string               `json:"kind"`
+	CurrentStreak  int                  `json:"current_streak,omitempty"`   // 当前连续天数，仅连续打卡任务
+	BestStreak     int                  `json:"best_streak,omitempty"`      // 历史最长连续天数，仅连续打卡任务
+	GraceCardsLeft int                  `json:"grace_cards_left,omitempty"` // 剩余补签卡，仅连续打卡任务
+	Prerequisites  []int64              `json:"prerequisites,omitempty"`    // 前置任务定义ID
+	CreatedAt      string               `json:"created_at"`
+	UpdatedAt      string               `json:"updated_at"`
+}
+
+// TaskPeriodOutput 任务历史周期进度输出
+type TaskPeriodOutput struct {
+	TaskID    int64  `json:"task_id"`
+	UserID    int64  `json:"user_id"`
+	PeriodKey string `json:"period_key"`
+	Status    string `json:"status"`
+	Progress  int    `json:"progress"`
+	Target    int    `json:"target"`
 }
 
 // TaskDetailOutput 任务明细输出
 type TaskDetailOutput struct {
-	ID          int64  `json:"id"`
-	TaskID      int64  `json:"task_id"`
-	UserID      int64  `json:"user_id"`
-	Status      string `json:"status"`
-	UniqueFlag  string `json:"unique_flag"`
-	RewardValue int    `json:"reward_value"`
-	CreatedAt   string `json:"created_at"`
+	ID            int64  `json:"id"`
+	TaskID        int64  `json:"task_id"`
+	UserID        int64  `json:"user_id"`
+	Status        string `json:"status"`
+	UniqueFlag    string `json:"unique_flag"`
+	RewardValue   int    `json:"reward_value"`
+	ProgressDelta int    `json:"progress_delta"`
+	CreatedAt     string `json:"created_at"`
 }
-
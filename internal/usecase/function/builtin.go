@@ -0,0 +1,159 @@
+package function
+
+// builtinDefinitions 内置表达式函数，时间类函数通过 clock 获取当前时间
+func builtinDefinitions(clock Clock) []*Definition {
+	return []*Definition{
+		{
+			Name:    "WITH_ANY_TOPIC",
+			Params:  []Param{{Name: "tag_ids", Type: TypeIDList}, {Name: "required_tag_ids", Type: TypeIDList}},
+			Returns: TypeBool,
+			Doc:     "内容携带的话题中包含任意一个要求的话题",
+			Example: "WITH_ANY_TOPIC(tag_ids, required_tag_ids)",
+			Impl:    withAnyTopic,
+		},
+		{
+			Name:    "LIKE_COUNT_GTE",
+			Params:  []Param{{Name: "like_count", Type: TypeNumber}, {Name: "min", Type: TypeNumber}},
+			Returns: TypeBool,
+			Doc:     "点赞数大于等于指定值",
+			Example: "LIKE_COUNT_GTE(like_count, 10)",
+			Impl:    numberGte,
+		},
+		{
+			Name:    "IS_AUDITED",
+			Params:  []Param{{Name: "is_audited", Type: TypeBool}},
+			Returns: TypeBool,
+			Doc:     "内容已审核通过",
+			Example: "IS_AUDITED(is_audited)",
+			Impl:    isAudited,
+		},
+		{
+			Name:    "IS_TODAY",
+			Params:  []Param{{Name: "date", Type: TypeString}, {Name: "tz", Type: TypeString}},
+			Returns: TypeBool,
+			Doc:     "日期（YYYY-MM-DD）是指定时区的今天，时区为空时使用服务器时区",
+			Example: "IS_TODAY(date, timezone)",
+			Impl:    isToday(clock),
+		},
+		{
+			Name:    "WITHIN_ACTIVITY",
+			Returns: TypeBool,
+			Doc:     "当前时间在任务所属活动的时间窗口内",
+			Example: "WITHIN_ACTIVITY()",
+			Bind:    withinActivity(clock),
+		},
+		{
+			Name:    "HOUR_BETWEEN",
+			Params:  []Param{{Name: "start", Type: TypeNumber}, {Name: "end", Type: TypeNumber}, {Name: "tz", Type: TypeString}},
+			Returns: TypeBool,
+			Doc:     "当前小时在 [start, end) 内，start 大于 end 时跨零点（如 22 点到次日 6 点）",
+			Example: "HOUR_BETWEEN(8, 22, timezone)",
+			Impl:    hourBetween(clock),
+		},
+		{
+			Name:     "WEEKDAY_IN",
+			Params:   []Param{{Name: "tz", Type: TypeString}, {Name: "weekdays", Type: TypeNumber}},
+			Variadic: true,
+			Returns:  TypeBool,
+			Doc:      "今天是指定的星期几之一，1-7 表示周一到周日",
+			Example:  "WEEKDAY_IN(timezone, 6, 7)",
+			Impl:     weekdayIn(clock),
+		},
+		{
+			Name:    "DAYS_SINCE",
+			Params:  []Param{{Name: "date", Type: TypeString}, {Name: "tz", Type: TypeString}},
+			Returns: TypeNumber,
+			Doc:     "日期（YYYY-MM-DD）距今天的天数，日期在未来时为负数",
+			Example: "DAYS_SINCE(date, timezone) <= 1",
+			Impl:    daysSince(clock),
+		},
+		{
+			Name:     "CHANNEL_IN",
+			Params:   []Param{{Name: "channel", Type: TypeString}, {Name: "expected", Type: TypeString}},
+			Variadic: true,
+			Returns:  TypeBool,
+			Doc:      "分享渠道在指定渠道中",
+			Example:  "CHANNEL_IN(channel, 'wechat', 'weibo')",
+			Impl:     stringIn,
+		},
+		{
+			Name:     "AUTHOR_IN",
+			Params:   []Param{{Name: "author_id", Type: TypeNumber}, {Name: "expected", Type: TypeNumber}},
+			Variadic: true,
+			Returns:  TypeBool,
+			Doc:      "目标内容作者在指定作者中（如官方账号）",
+			Example:  "AUTHOR_IN(author_id, 10001, 10002)",
+			Impl:     numberIn,
+		},
+		{
+			Name:    "IS_OTHERS_CONTENT",
+			Params:  []Param{{Name: "user_id", Type: TypeNumber}, {Name: "author_id", Type: TypeNumber}},
+			Returns: TypeBool,
+			Doc:     "目标内容为他人内容（排除给自己点赞、评论等刷量行为）",
+			Example: "IS_OTHERS_CONTENT(user_id, author_id)",
+			Impl:    isOthersContent,
+		},
+		{
+			Name:    "LENGTH_GTE",
+			Params:  []Param{{Name: "length", Type: TypeNumber}, {Name: "min", Type: TypeNumber}},
+			Returns: TypeBool,
+			Doc:     "长度大于等于指定值（如评论字数）",
+			Example: "LENGTH_GTE(comment_length, 10)",
+			Impl:    numberGte,
+		},
+	}
+}
+
+// withAnyTopic 判断是否包含任意话题
+func withAnyTopic(args ...interface{}) (interface{}, error) {
+	carryIDs := args[0].([]uint64)
+	condIDs := args[1].([]uint64)
+
+	for _, cid := range carryIDs {
+		for _, tid := range condIDs {
+			if cid == tid {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// numberGte 判断数值是否达标
+func numberGte(args ...interface{}) (interface{}, error) {
+	return args[0].(float64) >= args[1].(float64), nil
+}
+
+// isAudited 判断是否已审核通过
+func isAudited(args ...interface{}) (interface{}, error) {
+	return args[0].(bool), nil
+}
+
+// stringIn 判断字符串是否在候选值中
+func stringIn(args ...interface{}) (interface{}, error) {
+	value := args[0].(string)
+	for _, arg := range args[1:] {
+		if value == arg.(string) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// numberIn 判断数值是否在候选值中
+func numberIn(args ...interface{}) (interface{}, error) {
+	value := args[0].(float64)
+	for _, arg := range args[1:] {
+		if value == arg.(float64) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isOthersContent 判断目标内容是否为他人内容
+func isOthersContent(args ...interface{}) (interface{}, error) {
+	userID := args[0].(float64)
+	authorID := args[1].(float64)
+	return authorID > 0 && userID != authorID, nil
+}
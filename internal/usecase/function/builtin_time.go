@@ -0,0 +1,132 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// locations 已加载的时区，避免每次求值都读取时区数据
+var locations sync.Map
+
+// loadLocation 加载时区，为空时使用服务器时区
+func loadLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.Local, nil
+	}
+	if loc, ok := locations.Load(tz); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", tz)
+	}
+	locations.Store(tz, loc)
+	return loc, nil
+}
+
+// daysBetween 计算时区 loc 下日期 date 到时间点 now 所在日期的天数
+func daysBetween(date string, now time.Time, loc *time.Location) (int, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return 0, fmt.Errorf("date %q must be in YYYY-MM-DD format", date)
+	}
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	// 夏令时切换日不足或超过 24 小时，按四舍五入取整
+	return int(math.Round(today.Sub(day).Hours() / 24)), nil
+}
+
+// isToday 判断日期是否为今天
+func isToday(clock Clock) Impl {
+	return func(args ...interface{}) (interface{}, error) {
+		loc, err := loadLocation(args[1].(string))
+		if err != nil {
+			return nil, err
+		}
+		days, err := daysBetween(args[0].(string), clock.Now(), loc)
+		if err != nil {
+			return nil, err
+		}
+		return days == 0, nil
+	}
+}
+
+// withinActivity 判断当前时间是否在活动时间窗口内
+// 与活动的 IsActive 一致，开始和结束时刻都不在窗口内
+func withinActivity(clock Clock) Binder {
+	return func(env Env) Impl {
+		return func(args ...interface{}) (interface{}, error) {
+			if !env.HasActivity() {
+				return nil, errors.New("task has no activity time window")
+			}
+			now := clock.Now()
+			return now.After(env.ActivityStart) && now.Before(env.ActivityEnd), nil
+		}
+	}
+}
+
+// hourBetween 判断当前小时是否在区间内
+func hourBetween(clock Clock) Impl {
+	return func(args ...interface{}) (interface{}, error) {
+		start, end := args[0].(float64), args[1].(float64)
+		if start < 0 || start > 24 || end < 0 || end > 24 {
+			return nil, fmt.Errorf("hour range [%v, %v) out of 0-24", start, end)
+		}
+		loc, err := loadLocation(args[2].(string))
+		if err != nil {
+			return nil, err
+		}
+
+		hour := float64(clock.Now().In(loc).Hour())
+		if start <= end {
+			return hour >= start && hour < end, nil
+		}
+		// 跨零点
+		return hour >= start || hour < end, nil
+	}
+}
+
+// weekdayIn 判断今天是否为指定的星期几
+func weekdayIn(clock Clock) Impl {
+	return func(args ...interface{}) (interface{}, error) {
+		loc, err := loadLocation(args[0].(string))
+		if err != nil {
+			return nil, err
+		}
+
+		weekday := clock.Now().In(loc).Weekday()
+		// time.Weekday 中周日为 0，转换为 1-7 表示周一到周日
+		today := float64(weekday)
+		if weekday == time.Sunday {
+			today = 7
+		}
+		for _, arg := range args[1:] {
+			expected := arg.(float64)
+			if expected < 1 || expected > 7 {
+				return nil, fmt.Errorf("weekday %v out of 1-7", expected)
+			}
+			if expected == today {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// daysSince 计算日期距今天的天数
+func daysSince(clock Clock) Impl {
+	return func(args ...interface{}) (interface{}, error) {
+		loc, err := loadLocation(args[1].(string))
+		if err != nil {
+			return nil, err
+		}
+		days, err := daysBetween(args[0].(string), clock.Now(), loc)
+		if err != nil {
+			return nil, err
+		}
+		return float64(days), nil
+	}
+}
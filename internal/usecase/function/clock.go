@@ -0,0 +1,78 @@
+package function
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Clock 时钟，时间类表达式函数通过时钟获取当前时间，便于测试控制时间
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock 系统时钟
+type SystemClock struct{}
+
+// Now 返回当前系统时间
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock 固定时钟，时间只在 Set/Advance 时变化
+type FixedClock struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewFixedClock 创建固定时钟
+func NewFixedClock(now time.Time) *FixedClock {
+	return &FixedClock{now: now}
+}
+
+// Now 返回时钟当前时间
+func (c *FixedClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.now
+}
+
+// Set 设置时钟时间
+func (c *FixedClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance 拨快时钟
+func (c *FixedClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Env 表达式求值环境，由触发方按任务所属活动提供
+type Env struct {
+	ActivityID    int64
+	ActivityStart time.Time
+	ActivityEnd   time.Time
+}
+
+// HasActivity 判断求值环境是否包含活动时间窗口
+func (e Env) HasActivity() bool {
+	return !e.ActivityStart.IsZero() && !e.ActivityEnd.IsZero()
+}
+
+// key 求值环境标识，用于缓存按环境生成的函数集
+// 按活动ID区分，活动时间窗口调整后替换原有的函数集，缓存数量不超过活动数量
+func (e Env) key() string {
+	if !e.HasActivity() {
+		return ""
+	}
+	return fmt.Sprintf("activity:%d", e.ActivityID)
+}
+
+// sameWindow 判断两个求值环境的活动时间窗口是否相同
+func (e Env) sameWindow(other Env) bool {
+	return e.ActivityStart.Equal(other.ActivityStart) && e.ActivityEnd.Equal(other.ActivityEnd)
+}
@@ -0,0 +1,139 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/valueobject"
+	"strings"
+)
+
+// ErrArgumentType 表达式函数参数类型或个数不匹配
+var ErrArgumentType = errors.New("function argument mismatch")
+
+// Type 表达式值类型
+type Type = valueobject.ValueType
+
+const (
+	TypeNumber = valueobject.ValueTypeNumber // 数值（float64）
+	TypeString = valueobject.ValueTypeString // 字符串
+	TypeBool   = valueobject.ValueTypeBool   // 布尔值
+	TypeIDList = valueobject.ValueTypeIDList // ID 列表，如话题ID列表
+	TypeAny    = valueobject.ValueTypeAny    // 任意类型
+)
+
+// Param 函数参数声明
+type Param struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+}
+
+// Impl 表达式函数实现，参数个数和类型已按签名校验
+type Impl func(args ...interface{}) (interface{}, error)
+
+// Binder 按求值环境生成函数实现
+type Binder func(env Env) Impl
+
+// Definition 表达式函数定义
+type Definition struct {
+	Name     string  `json:"name"`
+	Params   []Param `json:"params"`
+	Variadic bool    `json:"variadic"` // 最后一个参数可重复出现
+	Returns  Type    `json:"returns"`
+	Doc      string  `json:"doc"`
+	Example  string  `json:"example"`
+	Impl     Impl    `json:"-"`
+	Bind     Binder  `json:"-"` // 依赖求值环境的函数（如 WITHIN_ACTIVITY）按环境生成实现，与 Impl 二选一
+}
+
+// IsValid 验证函数定义是否有效
+func (d *Definition) IsValid() bool {
+	if d.Name == "" || (d.Impl == nil) == (d.Bind == nil) || d.Returns == "" {
+		return false
+	}
+	if d.Variadic && len(d.Params) == 0 {
+		return false
+	}
+	return true
+}
+
+// Signature 返回函数签名，如 CHANNEL_IN(channel string, expected ...string) bool
+func (d *Definition) Signature() string {
+	params := make([]string, 0, len(d.Params))
+	for i, param := range d.Params {
+		if d.Variadic && i == len(d.Params)-1 {
+			params = append(params, fmt.Sprintf("%s ...%s", param.Name, param.Type))
+			continue
+		}
+		params = append(params, fmt.Sprintf("%s %s", param.Name, param.Type))
+	}
+	return fmt.Sprintf("%s(%s) %s", d.Name, strings.Join(params, ", "), d.Returns)
+}
+
+// CheckArgs 按签名校验实参个数和类型
+func (d *Definition) CheckArgs(args []interface{}) error {
+	switch {
+	case d.Variadic && len(args) < len(d.Params):
+		return fmt.Errorf("%w: %s requires at least %d arguments, got %d", ErrArgumentType, d.Name, len(d.Params), len(args))
+	case !d.Variadic && len(args) != len(d.Params):
+		return fmt.Errorf("%w: %s requires %d arguments, got %d", ErrArgumentType, d.Name, len(d.Params), len(args))
+	}
+
+	for i, arg := range args {
+		param := d.Params[min(i, len(d.Params)-1)]
+		if !param.Type.Accepts(arg) {
+			return fmt.Errorf("%w: %s argument %d (%s) must be %s, got %T", ErrArgumentType, d.Name, i+1, param.Name, param.Type, arg)
+		}
+	}
+	return nil
+}
+
+// IsBound 判断函数是否依赖求值环境
+func (d *Definition) IsBound() bool {
+	return d.Bind != nil
+}
+
+// Call 校验实参后调用函数，并校验返回值类型
+// 依赖求值环境的函数使用空环境调用
+func (d *Definition) Call(args ...interface{}) (interface{}, error) {
+	return d.callWith(d.implFor(Env{}), args)
+}
+
+// implFor 获取函数在指定求值环境下的实现
+func (d *Definition) implFor(env Env) Impl {
+	if d.Bind != nil {
+		return d.Bind(env)
+	}
+	return d.Impl
+}
+
+// callWith 校验实参后调用指定实现，并校验返回值类型
+func (d *Definition) callWith(impl Impl, args []interface{}) (interface{}, error) {
+	if err := d.CheckArgs(args); err != nil {
+		return nil, err
+	}
+
+	result, err := impl(args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", d.Name, err)
+	}
+	if !d.Returns.Accepts(result) {
+		return nil, fmt.Errorf("%s must return %s, got %T", d.Name, d.Returns, result)
+	}
+	return result, nil
+}
+
+// expressionFunction 转换为规则引擎使用的函数，impl 为函数在求值环境下的实现
+func (d *Definition) expressionFunction(impl Impl) *valueobject.ExpressionFunction {
+	params := make([]valueobject.ValueType, 0, len(d.Params))
+	for _, param := range d.Params {
+		params = append(params, param.Type)
+	}
+	return &valueobject.ExpressionFunction{
+		Params:   params,
+		Variadic: d.Variadic,
+		Returns:  d.Returns,
+		Call: func(args ...interface{}) (interface{}, error) {
+			return d.callWith(impl, args)
+		},
+	}
+}
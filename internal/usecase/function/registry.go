@@ -0,0 +1,150 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/valueobject"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry 表达式函数注册表
+// 按事件类型声明的函数名（TaskModeDTO.GetExpressionFunctions）生成作用域内的函数集
+type Registry struct {
+	mu          sync.RWMutex
+	clock       Clock
+	definitions map[string]*Definition
+	scoped      map[string]scopedFunctions // 按函数名集合（及活动）缓存的函数集
+}
+
+// scopedFunctions 缓存的函数集及生成时的求值环境
+type scopedFunctions struct {
+	env       Env
+	functions valueobject.ExpressionFunctions
+}
+
+// NewRegistry 创建空的函数注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		clock:       SystemClock{},
+		definitions: make(map[string]*Definition),
+		scoped:      make(map[string]scopedFunctions),
+	}
+}
+
+// NewBuiltinRegistry 创建注册了内置函数的注册表，时间类函数使用系统时钟
+func NewBuiltinRegistry() *Registry {
+	return NewBuiltinRegistryWithClock(SystemClock{})
+}
+
+// NewBuiltinRegistryWithClock 创建注册了内置函数的注册表，时间类函数使用指定时钟
+func NewBuiltinRegistryWithClock(clock Clock) *Registry {
+	registry := NewRegistry()
+	registry.clock = clock
+	for _, def := range builtinDefinitions(clock) {
+		_ = registry.Register(def)
+	}
+	return registry
+}
+
+// Clock 获取时间类函数使用的时钟
+func (r *Registry) Clock() Clock {
+	return r.clock
+}
+
+// Register 注册函数，同名函数不可重复注册
+func (r *Registry) Register(def *Definition) error {
+	if def == nil || !def.IsValid() {
+		return errors.New("invalid function definition")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.definitions[def.Name]; exists {
+		return fmt.Errorf("function %s already registered", def.Name)
+	}
+
+	r.definitions[def.Name] = def
+	// 已生成的函数集可能缺少新函数，全部重建
+	r.scoped = make(map[string]scopedFunctions)
+	return nil
+}
+
+// Get 获取函数定义
+func (r *Registry) Get(name string) (*Definition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, ok := r.definitions[name]
+	return def, ok
+}
+
+// List 获取所有函数定义（按函数名排序）
+func (r *Registry) List() []*Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make([]*Definition, 0, len(r.definitions))
+	for _, def := range r.definitions {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Name < defs[j].Name
+	})
+	return defs
+}
+
+// Functions 获取指定函数名的函数集，函数调用前按签名校验实参，未注册的函数名被忽略
+// 相同函数名集合返回同一个 map，使规则引擎的编译缓存可以命中，调用方不应修改返回值。
+// 依赖求值环境的函数使用空环境（如 WITHIN_ACTIVITY 调用时报错），适用于校验和试算
+func (r *Registry) Functions(names []string) valueobject.ExpressionFunctions {
+	return r.FunctionsWithEnv(names, Env{})
+}
+
+// FunctionsWithEnv 获取指定函数名在求值环境下的函数集
+// 函数集包含依赖环境的函数时按活动分别缓存（每个活动一份，时间窗口调整后重建），否则与 Functions 共用
+func (r *Registry) FunctionsWithEnv(names []string, env Env) valueobject.ExpressionFunctions {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	key := strings.Join(sorted, ",")
+
+	r.mu.RLock()
+	if envKey := env.key(); envKey != "" && r.anyBound(sorted) {
+		key += "@" + envKey
+	} else {
+		// 不依赖环境的函数集与 Functions 共用
+		env = Env{}
+	}
+	cached, ok := r.scoped[key]
+	r.mu.RUnlock()
+	if ok && cached.env.sameWindow(env) {
+		return cached.functions
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.scoped[key]; ok && cached.env.sameWindow(env) {
+		return cached.functions
+	}
+	functions := make(valueobject.ExpressionFunctions, len(sorted))
+	for _, name := range sorted {
+		if def, ok := r.definitions[name]; ok {
+			functions[name] = def.expressionFunction(def.implFor(env))
+		}
+	}
+	r.scoped[key] = scopedFunctions{env: env, functions: functions}
+	return functions
+}
+
+// anyBound 判断函数名中是否有依赖求值环境的函数，调用方需持有锁
+func (r *Registry) anyBound(names []string) bool {
+	for _, name := range names {
+		if def, ok := r.definitions[name]; ok && def.IsBound() {
+			return true
+		}
+	}
+	return false
+}
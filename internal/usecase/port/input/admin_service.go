@@ -0,0 +1,61 @@
+package input
+
+import (
+	"context"
+	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/usecase/dto"
+)
+
+// AdminService 运营管理服务输入端口
+type AdminService interface {
+	// CreateActivity 创建活动
+	CreateActivity(ctx context.Context, input dto.CreateActivityInput) (*dto.ActivityOutput, error)
+
+	// UpdateActivity 更新活动
+	UpdateActivity(ctx context.Context, input dto.UpdateActivityInput) (*dto.ActivityOutput, error)
+
+	// GetActivity 查询单个活动
+	GetActivity(ctx context.Context, activityID int64) (*dto.ActivityOutput, error)
+
+	// ListActivities 查询活动列表
+	ListActivities(ctx context.Context, includeArchived bool) ([]*dto.ActivityOutput, error)
+
+	// PublishActivity 发布活动
+	PublishActivity(ctx context.Context, activityID int64) (*dto.ActivityOutput, error)
+
+	// PauseActivity 暂停活动
+	PauseActivity(ctx context.Context, activityID int64) (*dto.ActivityOutput, error)
+
+	// EndActivity 提前结束活动
+	EndActivity(ctx context.Context, activityID int64) (*dto.ActivityOutput, error)
+
+	// ArchiveActivity 归档活动
+	ArchiveActivity(ctx context.Context, activityID int64) (*dto.ActivityOutput, error)
+
+	// ListParticipants 分页查询活动参与用户
+	ListParticipants(ctx context.Context, input dto.ListParticipantsInput) (*dto.ParticipantPageOutput, error)
+
+	// QueryActivityStats 查询活动完成统计
+	QueryActivityStats(ctx context.Context, activityID int64) (*dto.ActivityStatsOutput, error)
+
+	// CreateTaskDefinition 创建任务定义
+	CreateTaskDefinition(ctx context.Context, input dto.TaskDefinitionInput) (*dto.TaskDefinitionOutput, error)
+
+	// UpdateTaskDefinition 更新任务定义
+	UpdateTaskDefinition(ctx context.Context, input dto.TaskDefinitionInput) (*dto.TaskDefinitionOutput, error)
+
+	// ListTaskDefinitions 查询活动下的任务定义
+	ListTaskDefinitions(ctx context.Context, activityID int64, includeArchived bool) ([]*dto.TaskDefinitionOutput, error)
+
+	// ArchiveTaskDefinition 归档任务定义
+	ArchiveTaskDefinition(ctx context.Context, taskDefID int64) (*dto.TaskDefinitionOutput, error)
+
+	// DryRunExpression 使用样例事件试运行任务表达式
+	DryRunExpression(ctx context.Context, input dto.DryRunExpressionInput) (*dto.DryRunExpressionOutput, error)
+
+	// QueryExpressionTraces 查询任务达成条件的求值轨迹
+	QueryExpressionTraces(ctx context.Context, input dto.QueryTraceInput) ([]*dto.ExpressionTraceOutput, error)
+
+	// ListExpressionFunctions 查询表达式函数，taskType 为空时返回全部
+	ListExpressionFunctions(ctx context.Context, taskType valueobject.TaskType) ([]*dto.FunctionOutput, error)
+}
@@ -0,0 +1,21 @@
+package input
+
+import (
+	"context"
+	"mini-sirus/internal/usecase/dto"
+)
+
+// RewardService 奖励服务输入端口
+type RewardService interface {
+	// QueryWallet 查询用户奖励余额
+	QueryWallet(ctx context.Context, userID int64) (*dto.WalletOutput, error)
+
+	// QueryLedger 查询用户奖励流水
+	QueryLedger(ctx context.Context, userID int64) ([]*dto.RewardLedgerOutput, error)
+
+	// QueryActivityBudget 查询活动的剩余奖励预算
+	QueryActivityBudget(ctx context.Context, activityID int64) (*dto.BudgetOutput, error)
+
+	// QueryTaskBudget 查询任务定义的剩余奖励预算
+	QueryTaskBudget(ctx context.Context, taskDefID int64) (*dto.BudgetOutput, error)
+}
@@ -20,5 +20,8 @@ type TaskService interface {
 
 	// QueryTasksByUser 查询用户的任务列表
 	QueryTasksByUser(ctx context.Context, userID int64) ([]*dto.TaskOutput, error)
+
+	// QueryTaskHistory 查询周期任务的历史周期进度
+	QueryTaskHistory(ctx context.Context, taskID int64) ([]*dto.TaskPeriodOutput, error)
 }
 
@@ -0,0 +1,27 @@
+package output
+
+import (
+	"context"
+	"mini-sirus/internal/domain/event"
+)
+
+// ActivityObserver 活动观察者输出端口
+type ActivityObserver interface {
+	// OnActivityStatusChanged 当活动状态变更时
+	OnActivityStatusChanged(ctx context.Context, changed *event.ActivityStatusChanged) error
+
+	// GetObserverName 获取观察者名称（用于标识）
+	GetObserverName() string
+}
+
+// ActivityObserverRegistry 活动观察者注册表
+type ActivityObserverRegistry interface {
+	// Register 注册观察者
+	Register(observer ActivityObserver)
+
+	// Unregister 注销观察者
+	Unregister(observerName string)
+
+	// NotifyStatusChanged 通知所有观察者活动状态变更
+	NotifyStatusChanged(ctx context.Context, changed *event.ActivityStatusChanged) error
+}
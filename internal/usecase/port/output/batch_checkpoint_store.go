@@ -0,0 +1,40 @@
+package output
+
+import (
+	"context"
+	"time"
+)
+
+// BatchCheckpointStore 批量任务断点存储输出端口
+// 用于记录批量预创建任务的执行进度，支持中断后续跑
+type BatchCheckpointStore interface {
+	// Load 加载断点，不存在时返回 nil
+	Load(ctx context.Context, jobID string) (*BatchCheckpoint, error)
+
+	// Save 保存断点
+	Save(ctx context.Context, checkpoint *BatchCheckpoint) error
+}
+
+// BatchCheckpoint 批量任务断点
+// Offset 之前的用户均已处理完成，续跑时从 Offset 开始，并跳过 Completed 中的批次
+type BatchCheckpoint struct {
+	JobID      string
+	ActivityID int64
+	Status     string
+	Total      int64        // 用户总数，未知时为 -1
+	Offset     int64        // 已连续处理完成的用户数
+	Created    int64        // 已创建的任务数（不含 Completed）
+	Skipped    int64        // 已存在而跳过的任务数（不含 Completed）
+	Completed  []BatchChunk // Offset 之后已乱序完成的批次，按 Offset 升序
+	LastError  string
+	StartedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// BatchChunk 已处理完成的一批用户
+type BatchChunk struct {
+	Offset  int64 // 批次第一个用户的位置
+	Size    int64
+	Created int64
+	Skipped int64
+}
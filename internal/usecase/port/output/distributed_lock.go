@@ -1,22 +1,39 @@
 package output
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLockNotHeld 锁不存在、已过期或已被其他持有者获取
+var ErrLockNotHeld = errors.New("lock not held")
+
+// Lease 锁租约，加锁成功时返回
+type Lease struct {
+	Key       string
+	ID        string    // 持有者标识（随机生成），解锁和续期时校验
+	Token     uint64    // 隔离令牌（fencing token），同一个 key 每次加锁单调递增
+	ExpiresAt time.Time // 过期时间，续期后更新
+}
 
 // DistributedLock 分布式锁输出端口
 // 定义分布式锁的抽象接口，具体实现在 infrastructure 层
+// 锁在 ttl 后自动过期，持有者崩溃不会永久占用锁；锁过期后原持有者的写入应通过 Token 拒绝
 type DistributedLock interface {
-	// Lock 加锁
+	// Lock 加锁，锁被占用时阻塞等待，直到加锁成功或 ctx 取消
 	// key: 锁的键
-	// ttl: 锁的过期时间（秒）
-	// 返回: 锁的标识和错误
-	Lock(ctx context.Context, key string, ttl int) (string, error)
+	// ttl: 锁的过期时间
+	Lock(ctx context.Context, key string, ttl time.Duration) (*Lease, error)
 
-	// Unlock 解锁
-	// key: 锁的键
-	// lockID: 锁的标识
-	Unlock(ctx context.Context, key string, lockID string) error
+	// TryLock 尝试加锁（非阻塞），锁被占用时返回 false
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, *Lease, error)
 
-	// TryLock 尝试加锁（非阻塞）
-	TryLock(ctx context.Context, key string, ttl int) (bool, string, error)
-}
+	// Renew 续期，将锁的过期时间重置为 ttl 之后
+	// 锁已过期或已被其他持有者获取时返回 ErrLockNotHeld
+	Renew(ctx context.Context, lease *Lease, ttl time.Duration) error
 
+	// Unlock 解锁
+	// 锁已过期或已被其他持有者获取时返回 ErrLockNotHeld
+	Unlock(ctx context.Context, lease *Lease) error
+}
@@ -12,7 +12,7 @@ type NotificationService interface {
 	SendTaskCompletedNotification(ctx context.Context, userID int64, taskDetail *entity.ActUserTaskDetail) error
 
 	// SendTaskProgressNotification 发送任务进度通知
-	SendTaskProgressNotification(ctx context.Context, userID int64, task *entity.ActUserTask) error
+	SendTaskProgressNotification(ctx context.Context, userID int64, task *entity.ActUserTask, def *entity.ActTaskDefinition) error
 }
 
 // ReachService 触达服务输出端口
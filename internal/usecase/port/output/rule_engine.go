@@ -3,12 +3,11 @@ package output
 import (
 	"context"
 	"mini-sirus/internal/domain/valueobject"
-
-	"github.com/Knetic/govaluate"
 )
 
 // RuleEngine 规则引擎输出端口
 // 定义规则引擎的抽象接口，具体实现在 adapter 层
+// 函数与参数使用与引擎无关的类型，更换表达式引擎不影响用例层
 type RuleEngine interface {
 	// Evaluate 执行表达式求值
 	// expr: 表达式字符串
@@ -18,14 +17,43 @@ type RuleEngine interface {
 	Evaluate(
 		ctx context.Context,
 		expr string,
-		functions map[string]govaluate.ExpressionFunction,
+		functions valueobject.ExpressionFunctions,
 		args valueobject.ExpressionArguments,
 	) (bool, error)
 
+	// EvaluateNumber 执行数值表达式求值
+	// 用于计算加权进度等数值结果
+	EvaluateNumber(
+		ctx context.Context,
+		expr string,
+		functions valueobject.ExpressionFunctions,
+		args valueobject.ExpressionArguments,
+	) (float64, error)
+
+	// Explain 执行表达式求值并返回求值轨迹
+	// 轨迹树包含每个子表达式和函数调用的输入与结果，根节点结果与 Evaluate 一致
+	Explain(
+		ctx context.Context,
+		expr string,
+		functions valueobject.ExpressionFunctions,
+		args valueobject.ExpressionArguments,
+	) (*valueobject.ExpressionTraceNode, error)
+
+	// Validate 编译并校验表达式，不执行求值
+	// 检查语法、引用的函数是否在 functions 中、引用的参数是否在 params 中（参数名 -> 类型），
+	// 支持类型检查的引擎同时按函数签名和参数类型检查；
+	// params 为 nil 时不校验参数；校验失败返回 *valueobject.ExpressionError
+	Validate(
+		ctx context.Context,
+		expr string,
+		functions valueobject.ExpressionFunctions,
+		params map[string]valueobject.ValueType,
+	) error
+
 	// RegisterFunction 注册自定义函数
-	RegisterFunction(name string, fn govaluate.ExpressionFunction) error
+	RegisterFunction(name string, fn *valueobject.ExpressionFunction) error
 
 	// GetRegisteredFunctions 获取所有注册的函数
-	GetRegisteredFunctions() map[string]govaluate.ExpressionFunction
+	GetRegisteredFunctions() valueobject.ExpressionFunctions
 }
 
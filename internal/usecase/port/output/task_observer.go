@@ -3,6 +3,7 @@ package output
 import (
 	"context"
 	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/event"
 )
 
 // TaskObserver 任务观察者输出端口
@@ -18,6 +19,13 @@ type TaskObserver interface {
 	GetObserverName() string
 }
 
+// TaskUnlockObserver 任务解锁观察者（可选实现）
+// 实现了 TaskObserver 的观察者如果同时实现该接口，会在任务解锁时收到通知
+type TaskUnlockObserver interface {
+	// OnTaskUnlocked 当任务解锁时
+	OnTaskUnlocked(ctx context.Context, unlocked *event.TaskUnlocked) error
+}
+
 // TaskObserverRegistry 任务观察者注册表
 type TaskObserverRegistry interface {
 	// Register 注册观察者
@@ -28,5 +36,8 @@ type TaskObserverRegistry interface {
 
 	// Notify 通知所有观察者
 	Notify(ctx context.Context, detail *entity.ActUserTaskDetail) error
+
+	// NotifyTaskUnlocked 通知所有关注任务解锁的观察者
+	NotifyTaskUnlocked(ctx context.Context, unlocked *event.TaskUnlocked) error
 }
 
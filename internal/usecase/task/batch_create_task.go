@@ -0,0 +1,389 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
+	"mini-sirus/internal/usecase/port/output"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchChunkSize = 1000
+	defaultBatchWorkers   = 4
+)
+
+// BatchCreateTaskUseCase 批量预创建任务用例
+// 对应"模式一：异步批量预创建"，活动开始前为全部用户创建该活动下的所有任务
+type BatchCreateTaskUseCase struct {
+	taskRepo        repository.TaskRepository
+	taskDefRepo     repository.TaskDefinitionRepository
+	checkpointStore output.BatchCheckpointStore
+	clock           function.Clock
+}
+
+// NewBatchCreateTaskUseCase 创建批量预创建任务用例，任务的初始周期按时钟的当前时间计算
+func NewBatchCreateTaskUseCase(
+	taskRepo repository.TaskRepository,
+	taskDefRepo repository.TaskDefinitionRepository,
+	checkpointStore output.BatchCheckpointStore,
+	clock function.Clock,
+) *BatchCreateTaskUseCase {
+	return &BatchCreateTaskUseCase{
+		taskRepo:        taskRepo,
+		taskDefRepo:     taskDefRepo,
+		checkpointStore: checkpointStore,
+		clock:           clock,
+	}
+}
+
+// batchChunk 一批待处理的用户
+type batchChunk struct {
+	offset  int64 // 批次第一个用户的位置
+	userIDs []int64
+}
+
+// batchResult 一批用户的处理结果
+type batchResult struct {
+	offset  int64
+	size    int
+	created int
+	skipped int
+	err     error
+}
+
+// Execute 执行批量预创建
+// 相同 JobID 重复执行时从上次的断点继续，已完成的批次直接返回进度
+func (uc *BatchCreateTaskUseCase) Execute(ctx context.Context, input dto.BatchCreateTaskInput) (*dto.BatchCreateProgress, error) {
+	if input.ActivityID <= 0 {
+		return nil, errors.New("activity_id is required")
+	}
+	if input.JobID == "" {
+		input.JobID = fmt.Sprintf("batch_create:%d", input.ActivityID)
+	}
+	if input.ChunkSize <= 0 {
+		input.ChunkSize = defaultBatchChunkSize
+	}
+	if input.Workers <= 0 {
+		input.Workers = defaultBatchWorkers
+	}
+
+	defs, err := uc.listPreCreateDefinitions(ctx, input.ActivityID)
+	if err != nil {
+		return nil, err
+	}
+	if len(defs) == 0 {
+		return nil, errors.New("no pre-create task definitions for activity")
+	}
+
+	source, err := newUserIDSource(input.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	checkpoint, err := uc.checkpointStore.Load(ctx, input.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint failed: %w", err)
+	}
+	if checkpoint == nil {
+		checkpoint = &output.BatchCheckpoint{
+			JobID:      input.JobID,
+			ActivityID: input.ActivityID,
+			Total:      source.Total(),
+			StartedAt:  time.Now(),
+		}
+	}
+	if checkpoint.ActivityID != input.ActivityID {
+		return nil, errors.New("job id already used by another activity")
+	}
+	if checkpoint.Status == dto.BatchStatusCompleted {
+		return uc.toProgress(checkpoint), nil
+	}
+
+	if err := source.Skip(checkpoint.Offset); err != nil {
+		return nil, fmt.Errorf("skip processed users failed: %w", err)
+	}
+
+	checkpoint.Status = dto.BatchStatusRunning
+	checkpoint.LastError = ""
+	if err := uc.saveCheckpoint(ctx, checkpoint, input.OnProgress); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("[BatchCreate] Job %s started for activity %d from offset %d\n",
+		input.JobID, input.ActivityID, checkpoint.Offset)
+
+	runErr := uc.run(ctx, input, defs, source, checkpoint)
+	if runErr != nil {
+		checkpoint.Status = dto.BatchStatusFailed
+		checkpoint.LastError = runErr.Error()
+	} else {
+		checkpoint.Status = dto.BatchStatusCompleted
+	}
+
+	// 使用独立的 context 保存最终状态，避免外部取消导致断点丢失
+	if err := uc.saveCheckpoint(context.Background(), checkpoint, input.OnProgress); err != nil && runErr == nil {
+		runErr = err
+	}
+
+	fmt.Printf("[BatchCreate] Job %s %s: processed=%d created=%d skipped=%d\n",
+		input.JobID, checkpoint.Status, checkpoint.Offset, checkpoint.Created, checkpoint.Skipped)
+
+	return uc.toProgress(checkpoint), runErr
+}
+
+// GetProgress 查询批量预创建进度
+func (uc *BatchCreateTaskUseCase) GetProgress(ctx context.Context, jobID string) (*dto.BatchCreateProgress, error) {
+	if jobID == "" {
+		return nil, errors.New("job_id is required")
+	}
+
+	checkpoint, err := uc.checkpointStore.Load(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint failed: %w", err)
+	}
+	if checkpoint == nil {
+		return nil, errors.New("batch job not found")
+	}
+
+	return uc.toProgress(checkpoint), nil
+}
+
+// listPreCreateDefinitions 获取活动下需要预创建的任务定义
+// 延迟创建的任务在用户首次触发事件时生成，无需预创建；已归档的任务不再创建
+func (uc *BatchCreateTaskUseCase) listPreCreateDefinitions(ctx context.Context, activityID int64) ([]*entity.ActTaskDefinition, error) {
+	defs, err := uc.taskDefRepo.ListByActivityID(ctx, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("list task definitions failed: %w", err)
+	}
+
+	result := make([]*entity.ActTaskDefinition, 0, len(defs))
+	for _, def := range defs {
+		if !def.IsLazy() && !def.Archived {
+			result = append(result, def)
+		}
+	}
+	return result, nil
+}
+
+// run 分批读取用户并由工作池并发创建任务
+// 批次可能乱序完成，断点只推进到连续完成的最后一个批次，其后已完成的批次记录在 Completed 中，
+// 续跑时既不遗漏也不重复处理
+func (uc *BatchCreateTaskUseCase) run(
+	ctx context.Context,
+	input dto.BatchCreateTaskInput,
+	defs []*entity.ActTaskDefinition,
+	source userIDSource,
+	checkpoint *output.BatchCheckpoint,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks := make(chan batchChunk)
+	results := make(chan batchResult)
+	readErr := make(chan error, 1)
+
+	// 已完成的批次，按位置索引
+	completed := make(map[int64]output.BatchChunk, len(checkpoint.Completed))
+	for _, chunk := range checkpoint.Completed {
+		completed[chunk.Offset] = chunk
+	}
+
+	// 读取协程：按顺序切分批次，跳过上次已完成的批次
+	skips := append([]output.BatchChunk(nil), checkpoint.Completed...)
+	go func() {
+		defer close(chunks)
+		offset := checkpoint.Offset
+		for {
+			if len(skips) > 0 && skips[0].Offset == offset {
+				if err := source.Skip(skips[0].Size); err != nil {
+					readErr <- err
+					return
+				}
+				offset += skips[0].Size
+				skips = skips[1:]
+				continue
+			}
+
+			// 批次不跨越已完成的批次
+			size := input.ChunkSize
+			if len(skips) > 0 && skips[0].Offset-offset < int64(size) {
+				size = int(skips[0].Offset - offset)
+			}
+			userIDs, err := source.Next(size)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			if len(userIDs) == 0 {
+				return
+			}
+			select {
+			case chunks <- batchChunk{offset: offset, userIDs: userIDs}:
+			case <-ctx.Done():
+				return
+			}
+			offset += int64(len(userIDs))
+		}
+	}()
+
+	// 工作池
+	var wg sync.WaitGroup
+	for i := 0; i < input.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				results <- uc.processChunk(ctx, chunk, defs)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	for result := range results {
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+				cancel()
+			}
+			continue
+		}
+
+		completed[result.offset] = output.BatchChunk{
+			Offset:  result.offset,
+			Size:    int64(result.size),
+			Created: int64(result.created),
+			Skipped: int64(result.skipped),
+		}
+		advanceCheckpoint(checkpoint, completed)
+
+		if err := uc.saveCheckpoint(ctx, checkpoint, input.OnProgress); err != nil && firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	advanceCheckpoint(checkpoint, completed)
+
+	select {
+	case err := <-readErr:
+		if firstErr == nil {
+			firstErr = fmt.Errorf("read user ids failed: %w", err)
+		}
+	default:
+	}
+
+	return firstErr
+}
+
+// advanceCheckpoint 将从 Offset 开始连续完成的批次计入断点，其余已完成的批次记入 Completed
+func advanceCheckpoint(checkpoint *output.BatchCheckpoint, completed map[int64]output.BatchChunk) {
+	for {
+		chunk, ok := completed[checkpoint.Offset]
+		if !ok {
+			break
+		}
+		delete(completed, checkpoint.Offset)
+		checkpoint.Offset += chunk.Size
+		checkpoint.Created += chunk.Created
+		checkpoint.Skipped += chunk.Skipped
+	}
+
+	checkpoint.Completed = checkpoint.Completed[:0]
+	for _, chunk := range completed {
+		checkpoint.Completed = append(checkpoint.Completed, chunk)
+	}
+	sort.Slice(checkpoint.Completed, func(i, j int) bool {
+		return checkpoint.Completed[i].Offset < checkpoint.Completed[j].Offset
+	})
+}
+
+// processChunk 为一批用户创建活动下的全部任务
+func (uc *BatchCreateTaskUseCase) processChunk(
+	ctx context.Context,
+	chunk batchChunk,
+	defs []*entity.ActTaskDefinition,
+) batchResult {
+	result := batchResult{offset: chunk.offset, size: len(chunk.userIDs)}
+	if err := ctx.Err(); err != nil {
+		result.err = err
+		return result
+	}
+
+	now := uc.clock.Now()
+	tasks := make([]*entity.ActUserTask, 0, len(chunk.userIDs)*len(defs))
+	for _, userID := range chunk.userIDs {
+		for _, def := range defs {
+			// 配置了前置任务的一律以未解锁状态预创建，
+			// 前置任务完成时解锁，触发链路也会对已满足条件的任务补偿解锁
+			status := entity.TaskStatusPending
+			if def.HasPrerequisites() {
+				status = entity.TaskStatusLocked
+			}
+
+			tasks = append(tasks, &entity.ActUserTask{
+				ActivityID: def.ActivityID,
+				TaskID:     def.ID,
+				UserID:     userID,
+				TaskType:   def.TaskType,
+				Status:     status,
+				PeriodKey:  def.PeriodKeyAt(now),
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			})
+		}
+	}
+
+	created, err := uc.taskRepo.BatchCreate(ctx, tasks)
+	if err != nil {
+		result.err = fmt.Errorf("batch create tasks failed: %w", err)
+		return result
+	}
+
+	result.created = created
+	result.skipped = len(tasks) - created
+	return result
+}
+
+// saveCheckpoint 保存断点并回调进度
+func (uc *BatchCreateTaskUseCase) saveCheckpoint(
+	ctx context.Context,
+	checkpoint *output.BatchCheckpoint,
+	onProgress func(dto.BatchCreateProgress),
+) error {
+	checkpoint.UpdatedAt = time.Now()
+	if err := uc.checkpointStore.Save(ctx, checkpoint); err != nil {
+		return fmt.Errorf("save checkpoint failed: %w", err)
+	}
+
+	if onProgress != nil {
+		onProgress(*uc.toProgress(checkpoint))
+	}
+	return nil
+}
+
+// toProgress 转换为进度DTO
+func (uc *BatchCreateTaskUseCase) toProgress(checkpoint *output.BatchCheckpoint) *dto.BatchCreateProgress {
+	return &dto.BatchCreateProgress{
+		JobID:      checkpoint.JobID,
+		ActivityID: checkpoint.ActivityID,
+		Status:     checkpoint.Status,
+		Total:      checkpoint.Total,
+		Processed:  checkpoint.Offset,
+		Created:    checkpoint.Created,
+		Skipped:    checkpoint.Skipped,
+		LastError:  checkpoint.LastError,
+		StartedAt:  checkpoint.StartedAt.Format(time.RFC3339),
+		UpdatedAt:  checkpoint.UpdatedAt.Format(time.RFC3339),
+	}
+}
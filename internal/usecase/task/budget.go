@@ -0,0 +1,66 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/domain/valueobject"
+)
+
+// reserveBudget 预占活动和任务的奖励预算
+// 每次达成消耗本次应发放奖励的数量之和，完成任务时额外占用一个完成人数名额
+func (uc *TriggerTaskUseCase) reserveBudget(
+	ctx context.Context,
+	task *entity.ActUserTask,
+	def *entity.ActTaskDefinition,
+	detail *entity.ActUserTaskDetail,
+) (*entity.BudgetReservation, error) {
+	activityBudget, err := activityBudget(ctx, uc.activityRepo, task.ActivityID)
+	if err != nil {
+		return nil, err
+	}
+
+	rewards, err := uc.rewardRepo.ListByTaskDefID(ctx, def.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list task rewards failed: %w", err)
+	}
+	var rewardUnits int64
+	for _, reward := range rewards {
+		if reward.ShouldGrant(detail) {
+			rewardUnits += int64(reward.Amount)
+		}
+	}
+
+	reservation := &entity.BudgetReservation{
+		UserID: task.UserID,
+		Lines: []entity.BudgetLine{
+			{Scope: entity.NewActivityBudgetScope(task.ActivityID), Budget: activityBudget},
+			{Scope: entity.NewTaskBudgetScope(def.ID), Budget: def.Budget},
+		},
+		RewardUnits: rewardUnits,
+		Completion:  detail.CompletesTask,
+	}
+	if err := uc.budgetRepo.Reserve(ctx, reservation); err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// activityBudget 获取活动的奖励预算，未配置活动时不限制
+func activityBudget(
+	ctx context.Context,
+	activityRepo repository.ActivityRepository,
+	activityID int64,
+) (valueobject.RewardBudget, error) {
+	activity, err := activityRepo.GetByID(ctx, activityID)
+	if errors.Is(err, repository.ErrActivityNotFound) {
+		return valueobject.RewardBudget{}, nil
+	}
+	if err != nil {
+		return valueobject.RewardBudget{}, fmt.Errorf("get activity failed: %w", err)
+	}
+	return activity.Budget, nil
+}
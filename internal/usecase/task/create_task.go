@@ -3,21 +3,31 @@ package task
 import (
 	"context"
 	"errors"
+	"fmt"
 	"mini-sirus/internal/domain/entity"
 	"mini-sirus/internal/domain/repository"
 	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
 	"time"
 )
 
 // CreateTaskUseCase 创建任务用例
 type CreateTaskUseCase struct {
-	taskRepo repository.TaskRepository
+	taskRepo    repository.TaskRepository
+	taskDefRepo repository.TaskDefinitionRepository
+	clock       function.Clock
 }
 
-// NewCreateTaskUseCase 创建任务用例构造函数
-func NewCreateTaskUseCase(taskRepo repository.TaskRepository) *CreateTaskUseCase {
+// NewCreateTaskUseCase 创建任务用例构造函数，任务的初始周期按时钟的当前时间计算
+func NewCreateTaskUseCase(
+	taskRepo repository.TaskRepository,
+	taskDefRepo repository.TaskDefinitionRepository,
+	clock function.Clock,
+) *CreateTaskUseCase {
 	return &CreateTaskUseCase{
-		taskRepo: taskRepo,
+		taskRepo:    taskRepo,
+		taskDefRepo: taskDefRepo,
+		clock:       clock,
 	}
 }
 
@@ -28,18 +38,35 @@ func (uc *CreateTaskUseCase) Execute(ctx context.Context, input dto.CreateTaskIn
 		return nil, err
 	}
 
+	// 获取任务定义
+	def, err := uc.taskDefRepo.GetByID(ctx, input.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("get task definition failed: %w", err)
+	}
+	if def.ActivityID != input.ActivityID {
+		return nil, errors.New("task definition does not belong to activity")
+	}
+	if def.Archived {
+		return nil, errors.New("task definition is archived")
+	}
+
+	// 前置任务未完成时以未解锁状态创建
+	status, err := initialTaskStatus(ctx, uc.taskRepo, def, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建任务实体
 	task := &entity.ActUserTask{
-		ActivityID:   input.ActivityID,
-		TaskID:       input.TaskID,
-		UserID:       input.UserID,
-		TaskType:     input.TaskType,
-		Status:       entity.TaskStatusPending,
-		Progress:     0,
-		Target:       input.Target,
-		TaskCondExpr: input.TaskCondExpr,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ActivityID: def.ActivityID,
+		TaskID:     def.ID,
+		UserID:     input.UserID,
+		TaskType:   def.TaskType,
+		Status:     status,
+		Progress:   0,
+		PeriodKey:  def.PeriodKeyAt(uc.clock.Now()),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
 	}
 
 	// 验证实体
@@ -53,7 +80,7 @@ func (uc *CreateTaskUseCase) Execute(ctx context.Context, input dto.CreateTaskIn
 	}
 
 	// 转换为输出DTO
-	return uc.toTaskOutput(task), nil
+	return uc.toTaskOutput(task, def), nil
 }
 
 // validateInput 验证输入
@@ -67,32 +94,29 @@ func (uc *CreateTaskUseCase) validateInput(input dto.CreateTaskInput) error {
 	if input.UserID <= 0 {
 		return errors.New("user_id is required")
 	}
-	if input.Target <= 0 {
-		return errors.New("target must be greater than 0")
-	}
-	if !input.TaskType.IsValid() {
-		return errors.New("invalid task type")
-	}
-	if input.TaskCondExpr == "" {
-		return errors.New("task_cond_expr is required")
-	}
 	return nil
 }
 
 // toTaskOutput 转换为输出DTO
-func (uc *CreateTaskUseCase) toTaskOutput(task *entity.ActUserTask) *dto.TaskOutput {
+func (uc *CreateTaskUseCase) toTaskOutput(task *entity.ActUserTask, def *entity.ActTaskDefinition) *dto.TaskOutput {
 	return &dto.TaskOutput{
-		ID:           task.ID,
-		ActivityID:   task.ActivityID,
-		TaskID:       task.TaskID,
-		UserID:       task.UserID,
-		TaskType:     task.TaskType,
-		Status:       task.Status.String(),
-		Progress:     task.Progress,
-		Target:       task.Target,
-		TaskCondExpr: task.TaskCondExpr,
-		CreatedAt:    task.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    task.UpdatedAt.Format(time.RFC3339),
+		ID:             task.ID,
+		ActivityID:     task.ActivityID,
+		TaskID:         task.TaskID,
+		UserID:         task.UserID,
+		TaskType:       task.TaskType,
+		Status:         task.Status.String(),
+		Progress:       task.Progress,
+		Target:         def.Target,
+		TaskCondExpr:   def.TaskCondExpr,
+		Recurrence:     def.Recurrence.String(),
+		PeriodKey:      task.PeriodKey,
+		Kind:           def.Kind.String(),
+		CurrentStreak:  task.CurrentStreak(def, uc.clock.Now()),
+		BestStreak:     task.BestStreak,
+		GraceCardsLeft: task.GraceCardsLeft(def),
+		Prerequisites:  def.Prerequisites,
+		CreatedAt:      task.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      task.UpdatedAt.Format(time.RFC3339),
 	}
 }
-
@@ -0,0 +1,85 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
+	"mini-sirus/internal/usecase/port/output"
+)
+
+// DryRunExpressionUseCase 表达式试运行用例
+// 校验表达式并用样例事件求值，供运营配置任务时验证条件是否符合预期
+type DryRunExpressionUseCase struct {
+	ruleEngine       output.RuleEngine
+	functionRegistry *function.Registry
+	taskModeRegistry *dto.TaskModeRegistry
+}
+
+// NewDryRunExpressionUseCase 创建表达式试运行用例
+func NewDryRunExpressionUseCase(
+	ruleEngine output.RuleEngine,
+	functionRegistry *function.Registry,
+	taskModeRegistry *dto.TaskModeRegistry,
+) *DryRunExpressionUseCase {
+	return &DryRunExpressionUseCase{
+		ruleEngine:       ruleEngine,
+		functionRegistry: functionRegistry,
+		taskModeRegistry: taskModeRegistry,
+	}
+}
+
+// Execute 执行表达式试运行
+// 样例事件不合法时返回错误；表达式校验或求值失败时通过输出的 Valid 和 Error 返回
+func (uc *DryRunExpressionUseCase) Execute(ctx context.Context, input dto.DryRunExpressionInput) (*dto.DryRunExpressionOutput, error) {
+	if input.TaskCondExpr == "" {
+		return nil, fmt.Errorf("%w: task_cond_expr is required", dto.ErrInvalidInput)
+	}
+
+	taskMode, err := uc.taskModeRegistry.Decode(input.Event)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid sample event: %w", dto.ErrInvalidInput, err)
+	}
+
+	taskType := taskMode.GetTaskType()
+	schema, ok := uc.taskModeRegistry.Schema(taskType)
+	if !ok {
+		return nil, fmt.Errorf("%w: no event type registered for task type %s", dto.ErrInvalidInput, taskType)
+	}
+
+	params, err := toTaskParameters(input.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	args := params.Merge(taskMode.GetExpressionArguments())
+	output := &dto.DryRunExpressionOutput{
+		TaskType:  taskType,
+		Progress:  1,
+		Schema:    schema,
+		Arguments: args,
+	}
+
+	if err := validateTaskExpressions(ctx, uc.ruleEngine, uc.functionRegistry, uc.taskModeRegistry, taskType, params, input.TaskCondExpr, input.ProgressExpr); err != nil {
+		output.Error = err.Error()
+		return output, nil
+	}
+	output.Valid = true
+
+	functions := uc.functionRegistry.Functions(schema.Functions)
+	output.Reach, err = uc.ruleEngine.Evaluate(ctx, input.TaskCondExpr, functions, args)
+	if err != nil {
+		output.Error = fmt.Sprintf("evaluate expression failed: %v", err)
+		return output, nil
+	}
+
+	if input.ProgressExpr != "" {
+		output.Progress, err = uc.ruleEngine.EvaluateNumber(ctx, input.ProgressExpr, functions, args)
+		if err != nil {
+			output.Error = fmt.Sprintf("evaluate progress expression failed: %v", err)
+			return output, nil
+		}
+	}
+
+	return output, nil
+}
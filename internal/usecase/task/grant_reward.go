@@ -0,0 +1,48 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+)
+
+// grantRewards 按任务定义配置的奖励记账
+// 流水以明细唯一标识为幂等键，重复发放不会重复入账
+func (uc *TriggerTaskUseCase) grantRewards(ctx context.Context, task *entity.ActUserTask, detail *entity.ActUserTaskDetail) error {
+	rewards, err := uc.rewardRepo.ListByTaskDefID(ctx, task.TaskID)
+	if err != nil {
+		return fmt.Errorf("list task rewards failed: %w", err)
+	}
+
+	for _, reward := range rewards {
+		if !reward.ShouldGrant(detail) {
+			continue
+		}
+
+		entry := &entity.ActRewardLedgerEntry{
+			UserID:         task.UserID,
+			ActivityID:     task.ActivityID,
+			UserTaskID:     task.ID,
+			TaskDefID:      task.TaskID,
+			DetailID:       detail.ID,
+			RewardID:       reward.ID,
+			Type:           reward.Type,
+			Amount:         reward.Amount,
+			ItemCode:       reward.ItemCode,
+			IdempotencyKey: entity.RewardIdempotencyKey(detail, reward.ID),
+		}
+
+		appended, err := uc.rewardLedgerRepo.Append(ctx, entry)
+		if err != nil {
+			return fmt.Errorf("append reward ledger failed: %w", err)
+		}
+		if !appended {
+			fmt.Printf("[TriggerTask] Reward %d already granted for %s\n", reward.ID, entry.IdempotencyKey)
+			continue
+		}
+
+		fmt.Printf("[TriggerTask] Granted %s x%d to user %d\n", reward.Type, reward.Amount, task.UserID)
+	}
+
+	return nil
+}
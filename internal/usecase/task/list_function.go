@@ -0,0 +1,73 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
+)
+
+// ListFunctionUseCase 查询表达式函数用例（供运营后台配置任务条件时参考）
+type ListFunctionUseCase struct {
+	functionRegistry *function.Registry
+	taskModeRegistry *dto.TaskModeRegistry
+}
+
+// NewListFunctionUseCase 创建查询表达式函数用例
+func NewListFunctionUseCase(
+	functionRegistry *function.Registry,
+	taskModeRegistry *dto.TaskModeRegistry,
+) *ListFunctionUseCase {
+	return &ListFunctionUseCase{
+		functionRegistry: functionRegistry,
+		taskModeRegistry: taskModeRegistry,
+	}
+}
+
+// Execute 查询表达式函数，指定任务类型时只返回该任务类型可用的函数
+func (uc *ListFunctionUseCase) Execute(ctx context.Context, taskType valueobject.TaskType) ([]*dto.FunctionOutput, error) {
+	// 函数名 -> 可使用该函数的任务类型
+	scopes := make(map[string][]valueobject.TaskType)
+	for _, schema := range uc.taskModeRegistry.Schemas() {
+		for _, name := range schema.Functions {
+			scopes[name] = append(scopes[name], schema.TaskType)
+		}
+	}
+
+	var allowed map[string]bool
+	if taskType != "" {
+		schema, ok := uc.taskModeRegistry.Schema(taskType)
+		if !ok {
+			return nil, fmt.Errorf("%w: no event type registered for task type %s", dto.ErrInvalidInput, taskType)
+		}
+		allowed = make(map[string]bool, len(schema.Functions))
+		for _, name := range schema.Functions {
+			allowed[name] = true
+		}
+	}
+
+	var outputs []*dto.FunctionOutput
+	for _, def := range uc.functionRegistry.List() {
+		if allowed != nil && !allowed[def.Name] {
+			continue
+		}
+
+		output := &dto.FunctionOutput{
+			Name:      def.Name,
+			Signature: def.Signature(),
+			Params:    make([]dto.FunctionParamOutput, 0, len(def.Params)),
+			Variadic:  def.Variadic,
+			Returns:   string(def.Returns),
+			Doc:       def.Doc,
+			Example:   def.Example,
+			TaskTypes: scopes[def.Name],
+		}
+		for _, param := range def.Params {
+			output.Params = append(output.Params, dto.FunctionParamOutput{Name: param.Name, Type: string(param.Type)})
+		}
+		outputs = append(outputs, output)
+	}
+
+	return outputs, nil
+}
@@ -0,0 +1,300 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
+	"mini-sirus/internal/usecase/port/output"
+	"time"
+)
+
+// ErrTargetBelowProgress 新的目标值不高于进行中用户任务的进度，这些任务将无法再完成
+var ErrTargetBelowProgress = errors.New("target below user task progress")
+
+// ManageTaskDefinitionUseCase 任务定义管理用例（创建、更新、查询、归档）
+type ManageTaskDefinitionUseCase struct {
+	taskRepo         repository.TaskRepository
+	taskDefRepo      repository.TaskDefinitionRepository
+	activityRepo     repository.ActivityRepository
+	ruleEngine       output.RuleEngine
+	functionRegistry *function.Registry
+	taskModeRegistry *dto.TaskModeRegistry
+}
+
+// NewManageTaskDefinitionUseCase 创建任务定义管理用例
+func NewManageTaskDefinitionUseCase(
+	taskRepo repository.TaskRepository,
+	taskDefRepo repository.TaskDefinitionRepository,
+	activityRepo repository.ActivityRepository,
+	ruleEngine output.RuleEngine,
+	functionRegistry *function.Registry,
+	taskModeRegistry *dto.TaskModeRegistry,
+) *ManageTaskDefinitionUseCase {
+	return &ManageTaskDefinitionUseCase{
+		taskRepo:         taskRepo,
+		taskDefRepo:      taskDefRepo,
+		activityRepo:     activityRepo,
+		ruleEngine:       ruleEngine,
+		functionRegistry: functionRegistry,
+		taskModeRegistry: taskModeRegistry,
+	}
+}
+
+// ExecuteCreate 创建任务定义
+func (uc *ManageTaskDefinitionUseCase) ExecuteCreate(ctx context.Context, input dto.TaskDefinitionInput) (*dto.TaskDefinitionOutput, error) {
+	activity, err := uc.activityRepo.GetByID(ctx, input.ActivityID)
+	if err != nil {
+		return nil, fmt.Errorf("get activity failed: %w", err)
+	}
+	if activity.Archived {
+		return nil, entity.ErrActivityArchived
+	}
+
+	createMode, err := entity.ParseTaskCreateMode(input.CreateMode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", dto.ErrInvalidInput, err)
+	}
+	kind, err := entity.ParseTaskKind(input.Kind)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", dto.ErrInvalidInput, err)
+	}
+
+	def := &entity.ActTaskDefinition{
+		ActivityID: input.ActivityID,
+		TaskType:   input.TaskType,
+		CreateMode: createMode,
+		Recurrence: valueobject.NewRecurrence(valueobject.RecurrenceType(input.Recurrence), input.Timezone),
+		Kind:       kind,
+	}
+	if def.IsStreak() {
+		def.Streak = valueobject.NewStreakPolicy(input.GraceCards, input.Timezone)
+	}
+	if err := uc.apply(ctx, def, input); err != nil {
+		return nil, err
+	}
+
+	if err := uc.taskDefRepo.Create(ctx, def); err != nil {
+		return nil, fmt.Errorf("create task definition failed: %w", err)
+	}
+
+	return toTaskDefinitionOutput(def), nil
+}
+
+// ExecuteUpdate 更新任务定义
+// 已创建的用户任务沿用新的条件、目标和奖励配置
+func (uc *ManageTaskDefinitionUseCase) ExecuteUpdate(ctx context.Context, input dto.TaskDefinitionInput) (*dto.TaskDefinitionOutput, error) {
+	if input.ID <= 0 {
+		return nil, fmt.Errorf("%w: id is required", dto.ErrInvalidInput)
+	}
+
+	def, err := uc.taskDefRepo.GetByID(ctx, input.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get task definition failed: %w", err)
+	}
+	if def.Archived {
+		return nil, errors.New("task definition is archived")
+	}
+
+	createMode, err := entity.ParseTaskCreateMode(input.CreateMode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", dto.ErrInvalidInput, err)
+	}
+	previousTarget := def.Target
+	def.CreateMode = createMode
+	if err := uc.apply(ctx, def, input); err != nil {
+		return nil, err
+	}
+
+	// 调低目标值时，进度已达到新目标的进行中任务不会再被判定完成，拒绝此类修改
+	if def.Target < previousTarget {
+		maxProgress, err := uc.taskRepo.MaxPendingProgress(ctx, def.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get user task progress failed: %w", err)
+		}
+		if maxProgress >= def.Target {
+			return nil, fmt.Errorf("%w: target %d, pending user task progress %d", ErrTargetBelowProgress, def.Target, maxProgress)
+		}
+	}
+
+	if err := uc.taskDefRepo.Update(ctx, def); err != nil {
+		return nil, fmt.Errorf("update task definition failed: %w", err)
+	}
+
+	return toTaskDefinitionOutput(def), nil
+}
+
+// ExecuteList 查询活动下的任务定义
+func (uc *ManageTaskDefinitionUseCase) ExecuteList(ctx context.Context, activityID int64, includeArchived bool) ([]*dto.TaskDefinitionOutput, error) {
+	if activityID <= 0 {
+		return nil, fmt.Errorf("%w: activity_id is required", dto.ErrInvalidInput)
+	}
+
+	defs, err := uc.taskDefRepo.ListByActivityID(ctx, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("list task definitions failed: %w", err)
+	}
+
+	outputs := make([]*dto.TaskDefinitionOutput, 0, len(defs))
+	for _, def := range defs {
+		if def.Archived && !includeArchived {
+			continue
+		}
+		outputs = append(outputs, toTaskDefinitionOutput(def))
+	}
+
+	return outputs, nil
+}
+
+// ExecuteArchive 归档任务定义
+// 归档后不再创建用户任务，已有用户任务也不再计入进度
+func (uc *ManageTaskDefinitionUseCase) ExecuteArchive(ctx context.Context, taskDefID int64) (*dto.TaskDefinitionOutput, error) {
+	if taskDefID <= 0 {
+		return nil, fmt.Errorf("%w: task_def_id is required", dto.ErrInvalidInput)
+	}
+
+	def, err := uc.taskDefRepo.GetByID(ctx, taskDefID)
+	if err != nil {
+		return nil, fmt.Errorf("get task definition failed: %w", err)
+	}
+	if def.Archived {
+		return toTaskDefinitionOutput(def), nil
+	}
+
+	def.Archived = true
+	if err := uc.taskDefRepo.Update(ctx, def); err != nil {
+		return nil, fmt.Errorf("archive task definition failed: %w", err)
+	}
+
+	return toTaskDefinitionOutput(def), nil
+}
+
+// apply 将可修改的配置写入任务定义并校验
+func (uc *ManageTaskDefinitionUseCase) apply(ctx context.Context, def *entity.ActTaskDefinition, input dto.TaskDefinitionInput) error {
+	prerequisiteMode, err := entity.ParsePrerequisiteMode(input.PrerequisiteMode)
+	if err != nil {
+		return fmt.Errorf("%w: %w", dto.ErrInvalidInput, err)
+	}
+
+	params, err := toTaskParameters(input.Parameters)
+	if err != nil {
+		return err
+	}
+
+	def.Name = input.Name
+	def.TaskCondExpr = input.TaskCondExpr
+	def.ProgressExpr = input.ProgressExpr
+	def.Parameters = params
+	def.Target = input.Target
+	def.RewardValue = input.RewardValue
+	def.Prerequisites = input.Prerequisites
+	def.PrerequisiteMode = prerequisiteMode
+	def.Budget = valueobject.NewRewardBudget(input.MaxRewardUnits, input.MaxCompleters)
+	def.StartTime = input.StartTime
+	def.EndTime = input.EndTime
+
+	if !def.IsValid() {
+		return fmt.Errorf("%w: invalid task definition", dto.ErrInvalidInput)
+	}
+
+	// 表达式只能引用任务类型对应事件声明的参数与函数，避免配置错误到真实事件触发时才暴露
+	if err := validateTaskExpressions(ctx, uc.ruleEngine, uc.functionRegistry, uc.taskModeRegistry, def.TaskType, def.Parameters, def.TaskCondExpr, def.ProgressExpr); err != nil {
+		return err
+	}
+
+	// 前置任务必须是同一活动下的任务定义，且不能构成循环依赖
+	for _, prereqID := range def.Prerequisites {
+		prereq, err := uc.taskDefRepo.GetByID(ctx, prereqID)
+		if errors.Is(err, repository.ErrTaskDefinitionNotFound) {
+			return fmt.Errorf("%w: prerequisite %d not found", dto.ErrInvalidInput, prereqID)
+		}
+		if err != nil {
+			return fmt.Errorf("get prerequisite %d failed: %w", prereqID, err)
+		}
+		if prereq.ActivityID != def.ActivityID {
+			return fmt.Errorf("%w: prerequisite %d does not belong to activity %d", dto.ErrInvalidInput, prereqID, def.ActivityID)
+		}
+	}
+	if def.HasPrerequisites() {
+		defs, err := uc.taskDefRepo.ListByActivityID(ctx, def.ActivityID)
+		if err != nil {
+			return fmt.Errorf("list task definitions failed: %w", err)
+		}
+		if err := def.CheckPrerequisiteCycle(defs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toTaskDefinitionOutput 转换为任务定义输出DTO
+func toTaskDefinitionOutput(def *entity.ActTaskDefinition) *dto.TaskDefinitionOutput {
+	output := &dto.TaskDefinitionOutput{
+		ID:             def.ID,
+		ActivityID:     def.ActivityID,
+		Name:           def.Name,
+		TaskType:       def.TaskType,
+		TaskCondExpr:   def.TaskCondExpr,
+		ProgressExpr:   def.ProgressExpr,
+		Parameters:     toTaskParameterDTOs(def.Parameters),
+		Target:         def.Target,
+		RewardValue:    def.RewardValue,
+		CreateMode:     def.CreateMode.String(),
+		Recurrence:     def.Recurrence.String(),
+		Kind:           def.Kind.String(),
+		Prerequisites:  def.Prerequisites,
+		MaxRewardUnits: def.Budget.MaxRewardUnits,
+		MaxCompleters:  def.Budget.MaxCompleters,
+		Archived:       def.Archived,
+		CreatedAt:      def.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      def.UpdatedAt.Format(time.RFC3339),
+	}
+	if def.HasPrerequisites() {
+		output.PrerequisiteMode = def.PrerequisiteMode.String()
+	}
+	if !def.StartTime.IsZero() {
+		output.StartTime = def.StartTime.Format(time.RFC3339)
+	}
+	if !def.EndTime.IsZero() {
+		output.EndTime = def.EndTime.Format(time.RFC3339)
+	}
+	return output
+}
+
+// toTaskParameters 转换任务参数，取值按参数类型转换
+func toTaskParameters(inputs []dto.TaskParameterDTO) (valueobject.TaskParameters, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	params := make(valueobject.TaskParameters, 0, len(inputs))
+	for _, input := range inputs {
+		param, err := valueobject.NewTaskParameter(input.Name, input.Type, input.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", dto.ErrInvalidInput, err)
+		}
+		params = append(params, param)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", dto.ErrInvalidInput, err)
+	}
+	return params, nil
+}
+
+// toTaskParameterDTOs 转换为任务参数DTO
+func toTaskParameterDTOs(params valueobject.TaskParameters) []dto.TaskParameterDTO {
+	if len(params) == 0 {
+		return nil
+	}
+
+	outputs := make([]dto.TaskParameterDTO, 0, len(params))
+	for _, param := range params {
+		outputs = append(outputs, dto.TaskParameterDTO{Name: param.Name, Type: param.Type, Value: param.Value})
+	}
+	return outputs
+}
@@ -0,0 +1,50 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+)
+
+// completedTaskDefs 获取用户在活动中已完成的任务定义ID
+func completedTaskDefs(
+	ctx context.Context,
+	taskRepo repository.TaskRepository,
+	userID, activityID int64,
+) (map[int64]bool, error) {
+	tasks, err := taskRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list user tasks failed: %w", err)
+	}
+
+	completed := make(map[int64]bool, len(tasks))
+	for _, task := range tasks {
+		if task.ActivityID == activityID && task.IsCompleted() {
+			completed[task.TaskID] = true
+		}
+	}
+	return completed, nil
+}
+
+// initialTaskStatus 计算新建用户任务的初始状态
+// 配置了前置任务且前置条件未满足时，任务以未解锁状态创建
+func initialTaskStatus(
+	ctx context.Context,
+	taskRepo repository.TaskRepository,
+	def *entity.ActTaskDefinition,
+	userID int64,
+) (entity.TaskStatus, error) {
+	if !def.HasPrerequisites() {
+		return entity.TaskStatusPending, nil
+	}
+
+	completed, err := completedTaskDefs(ctx, taskRepo, userID, def.ActivityID)
+	if err != nil {
+		return entity.TaskStatusLocked, err
+	}
+	if def.PrerequisitesMet(completed) {
+		return entity.TaskStatusPending, nil
+	}
+	return entity.TaskStatusLocked, nil
+}
@@ -0,0 +1,86 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/usecase/dto"
+)
+
+// QueryBudgetUseCase 查询剩余奖励预算用例
+type QueryBudgetUseCase struct {
+	activityRepo repository.ActivityRepository
+	taskDefRepo  repository.TaskDefinitionRepository
+	budgetRepo   repository.RewardBudgetRepository
+}
+
+// NewQueryBudgetUseCase 创建查询剩余奖励预算用例
+func NewQueryBudgetUseCase(
+	activityRepo repository.ActivityRepository,
+	taskDefRepo repository.TaskDefinitionRepository,
+	budgetRepo repository.RewardBudgetRepository,
+) *QueryBudgetUseCase {
+	return &QueryBudgetUseCase{
+		activityRepo: activityRepo,
+		taskDefRepo:  taskDefRepo,
+		budgetRepo:   budgetRepo,
+	}
+}
+
+// ExecuteActivity 查询活动的剩余预算
+func (uc *QueryBudgetUseCase) ExecuteActivity(ctx context.Context, activityID int64) (*dto.BudgetOutput, error) {
+	if activityID <= 0 {
+		return nil, errors.New("activity_id is required")
+	}
+
+	budget, err := activityBudget(ctx, uc.activityRepo, activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.query(ctx, entity.NewActivityBudgetScope(activityID), budget)
+}
+
+// ExecuteTask 查询任务定义的剩余预算
+func (uc *QueryBudgetUseCase) ExecuteTask(ctx context.Context, taskDefID int64) (*dto.BudgetOutput, error) {
+	if taskDefID <= 0 {
+		return nil, errors.New("task_def_id is required")
+	}
+
+	def, err := uc.taskDefRepo.GetByID(ctx, taskDefID)
+	if err != nil {
+		return nil, fmt.Errorf("get task definition failed: %w", err)
+	}
+
+	return uc.query(ctx, entity.NewTaskBudgetScope(def.ID), def.Budget)
+}
+
+// query 查询预算范围的用量并计算剩余预算
+func (uc *QueryBudgetUseCase) query(
+	ctx context.Context,
+	scope entity.BudgetScope,
+	budget valueobject.RewardBudget,
+) (*dto.BudgetOutput, error) {
+	usage, err := uc.budgetRepo.GetUsage(ctx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("get budget usage failed: %w", err)
+	}
+
+	remainingUnits := usage.RemainingRewardUnits(budget)
+	remainingCompleters := usage.RemainingCompleters(budget)
+
+	return &dto.BudgetOutput{
+		Scope:                scope.Type.String(),
+		ScopeID:              scope.ID,
+		MaxRewardUnits:       budget.MaxRewardUnits,
+		UsedRewardUnits:      usage.RewardUnits,
+		RemainingRewardUnits: remainingUnits,
+		MaxCompleters:        budget.MaxCompleters,
+		Completers:           usage.Completers,
+		RemainingCompleters:  remainingCompleters,
+		Exhausted:            remainingUnits == 0 || remainingCompleters == 0,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/usecase/dto"
+	"time"
+)
+
+// QueryRewardUseCase 查询用户奖励用例
+type QueryRewardUseCase struct {
+	rewardLedgerRepo repository.RewardLedgerRepository
+}
+
+// NewQueryRewardUseCase 创建查询用户奖励用例
+func NewQueryRewardUseCase(rewardLedgerRepo repository.RewardLedgerRepository) *QueryRewardUseCase {
+	return &QueryRewardUseCase{
+		rewardLedgerRepo: rewardLedgerRepo,
+	}
+}
+
+// ExecuteWallet 查询用户奖励余额
+func (uc *QueryRewardUseCase) ExecuteWallet(ctx context.Context, userID int64) (*dto.WalletOutput, error) {
+	if userID <= 0 {
+		return nil, errors.New("user_id is required")
+	}
+
+	wallet, err := uc.rewardLedgerRepo.GetWallet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dto.WalletOutput{
+		UserID:  wallet.UserID,
+		Points:  wallet.Points,
+		Coupons: wallet.Coupons,
+		Badges:  make(map[string]string, len(wallet.Badges)),
+	}
+	for code, obtainedAt := range wallet.Badges {
+		output.Badges[code] = obtainedAt.Format(time.RFC3339)
+	}
+	if !wallet.UpdatedAt.IsZero() {
+		output.UpdatedAt = wallet.UpdatedAt.Format(time.RFC3339)
+	}
+
+	return output, nil
+}
+
+// ExecuteLedger 查询用户奖励流水
+func (uc *QueryRewardUseCase) ExecuteLedger(ctx context.Context, userID int64) ([]*dto.RewardLedgerOutput, error) {
+	if userID <= 0 {
+		return nil, errors.New("user_id is required")
+	}
+
+	entries, err := uc.rewardLedgerRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*dto.RewardLedgerOutput, 0, len(entries))
+	for _, entry := range entries {
+		outputs = append(outputs, uc.toLedgerOutput(entry))
+	}
+
+	return outputs, nil
+}
+
+// toLedgerOutput 转换为奖励流水输出DTO
+func (uc *QueryRewardUseCase) toLedgerOutput(entry *entity.ActRewardLedgerEntry) *dto.RewardLedgerOutput {
+	return &dto.RewardLedgerOutput{
+		ID:         entry.ID,
+		UserID:     entry.UserID,
+		ActivityID: entry.ActivityID,
+		UserTaskID: entry.UserTaskID,
+		TaskDefID:  entry.TaskDefID,
+		DetailID:   entry.DetailID,
+		RewardID:   entry.RewardID,
+		RewardType: entry.Type.String(),
+		Amount:     entry.Amount,
+		ItemCode:   entry.ItemCode,
+		CreatedAt:  entry.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -3,21 +3,34 @@ package task
 import (
 	"context"
 	"errors"
+	"fmt"
 	"mini-sirus/internal/domain/entity"
 	"mini-sirus/internal/domain/repository"
 	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
 	"time"
 )
 
 // QueryTaskUseCase 查询任务用例
 type QueryTaskUseCase struct {
-	taskRepo repository.TaskRepository
+	taskRepo       repository.TaskRepository
+	taskDefRepo    repository.TaskDefinitionRepository
+	taskPeriodRepo repository.TaskPeriodRepository
+	clock          function.Clock
 }
 
-// NewQueryTaskUseCase 创建查询任务用例
-func NewQueryTaskUseCase(taskRepo repository.TaskRepository) *QueryTaskUseCase {
+// NewQueryTaskUseCase 创建查询任务用例，周期和连续天数按时钟的当前时间计算
+func NewQueryTaskUseCase(
+	taskRepo repository.TaskRepository,
+	taskDefRepo repository.TaskDefinitionRepository,
+	taskPeriodRepo repository.TaskPeriodRepository,
+	clock function.Clock,
+) *QueryTaskUseCase {
 	return &QueryTaskUseCase{
-		taskRepo: taskRepo,
+		taskRepo:       taskRepo,
+		taskDefRepo:    taskDefRepo,
+		taskPeriodRepo: taskPeriodRepo,
+		clock:          clock,
 	}
 }
 
@@ -32,7 +45,12 @@ func (uc *QueryTaskUseCase) Execute(ctx context.Context, input dto.QueryTaskInpu
 		return nil, err
 	}
 
-	return uc.toTaskOutput(task), nil
+	def, err := uc.taskDefRepo.GetByID(ctx, task.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("get task definition failed: %w", err)
+	}
+
+	return uc.toTaskOutput(task, def), nil
 }
 
 // ExecuteList 执行查询任务用例（用户任务列表）
@@ -48,26 +66,93 @@ func (uc *QueryTaskUseCase) ExecuteList(ctx context.Context, userID int64) ([]*d
 
 	outputs := make([]*dto.TaskOutput, 0, len(tasks))
 	for _, task := range tasks {
-		outputs = append(outputs, uc.toTaskOutput(task))
+		// 任务定义缺失时仅跳过该任务，不影响用户的其他任务
+		def, err := uc.taskDefRepo.GetByID(ctx, task.TaskID)
+		if errors.Is(err, repository.ErrTaskDefinitionNotFound) {
+			fmt.Printf("[QueryTask] Task %d skipped: definition %d not found\n", task.ID, task.TaskID)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get task definition failed: %w", err)
+		}
+		outputs = append(outputs, uc.toTaskOutput(task, def))
+	}
+
+	return outputs, nil
+}
+
+// ExecuteHistory 查询周期任务的历史周期进度
+func (uc *QueryTaskUseCase) ExecuteHistory(ctx context.Context, taskID int64) ([]*dto.TaskPeriodOutput, error) {
+	if taskID <= 0 {
+		return nil, errors.New("task_id is required")
+	}
+
+	task, err := uc.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := uc.taskDefRepo.GetByID(ctx, task.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("get task definition failed: %w", err)
+	}
+
+	periods, err := uc.taskPeriodRepo.ListByUserTaskID(ctx, task.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list task periods failed: %w", err)
+	}
+
+	// 进入新周期后尚未触发事件时，存储的仍是上一周期的进度，同样属于历史
+	current := *task
+	if archived, _ := current.RollPeriod(def, uc.clock.Now()); archived != nil {
+		periods = append(periods, archived)
+	}
+
+	outputs := make([]*dto.TaskPeriodOutput, 0, len(periods))
+	for _, period := range periods {
+		outputs = append(outputs, &dto.TaskPeriodOutput{
+			TaskID:    period.UserTaskID,
+			UserID:    period.UserID,
+			PeriodKey: period.PeriodKey,
+			Status:    period.Status.String(),
+			Progress:  period.Progress,
+			Target:    def.Target,
+		})
 	}
 
 	return outputs, nil
 }
 
 // toTaskOutput 转换为输出DTO
-func (uc *QueryTaskUseCase) toTaskOutput(task *entity.ActUserTask) *dto.TaskOutput {
+// 周期任务展示当前周期的进度，上一周期的进度通过 ExecuteHistory 查询
+func (uc *QueryTaskUseCase) toTaskOutput(task *entity.ActUserTask, def *entity.ActTaskDefinition) *dto.TaskOutput {
+	now := uc.clock.Now()
+	current := *task
+	current.RollPeriod(def, now)
+	if def.IsStreak() {
+		// 已断签的连续打卡任务展示为重新开始
+		current.Progress = current.CurrentStreak(def, now)
+	}
+	task = &current
+
 	return &dto.TaskOutput{
-		ID:           task.ID,
-		ActivityID:   task.ActivityID,
-		TaskID:       task.TaskID,
-		UserID:       task.UserID,
-		TaskType:     task.TaskType,
-		Status:       task.Status.String(),
-		Progress:     task.Progress,
-		Target:       task.Target,
-		TaskCondExpr: task.TaskCondExpr,
-		CreatedAt:    task.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    task.UpdatedAt.Format(time.RFC3339),
+		ID:             task.ID,
+		ActivityID:     task.ActivityID,
+		TaskID:         task.TaskID,
+		UserID:         task.UserID,
+		TaskType:       task.TaskType,
+		Status:         task.Status.String(),
+		Progress:       task.Progress,
+		Target:         def.Target,
+		TaskCondExpr:   def.TaskCondExpr,
+		Recurrence:     def.Recurrence.String(),
+		PeriodKey:      task.PeriodKey,
+		Kind:           def.Kind.String(),
+		CurrentStreak:  task.CurrentStreak(def, now),
+		BestStreak:     task.BestStreak,
+		GraceCardsLeft: task.GraceCardsLeft(def),
+		Prerequisites:  def.Prerequisites,
+		CreatedAt:      task.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      task.UpdatedAt.Format(time.RFC3339),
 	}
 }
-
@@ -0,0 +1,75 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/repository"
+	"mini-sirus/internal/usecase/dto"
+	"time"
+)
+
+const (
+	defaultTraceLimit = 20
+	maxTraceLimit     = 100
+)
+
+// QueryTraceUseCase 查询表达式求值轨迹用例
+type QueryTraceUseCase struct {
+	traceRepo repository.ExpressionTraceRepository
+}
+
+// NewQueryTraceUseCase 创建查询表达式求值轨迹用例
+func NewQueryTraceUseCase(traceRepo repository.ExpressionTraceRepository) *QueryTraceUseCase {
+	return &QueryTraceUseCase{
+		traceRepo: traceRepo,
+	}
+}
+
+// Execute 按用户或用户任务查询最近的求值轨迹，指定 TaskID 时优先按任务查询
+func (uc *QueryTraceUseCase) Execute(ctx context.Context, input dto.QueryTraceInput) ([]*dto.ExpressionTraceOutput, error) {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultTraceLimit
+	}
+	limit = min(limit, maxTraceLimit)
+
+	var (
+		traces []*entity.ActExpressionTrace
+		err    error
+	)
+	switch {
+	case input.TaskID > 0:
+		traces, err = uc.traceRepo.ListByTaskID(ctx, input.TaskID, limit)
+	case input.UserID > 0:
+		traces, err = uc.traceRepo.ListByUserID(ctx, input.UserID, limit)
+	default:
+		return nil, fmt.Errorf("%w: user_id or task_id is required", dto.ErrInvalidInput)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list expression traces failed: %w", err)
+	}
+
+	outputs := make([]*dto.ExpressionTraceOutput, 0, len(traces))
+	for _, trace := range traces {
+		output := &dto.ExpressionTraceOutput{
+			ID:         trace.ID,
+			UserID:     trace.UserID,
+			ActivityID: trace.ActivityID,
+			TaskID:     trace.TaskID,
+			TaskDefID:  trace.TaskDefID,
+			UniqueFlag: trace.UniqueFlag,
+			Expr:       trace.Expr,
+			Reach:      trace.Reach,
+			Error:      trace.Error,
+			Trace:      trace.Root,
+			CreatedAt:  trace.CreatedAt.Format(time.RFC3339),
+		}
+		if failed := trace.Root.FirstFailed(); failed != nil {
+			output.FailedClause = failed.Expr
+		}
+		outputs = append(outputs, output)
+	}
+
+	return outputs, nil
+}
@@ -0,0 +1,217 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/usecase/port/output"
+	"sync"
+	"time"
+)
+
+// ErrTaskLockBusy 用户任务锁被占用，按竞争策略等待、重试或排队后仍未获得
+var ErrTaskLockBusy = errors.New("task lock busy")
+
+// LockPolicy 用户任务锁的竞争策略
+type LockPolicy string
+
+const (
+	LockPolicyWait  LockPolicy = "wait"  // 阻塞等待锁，超过等待时间后失败
+	LockPolicyRetry LockPolicy = "retry" // 非阻塞加锁，失败后按指数退避重试
+	LockPolicyQueue LockPolicy = "queue" // 同一用户的事件在本实例内按到达顺序排队，依次加锁处理
+)
+
+// ParseLockPolicy 解析锁竞争策略，为空时使用 wait
+func ParseLockPolicy(s string) (LockPolicy, error) {
+	switch policy := LockPolicy(s); policy {
+	case "":
+		return LockPolicyWait, nil
+	case LockPolicyWait, LockPolicyRetry, LockPolicyQueue:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown lock policy: %s", s)
+	}
+}
+
+// LockContention 用户任务锁的竞争配置
+type LockContention struct {
+	Policy      LockPolicy
+	TTL         time.Duration // 锁过期时间，持有期间每 1/3 TTL 续期一次
+	WaitTimeout time.Duration // wait、queue：等待锁的最长时间，0 表示等到 ctx 取消
+	MaxRetry    int           // retry：最大重试次数
+	Backoff     time.Duration // retry：首次重试间隔，之后每次翻倍
+	QueueSize   int           // queue：单个用户排队中的最大事件数，0 表示不限制
+}
+
+// withDefaults 补全未配置的选项
+func (c LockContention) withDefaults() LockContention {
+	if c.Policy == "" {
+		c.Policy = LockPolicyWait
+	}
+	if c.TTL <= 0 {
+		c.TTL = 30 * time.Second
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = 10 * time.Millisecond
+	}
+	return c
+}
+
+// acquire 按竞争策略加锁
+func (c LockContention) acquire(ctx context.Context, lock output.DistributedLock, key string) (*output.Lease, error) {
+	if c.Policy == LockPolicyRetry {
+		return c.acquireWithRetry(ctx, lock, key)
+	}
+
+	waitCtx := ctx
+	if c.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, c.WaitTimeout)
+		defer cancel()
+	}
+
+	lease, err := lock.Lock(waitCtx, key, c.TTL)
+	if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w: %s not acquired within %v", ErrTaskLockBusy, key, c.WaitTimeout)
+	}
+	return lease, err
+}
+
+// acquireWithRetry 非阻塞加锁，失败后按指数退避（带随机抖动）重试
+func (c LockContention) acquireWithRetry(ctx context.Context, lock output.DistributedLock, key string) (*output.Lease, error) {
+	backoff := c.Backoff
+	for attempt := 0; ; attempt++ {
+		ok, lease, err := lock.TryLock(ctx, key, c.TTL)
+		if err != nil || ok {
+			return lease, err
+		}
+		if attempt >= c.MaxRetry {
+			return nil, fmt.Errorf("%w: %s not acquired after %d retries", ErrTaskLockBusy, key, c.MaxRetry)
+		}
+
+		// 在 [backoff/2, backoff) 内随机等待，避免竞争者同时重试
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("wait for lock %s: %w", key, ctx.Err())
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// taskLockKey 用户粒度任务锁的键，同一用户同一任务类型的事件串行处理
+func taskLockKey(userID int64, taskType valueobject.TaskType) string {
+	return fmt.Sprintf("task_lock:%d:%s", userID, taskType)
+}
+
+// holdLock 按竞争策略加锁，并在持有期间定期续期，返回的 release 停止续期并解锁
+// 续期失败说明锁已过期并可能被其他持有者获取，此后的写入由仓储按隔离令牌拒绝。
+// 续期和解锁不随请求取消，避免客户端断开后锁一直被占用到过期
+func holdLock(
+	ctx context.Context,
+	lock output.DistributedLock,
+	key string,
+	contention LockContention,
+) (*output.Lease, func(), error) {
+	lease, err := contention.acquire(ctx, lock, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ttl := contention.TTL
+	lockCtx := context.WithoutCancel(ctx)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := lock.Renew(lockCtx, lease, ttl); err != nil {
+					fmt.Printf("[TaskLock] Renew lock %s failed: %v\n", key, err)
+					return
+				}
+			}
+		}
+	}()
+
+	release := func() {
+		close(stop)
+		<-done
+		if err := lock.Unlock(lockCtx, lease); err != nil {
+			fmt.Printf("[TaskLock] Unlock %s failed: %v\n", key, err)
+		}
+	}
+	return lease, release, nil
+}
+
+// userQueue 按用户串行执行，同一用户的任务按提交顺序依次执行
+type userQueue struct {
+	mu    sync.Mutex
+	tails map[int64]*queueTail
+	size  int // 单个用户排队中的最大任务数，0 表示不限制
+}
+
+// queueTail 用户队列的队尾
+type queueTail struct {
+	done    chan struct{} // 最后一个入队的任务执行完毕时关闭
+	pending int           // 排队及执行中的任务数
+}
+
+func newUserQueue(size int) *userQueue {
+	return &userQueue{tails: make(map[int64]*queueTail), size: size}
+}
+
+// Do 排在该用户之前提交的任务之后执行 fn，并等待其返回
+// ctx 在排队期间取消时放弃执行，不影响后续任务的顺序
+func (q *userQueue) Do(ctx context.Context, userID int64, fn func() error) error {
+	q.mu.Lock()
+	tail, exists := q.tails[userID]
+	if !exists {
+		tail = &queueTail{}
+		q.tails[userID] = tail
+	}
+	if q.size > 0 && tail.pending >= q.size {
+		q.mu.Unlock()
+		return fmt.Errorf("%w: user %d has %d events queued", ErrTaskLockBusy, userID, tail.pending)
+	}
+	prev, done := tail.done, make(chan struct{})
+	tail.done = done
+	tail.pending++
+	q.mu.Unlock()
+
+	finish := func() {
+		q.mu.Lock()
+		tail.pending--
+		if tail.pending == 0 {
+			delete(q.tails, userID)
+		}
+		q.mu.Unlock()
+		close(done)
+	}
+
+	if prev != nil {
+		select {
+		case <-prev:
+		case <-ctx.Done():
+			// 前一个任务结束后才释放队尾，保证后续任务仍按顺序执行
+			go func() {
+				<-prev
+				finish()
+			}()
+			return fmt.Errorf("wait in user queue: %w", ctx.Err())
+		}
+	}
+
+	defer finish()
+	return fn()
+}
@@ -0,0 +1,76 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/valueobject"
+)
+
+// evaluateCondition 执行任务达成条件判定
+// explain 为 true（命中采样）时以 Explain 模式求值并保存求值轨迹，结果与 Evaluate 一致
+func (uc *TriggerTaskUseCase) evaluateCondition(
+	ctx context.Context,
+	task *entity.ActUserTask,
+	def *entity.ActTaskDefinition,
+	functions valueobject.ExpressionFunctions,
+	args valueobject.ExpressionArguments,
+	uniqueFlag string,
+	explain bool,
+) (bool, error) {
+	if !explain {
+		return uc.ruleEngine.Evaluate(ctx, def.TaskCondExpr, functions, args)
+	}
+
+	root, err := uc.ruleEngine.Explain(ctx, def.TaskCondExpr, functions, args)
+	if err != nil {
+		return false, err
+	}
+	reach, evalErr := traceResult(root)
+
+	trace := &entity.ActExpressionTrace{
+		UserID:     task.UserID,
+		ActivityID: task.ActivityID,
+		TaskID:     task.ID,
+		TaskDefID:  def.ID,
+		UniqueFlag: uniqueFlag,
+		Expr:       def.TaskCondExpr,
+		Reach:      reach,
+		Root:       root,
+	}
+	if evalErr != nil {
+		trace.Error = evalErr.Error()
+	}
+	// 轨迹仅用于排查，保存失败不影响任务判定
+	if err := uc.traceRepo.Create(ctx, trace); err != nil {
+		fmt.Printf("[TriggerTask] Save expression trace for task %d failed: %v\n", task.ID, err)
+	}
+
+	return reach, evalErr
+}
+
+// shouldTrace 按采样率决定是否记录求值轨迹
+func (uc *TriggerTaskUseCase) shouldTrace() bool {
+	switch {
+	case uc.traceSampleRate <= 0:
+		return false
+	case uc.traceSampleRate >= 1:
+		return true
+	default:
+		return rand.Float64() < uc.traceSampleRate
+	}
+}
+
+// traceResult 从求值轨迹根节点得到判定结果
+func traceResult(root *valueobject.ExpressionTraceNode) (bool, error) {
+	if root.Error != "" {
+		return false, errors.New(root.Error)
+	}
+	reach, ok := root.Result.(bool)
+	if !ok {
+		return false, errors.New("expression result must be bool")
+	}
+	return reach, nil
+}
@@ -4,42 +4,79 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"mini-sirus/internal/domain/entity"
+	"mini-sirus/internal/domain/event"
 	"mini-sirus/internal/domain/repository"
 	"mini-sirus/internal/domain/valueobject"
 	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
 	"mini-sirus/internal/usecase/port/output"
 	"time"
-
-	"github.com/Knetic/govaluate"
 )
 
 // TriggerTaskUseCase 触发任务用例
 type TriggerTaskUseCase struct {
 	taskRepo         repository.TaskRepository
+	taskDefRepo      repository.TaskDefinitionRepository
 	taskDetailRepo   repository.TaskDetailRepository
+	taskPeriodRepo   repository.TaskPeriodRepository
+	activityRepo     repository.ActivityRepository
+	rewardRepo       repository.RewardRepository
+	rewardLedgerRepo repository.RewardLedgerRepository
+	budgetRepo       repository.RewardBudgetRepository
+	traceRepo        repository.ExpressionTraceRepository
 	ruleEngine       output.RuleEngine
+	functionRegistry *function.Registry
 	observerRegistry output.TaskObserverRegistry
 	distributedLock  output.DistributedLock
 	riskCheckService output.RiskCheckService // 风控服务应该作为依赖注入，而不是观察者
+	taskExpireDays   int                     // 未配置活动时任务的有效天数
+	traceSampleRate  float64                 // 求值轨迹采样率，0 表示不记录，1 表示全部记录
+	lockContention   LockContention          // 用户任务锁的竞争策略
+	userQueue        *userQueue              // queue 策略下按用户排队
 }
 
 // NewTriggerTaskUseCase 创建触发任务用例
 func NewTriggerTaskUseCase(
 	taskRepo repository.TaskRepository,
+	taskDefRepo repository.TaskDefinitionRepository,
 	taskDetailRepo repository.TaskDetailRepository,
+	taskPeriodRepo repository.TaskPeriodRepository,
+	activityRepo repository.ActivityRepository,
+	rewardRepo repository.RewardRepository,
+	rewardLedgerRepo repository.RewardLedgerRepository,
+	budgetRepo repository.RewardBudgetRepository,
+	traceRepo repository.ExpressionTraceRepository,
 	ruleEngine output.RuleEngine,
+	functionRegistry *function.Registry,
 	observerRegistry output.TaskObserverRegistry,
 	distributedLock output.DistributedLock,
 	riskCheckService output.RiskCheckService,
+	taskExpireDays int,
+	traceSampleRate float64,
+	lockContention LockContention,
 ) *TriggerTaskUseCase {
+	lockContention = lockContention.withDefaults()
 	return &TriggerTaskUseCase{
 		taskRepo:         taskRepo,
+		taskDefRepo:      taskDefRepo,
 		taskDetailRepo:   taskDetailRepo,
+		taskPeriodRepo:   taskPeriodRepo,
+		activityRepo:     activityRepo,
+		rewardRepo:       rewardRepo,
+		rewardLedgerRepo: rewardLedgerRepo,
+		budgetRepo:       budgetRepo,
+		traceRepo:        traceRepo,
 		ruleEngine:       ruleEngine,
+		functionRegistry: functionRegistry,
 		observerRegistry: observerRegistry,
 		distributedLock:  distributedLock,
 		riskCheckService: riskCheckService,
+		taskExpireDays:   taskExpireDays,
+		traceSampleRate:  traceSampleRate,
+		lockContention:   lockContention,
+		userQueue:        newUserQueue(lockContention.QueueSize),
 	}
 }
 
@@ -49,16 +86,26 @@ func (uc *TriggerTaskUseCase) Execute(ctx context.Context, input dto.TriggerTask
 		return errors.New("task mode is required")
 	}
 
+	// queue 策略：同一用户的事件按到达顺序依次处理
+	if uc.lockContention.Policy == LockPolicyQueue {
+		return uc.userQueue.Do(ctx, input.TaskMode.GetUserID(), func() error {
+			return uc.execute(ctx, input)
+		})
+	}
+	return uc.execute(ctx, input)
+}
+
+// execute 持有用户粒度任务锁处理事件
+func (uc *TriggerTaskUseCase) execute(ctx context.Context, input dto.TriggerTaskInput) error {
 	userID := input.TaskMode.GetUserID()
 	taskType := input.TaskMode.GetTaskType()
 
-	// 用户粒度任务锁
-	lockKey := fmt.Sprintf("task_lock:%d:%s", userID, taskType)
-	lockID, err := uc.distributedLock.Lock(ctx, lockKey, 30) // 30秒超时
+	// 用户粒度任务锁，锁被占用时按竞争策略等待或重试
+	lease, release, err := holdLock(ctx, uc.distributedLock, taskLockKey(userID, taskType), uc.lockContention)
 	if err != nil {
 		return fmt.Errorf("acquire lock failed: %w", err)
 	}
-	defer uc.distributedLock.Unlock(ctx, lockKey, lockID)
+	defer release()
 
 	fmt.Printf("[TriggerTask] Processing task for user: %d, type: %s\n", userID, taskType)
 
@@ -68,31 +115,63 @@ func (uc *TriggerTaskUseCase) Execute(ctx context.Context, input dto.TriggerTask
 		return fmt.Errorf("list user tasks failed: %w", err)
 	}
 
+	// 延迟创建：为进行中活动里尚未创建的任务补建用户任务
+	lazyTasks, err := uc.createLazyTasks(ctx, userID, taskType, tasks)
+	if err != nil {
+		return err
+	}
+	tasks = append(tasks, lazyTasks...)
+
+	// 重复事件不再推进进度，只补发已有明细的奖励
+	tasks, err = uc.skipDuplicates(ctx, tasks, input.TaskMode.GetUniqueFlag())
+	if err != nil {
+		return err
+	}
+
+	// 写入携带锁令牌，锁过期后被其他持有者获取时，仓储拒绝本次的迟到写入
+	for _, task := range tasks {
+		task.FencingToken = lease.Token
+	}
+
 	if len(tasks) == 0 {
 		fmt.Printf("[TriggerTask] No pending tasks for user: %d\n", userID)
 		return nil
 	}
 
 	// 过滤有效任务
-	validTasks := uc.filterValidTasks(tasks)
+	validTasks, err := uc.filterValidTasks(ctx, tasks)
+	if err != nil {
+		return err
+	}
 	// ========== 风控检查（同步执行，阻塞任务完成）==========
-	for _, task := range validTasks {
-		if err := uc.performRiskCheck(ctx, task.UserID, task.ID); err != nil {
-			fmt.Printf("[TriggerTask] Risk check failed for user %d: %v\n", task.UserID, err)
+	for _, item := range validTasks {
+		if err := uc.performRiskCheck(ctx, item.task.UserID, item.task.ID); err != nil {
+			fmt.Printf("[TriggerTask] Risk check failed for user %d: %v\n", item.task.UserID, err)
 			return fmt.Errorf("风控检查失败: %w", err)
 		}
-		fmt.Printf("[TriggerTask] Risk check passed for user %d\n", task.UserID)
+		fmt.Printf("[TriggerTask] Risk check passed for user %d\n", item.task.UserID)
 	}
 
-	// 获取表达式参数和函数
+	// 获取表达式参数
 	expressArgs := input.TaskMode.GetExpressionArguments()
-	expressFuncs := uc.buildExpressionFunctions(input.TaskMode)
 
-	// 任务达成判定
+	// 任务达成判定，写入版本冲突时基于最新的任务重新判定
 	var lastError error
-	for _, task := range validTasks {
-		if err := uc.processTask(ctx, task, expressFuncs, expressArgs, input.TaskMode.GetUniqueFlag()); err != nil {
-			fmt.Printf("[TriggerTask] Process task %d failed: %v\n", task.ID, err)
+	for _, item := range validTasks {
+		expressFuncs := uc.buildExpressionFunctions(input.TaskMode, item.activity)
+		// 每个任务对本次事件只采样一次，冲突重试时不重复记录求值轨迹
+		trace := uc.shouldTrace()
+		err := uc.withConflictRetry(ctx, item.task, func(task *entity.ActUserTask) error {
+			// 重新加载的任务可能已被其他写入完成
+			if !task.IsPending() {
+				return nil
+			}
+			explain := trace
+			trace = false
+			return uc.processTask(ctx, task, item.def, expressFuncs, expressArgs, input.TaskMode.GetUniqueFlag(), explain)
+		})
+		if err != nil {
+			fmt.Printf("[TriggerTask] Process task %d failed: %v\n", item.task.ID, err)
 			lastError = err
 			continue
 		}
@@ -101,122 +180,296 @@ func (uc *TriggerTaskUseCase) Execute(ctx context.Context, input dto.TriggerTask
 	return lastError
 }
 
-// filterValidTasks 过滤有效的任务
-func (uc *TriggerTaskUseCase) filterValidTasks(tasks []*entity.ActUserTask) []*entity.ActUserTask {
-	validTasks := make([]*entity.ActUserTask, 0, len(tasks))
+// createLazyTasks 延迟创建用户任务
+// 对应"模式二：实时延迟创建"，查找进行中活动里该任务类型的延迟创建任务定义，
+// 用户首次触发匹配事件时创建用户任务。调用方需持有用户粒度任务锁
+func (uc *TriggerTaskUseCase) createLazyTasks(
+	ctx context.Context,
+	userID int64,
+	taskType valueobject.TaskType,
+	existing []*entity.ActUserTask,
+) ([]*entity.ActUserTask, error) {
+	activities, err := uc.activityRepo.ListActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list active activities failed: %w", err)
+	}
 
-	for _, task := range tasks {
-		// 过滤已完成的任务
-		if task.IsCompleted() {
-			continue
-		}
+	owned := make(map[int64]bool, len(existing))
+	for _, task := range existing {
+		owned[task.TaskID] = true
+	}
 
-		// 检查任务是否过期（30天）
-		if task.IsExpired(30) {
-			continue
+	var created []*entity.ActUserTask
+	for _, activity := range activities {
+		defs, err := uc.taskDefRepo.ListByActivityID(ctx, activity.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list task definitions failed: %w", err)
 		}
 
-		validTasks = append(validTasks, task)
+		for _, def := range defs {
+			if !def.IsLazy() || def.TaskType != taskType || owned[def.ID] || !def.IsEffective(uc.now()) {
+				continue
+			}
+
+			status, err := initialTaskStatus(ctx, uc.taskRepo, def, userID)
+			if err != nil {
+				return nil, err
+			}
+
+			task := &entity.ActUserTask{
+				ActivityID: activity.ID,
+				TaskID:     def.ID,
+				UserID:     userID,
+				TaskType:   def.TaskType,
+				Status:     status,
+				PeriodKey:  def.PeriodKeyAt(uc.now()),
+				CreatedAt:  time.Now(),
+				UpdatedAt:  time.Now(),
+			}
+			err = uc.taskRepo.Create(ctx, task)
+			if errors.Is(err, repository.ErrUserTaskExists) {
+				// 批量预创建等并发写入已创建该任务，改用已存在的任务
+				task, err = uc.findUserTask(ctx, userID, taskType, def.ID)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("create lazy task failed: %w", err)
+			}
+
+			fmt.Printf("[TriggerTask] Lazily created task %d (definition %d) for user %d\n", task.ID, def.ID, userID)
+			owned[def.ID] = true
+			created = append(created, task)
+		}
 	}
 
-	return validTasks
+	return created, nil
 }
 
-// buildExpressionFunctions 构建表达式函数
-func (uc *TriggerTaskUseCase) buildExpressionFunctions(taskMode dto.TaskModeDTO) map[string]govaluate.ExpressionFunction {
-	functions := make(map[string]govaluate.ExpressionFunction)
-
-	// 注册通用函数
-	functions["WITH_ANY_TOPIC"] = uc.withAnyTopicFunc()
-	functions["LIKE_COUNT_GTE"] = uc.likeCountGteFunc()
-	functions["IS_AUDITED"] = uc.isAuditedFunc()
-	functions["IS_TODAY"] = uc.isTodayFunc()
+// findUserTask 查找用户在任务定义下已存在的任务
+func (uc *TriggerTaskUseCase) findUserTask(
+	ctx context.Context,
+	userID int64,
+	taskType valueobject.TaskType,
+	defID int64,
+) (*entity.ActUserTask, error) {
+	tasks, err := uc.taskRepo.ListByUserIDAndType(ctx, userID, taskType)
+	if err != nil {
+		return nil, fmt.Errorf("list user tasks failed: %w", err)
+	}
+	for _, task := range tasks {
+		if task.TaskID == defID {
+			return task, nil
+		}
+	}
+	return nil, fmt.Errorf("user task for definition %d not found", defID)
+}
 
-	return functions
+// userTask 用户任务及其对应的任务定义、所属活动（未配置活动时为 nil）
+type userTask struct {
+	task     *entity.ActUserTask
+	def      *entity.ActTaskDefinition
+	activity *entity.ActActivity
 }
 
-// withAnyTopicFunc 判断是否包含任意话题
-func (uc *TriggerTaskUseCase) withAnyTopicFunc() govaluate.ExpressionFunction {
-	return func(args ...interface{}) (interface{}, error) {
-		if len(args) < 2 {
-			return false, errors.New("WITH_ANY_TOPIC requires 2 arguments")
+// filterValidTasks 过滤有效的任务，并关联任务定义
+func (uc *TriggerTaskUseCase) filterValidTasks(ctx context.Context, tasks []*entity.ActUserTask) ([]*userTask, error) {
+	validTasks := make([]*userTask, 0, len(tasks))
+	activities := make(map[int64]*entity.ActActivity)
+	rejected := 0
+
+	for _, task := range tasks {
+		// 按所属活动的状态和时间窗口过滤，未配置活动时按任务有效天数判断过期
+		activity, err := uc.getActivity(ctx, activities, task.ActivityID)
+		if err != nil {
+			return nil, err
+		}
+		if activity != nil && !activity.IsActive(uc.now()) {
+			fmt.Printf("[TriggerTask] Task %d skipped: activity %d not active (status: %s)\n", task.ID, activity.ID, activity.Status)
+			rejected++
+			continue
+		}
+		if activity == nil && task.IsExpired(uc.taskExpireDays) {
+			continue
 		}
 
-		carryIDs, ok := args[0].([]uint64)
-		if !ok {
-			return false, errors.New("first argument must be []uint64")
+		// 任务定义缺失时仅跳过该任务，不影响用户同类型的其他任务
+		def, err := uc.taskDefRepo.GetByID(ctx, task.TaskID)
+		if errors.Is(err, repository.ErrTaskDefinitionNotFound) {
+			fmt.Printf("[TriggerTask] Task %d skipped: definition %d not found\n", task.ID, task.TaskID)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get task definition failed: %w", err)
 		}
 
-		condIDs, ok := args[1].([]uint64)
-		if !ok {
-			return false, errors.New("second argument must be []uint64")
+		// 过滤已归档或不在生效时间内的任务定义
+		if !def.IsEffective(uc.now()) {
+			continue
 		}
 
-		for _, cid := range carryIDs {
-			for _, tid := range condIDs {
-				if cid == tid {
-					return true, nil
-				}
+		// 周期任务进入新周期时重置进度，需在完成判断之前执行；
+		// 未解锁的任务：前置条件已满足时补偿解锁，否则跳过
+		unlocked := true
+		err = uc.withConflictRetry(ctx, task, func(task *entity.ActUserTask) error {
+			if err := uc.rollPeriod(ctx, task, def); err != nil {
+				return err
+			}
+			if task.IsLocked() {
+				unlocked, err = uc.tryUnlock(ctx, task, def)
 			}
+			return err
+		})
+		if err != nil {
+			return nil, err
 		}
-		return false, nil
+		if !unlocked {
+			continue
+		}
+
+		// 过滤已完成的任务
+		if task.IsCompleted() {
+			continue
+		}
+
+		validTasks = append(validTasks, &userTask{task: task, def: def, activity: activity})
+	}
+
+	// 事件命中的任务全部因活动不可用被过滤时拒绝该事件
+	if len(validTasks) == 0 && rejected > 0 {
+		return nil, entity.ErrActivityNotActive
 	}
+
+	return validTasks, nil
 }
 
-// likeCountGteFunc 判断点赞数是否达标
-func (uc *TriggerTaskUseCase) likeCountGteFunc() govaluate.ExpressionFunction {
-	return func(args ...interface{}) (interface{}, error) {
-		if len(args) < 2 {
-			return false, errors.New("LIKE_COUNT_GTE requires 2 arguments")
-		}
+// getActivity 获取任务所属活动，未配置活动时返回 nil
+func (uc *TriggerTaskUseCase) getActivity(
+	ctx context.Context,
+	cache map[int64]*entity.ActActivity,
+	activityID int64,
+) (*entity.ActActivity, error) {
+	if activity, ok := cache[activityID]; ok {
+		return activity, nil
+	}
 
-		likeCount, ok := args[0].(float64)
-		if !ok {
-			return false, errors.New("first argument must be number")
-		}
+	activity, err := uc.activityRepo.GetByID(ctx, activityID)
+	switch {
+	case errors.Is(err, repository.ErrActivityNotFound):
+		activity = nil
+	case err != nil:
+		return nil, fmt.Errorf("get activity failed: %w", err)
+	}
 
-		minCount, ok := args[1].(float64)
-		if !ok {
-			return false, errors.New("second argument must be number")
+	cache[activityID] = activity
+	return activity, nil
+}
+
+// rollPeriod 切换周期任务到当前周期，并归档上一周期的进度
+// 先持久化任务再归档，版本冲突时不产生归档记录，重新加载后不会重复归档
+func (uc *TriggerTaskUseCase) rollPeriod(ctx context.Context, task *entity.ActUserTask, def *entity.ActTaskDefinition) error {
+	archived, rolled := task.RollPeriod(def, uc.now())
+	if !rolled {
+		return nil
+	}
+
+	if err := uc.taskRepo.Update(ctx, task); err != nil {
+		return fmt.Errorf("roll task period failed: %w", err)
+	}
+
+	if archived != nil {
+		if err := uc.taskPeriodRepo.Create(ctx, archived); err != nil {
+			return fmt.Errorf("archive task period failed: %w", err)
 		}
+	}
 
-		return likeCount >= minCount, nil
+	fmt.Printf("[TriggerTask] Task %d rolled to period %s\n", task.ID, task.PeriodKey)
+	return nil
+}
+
+// tryUnlock 前置条件已满足时解锁任务
+func (uc *TriggerTaskUseCase) tryUnlock(ctx context.Context, task *entity.ActUserTask, def *entity.ActTaskDefinition) (bool, error) {
+	completed, err := completedTaskDefs(ctx, uc.taskRepo, task.UserID, task.ActivityID)
+	if err != nil {
+		return false, err
 	}
+	if !def.PrerequisitesMet(completed) {
+		return false, nil
+	}
+
+	task.Unlock()
+	if err := uc.taskRepo.Update(ctx, task); err != nil {
+		return false, fmt.Errorf("unlock task failed: %w", err)
+	}
+
+	fmt.Printf("[TriggerTask] Task %d unlocked for user %d\n", task.ID, task.UserID)
+	return true, nil
 }
 
-// isAuditedFunc 判断是否已审核通过
-func (uc *TriggerTaskUseCase) isAuditedFunc() govaluate.ExpressionFunction {
-	return func(args ...interface{}) (interface{}, error) {
-		if len(args) < 1 {
-			return false, errors.New("IS_AUDITED requires 1 argument")
+// unlockDependents 任务完成后，找出前置条件因此满足的后续任务并解锁（仅修改内存，由调用方统一持久化）
+func (uc *TriggerTaskUseCase) unlockDependents(ctx context.Context, task *entity.ActUserTask) ([]*entity.ActUserTask, error) {
+	userTasks, err := uc.taskRepo.ListByUserID(ctx, task.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("list user tasks failed: %w", err)
+	}
+
+	completed := map[int64]bool{task.TaskID: true}
+	for _, userTask := range userTasks {
+		if userTask.ActivityID == task.ActivityID && userTask.IsCompleted() {
+			completed[userTask.TaskID] = true
+		}
+	}
+
+	var unlocked []*entity.ActUserTask
+	for _, userTask := range userTasks {
+		if userTask.ActivityID != task.ActivityID || !userTask.IsLocked() {
+			continue
 		}
 
-		isAudited, ok := args[0].(bool)
-		if !ok {
-			return false, errors.New("argument must be bool")
+		def, err := uc.taskDefRepo.GetByID(ctx, userTask.TaskID)
+		if errors.Is(err, repository.ErrTaskDefinitionNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get task definition failed: %w", err)
+		}
+		if !def.DependsOn(task.TaskID) || !def.PrerequisitesMet(completed) {
+			continue
 		}
 
-		return isAudited, nil
+		userTask.Unlock()
+		unlocked = append(unlocked, userTask)
 	}
+
+	return unlocked, nil
 }
 
-// isTodayFunc 判断是否今天
-func (uc *TriggerTaskUseCase) isTodayFunc() govaluate.ExpressionFunction {
-	return func(args ...interface{}) (interface{}, error) {
-		return true, nil
+// buildExpressionFunctions 构建表达式函数
+// 仅包含事件声明的函数（GetExpressionFunctions），同一事件类型、同一活动复用同一个函数集
+func (uc *TriggerTaskUseCase) buildExpressionFunctions(
+	taskMode dto.TaskModeDTO,
+	activity *entity.ActActivity,
+) valueobject.ExpressionFunctions {
+	var env function.Env
+	if activity != nil {
+		env = function.Env{ActivityID: activity.ID, ActivityStart: activity.StartTime, ActivityEnd: activity.EndTime}
 	}
+	return uc.functionRegistry.FunctionsWithEnv(taskMode.GetExpressionFunctions(), env)
 }
 
 // processTask 处理单个任务
 func (uc *TriggerTaskUseCase) processTask(
 	ctx context.Context,
 	task *entity.ActUserTask,
-	functions map[string]govaluate.ExpressionFunction,
+	def *entity.ActTaskDefinition,
+	functions valueobject.ExpressionFunctions,
 	args valueobject.ExpressionArguments,
 	uniqueFlag string,
+	explain bool,
 ) error {
+	// 任务参数（如要求的话题）按任务合并到事件参数中
+	args = def.Parameters.Merge(args)
+
 	// 执行规则引擎判定
-	reach, err := uc.ruleEngine.Evaluate(ctx, task.TaskCondExpr, functions, args)
+	reach, err := uc.evaluateCondition(ctx, task, def, functions, args, uniqueFlag, explain)
 	if err != nil {
 		return fmt.Errorf("evaluate expression failed: %w", err)
 	}
@@ -226,45 +479,108 @@ func (uc *TriggerTaskUseCase) processTask(
 		return nil
 	}
 
+	// 计算进度增量
+	delta, err := uc.progressDelta(ctx, def, functions, args)
+	if err != nil {
+		return err
+	}
+	if delta <= 0 {
+		fmt.Printf("[TriggerTask] Task %d reached without progress increment\n", task.ID)
+		return nil
+	}
+
 	if uniqueFlag != "" {
-		exists, err := uc.taskDetailRepo.ExistsByUniqueFlag(ctx, uniqueFlag)
-		if err != nil {
-			// 检查唯一性失败，应返回错误
-			return fmt.Errorf("check unique flag failed: %w", err)
-		}
-		if exists {
-			// 如果已存在，说明是重复请求。
-			// 幂等处理：直接返回成功，表示“操作已成功执行”
-			uc.riskCheckService.RecordTaskCompletion(ctx, task.UserID, task.ID, time.Now())
-			fmt.Printf("[TriggerTask] Idempotency check: Task detail with unique_flag %s already exists\n", uniqueFlag)
-			return nil
+		duplicate, err := uc.handleDuplicate(ctx, task, uniqueFlag)
+		if err != nil || duplicate {
+			return err
 		}
 	}
 
 	fmt.Printf("[TriggerTask] Task %d reached!\n", task.ID)
 
+	// 累加任务进度（超过目标时截断），实际增量记录到任务明细；
+	// 连续打卡任务按事件的打卡日推进，打卡日须为时钟的当天
+	periodKey := task.PeriodKey
+	var applied int
+	if def.IsStreak() {
+		day, _ := args["date"].(string)
+		applied = task.CheckIn(def, day, uc.now())
+	} else {
+		applied = task.AddProgress(def, delta)
+	}
+	// 未推进进度（如同一天换日期重复打卡）不占用预算、不写明细、不发奖励
+	if applied <= 0 {
+		fmt.Printf("[TriggerTask] Task %d not advanced\n", task.ID)
+		return nil
+	}
+
 	// 创建任务明细
 	detail := &entity.ActUserTaskDetail{
-		TaskID:      task.ID,
-		UserID:      task.UserID,
-		Status:      entity.TaskDetailStatusDone,
-		UniqueFlag:  uniqueFlag,
-		RewardValue: 1, // 根据实际业务配置
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		TaskID:        task.ID,
+		UserID:        task.UserID,
+		Status:        entity.TaskDetailStatusDone,
+		UniqueFlag:    uniqueFlag,
+		RewardValue:   def.RewardValue,
+		PeriodKey:     periodKey,
+		ProgressDelta: applied,
+		CompletesTask: task.IsCompleted(),
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	// 写入明细前按本次应发放的奖励预占预算，预算不足时本次达成不计入
+	reservation, err := uc.reserveBudget(ctx, task, def, detail)
+	if err != nil {
+		if errors.Is(err, entity.ErrBudgetExhausted) {
+			fmt.Printf("[TriggerTask] Task %d not counted: %v\n", task.ID, err)
+		}
+		return err
 	}
 
 	// 保存任务明细
 	if err := uc.taskDetailRepo.Create(ctx, detail); err != nil {
+		if releaseErr := uc.budgetRepo.Release(ctx, reservation.ID); releaseErr != nil {
+			fmt.Printf("[TriggerTask] Release budget reservation %d failed: %v\n", reservation.ID, releaseErr)
+		}
 		return fmt.Errorf("save task detail failed: %w", err)
 	}
 
-	// 更新任务进度
-	task.UpdateProgress()
-	if err := uc.taskRepo.Update(ctx, task); err != nil {
+	// 任务完成时解锁后续任务，与进度更新一起原子持久化
+	var unlocked []*entity.ActUserTask
+	if task.IsCompleted() {
+		unlocked, err = uc.unlockDependents(ctx, task)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 更新任务进度，失败时撤销明细和预算预占，重试时不会被唯一标识判定为重复请求
+	if err := uc.taskRepo.UpdateBatch(ctx, append([]*entity.ActUserTask{task}, unlocked...)); err != nil {
+		uc.revertDetail(ctx, detail, reservation)
 		return fmt.Errorf("update task progress failed: %w", err)
 	}
 
+	// 发放奖励
+	if err := uc.grantRewards(ctx, task, detail); err != nil {
+		return err
+	}
+
+	// 发布任务解锁事件
+	for _, dependent := range unlocked {
+		fmt.Printf("[TriggerTask] Task %d unlocked by task %d\n", dependent.ID, task.ID)
+		unlockedEvent := &event.TaskUnlocked{
+			TaskID:     dependent.ID,
+			TaskDefID:  dependent.TaskID,
+			UserID:     dependent.UserID,
+			ActivityID: dependent.ActivityID,
+			UnlockedBy: task.ID,
+			UnlockedAt: time.Now(),
+		}
+		if err := uc.observerRegistry.NotifyTaskUnlocked(ctx, unlockedEvent); err != nil {
+			fmt.Printf("[TriggerTask] Notify task unlocked failed: %v\n", err)
+		}
+	}
+
 	// 记录任务完成事件（用于风控统计）
 	if err := uc.riskCheckService.RecordTaskCompletion(ctx, task.UserID, task.ID, detail.CreatedAt); err != nil {
 		fmt.Printf("[TriggerTask] Record task completion failed: %v\n", err)
@@ -280,6 +596,111 @@ func (uc *TriggerTaskUseCase) processTask(
 	return nil
 }
 
+// skipDuplicates 过滤已处理过该事件的任务
+// 已完成的任务也需要检查：完成该任务的事件在奖励记账前失败时，重试需要补发奖励
+func (uc *TriggerTaskUseCase) skipDuplicates(ctx context.Context, tasks []*entity.ActUserTask, uniqueFlag string) ([]*entity.ActUserTask, error) {
+	if uniqueFlag == "" {
+		return tasks, nil
+	}
+
+	remaining := make([]*entity.ActUserTask, 0, len(tasks))
+	for _, task := range tasks {
+		duplicate, err := uc.handleDuplicate(ctx, task, uniqueFlag)
+		if err != nil {
+			return nil, err
+		}
+		if !duplicate {
+			remaining = append(remaining, task)
+		}
+	}
+	return remaining, nil
+}
+
+// handleDuplicate 判断任务是否已处理过该事件，是则按已有明细补发奖励
+// 进度落库后奖励记账失败时，重试的事件会命中已有明细；流水按明细幂等，补发不会重复入账
+func (uc *TriggerTaskUseCase) handleDuplicate(ctx context.Context, task *entity.ActUserTask, uniqueFlag string) (bool, error) {
+	existing, err := uc.taskDetailRepo.FindByUniqueFlag(ctx, task.ID, uniqueFlag)
+	if err != nil {
+		// 检查唯一性失败，应返回错误
+		return false, fmt.Errorf("check unique flag failed: %w", err)
+	}
+	if existing == nil {
+		return false, nil
+	}
+
+	// 如果已存在，说明是重复请求。
+	// 幂等处理：直接返回成功，表示“操作已成功执行”
+	uc.riskCheckService.RecordTaskCompletion(ctx, task.UserID, task.ID, time.Now())
+	fmt.Printf("[TriggerTask] Idempotency check: Task detail with unique_flag %s already exists\n", uniqueFlag)
+	if err := uc.grantRewards(ctx, task, existing); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// now 获取当前时间，周期和连续打卡与时间类表达式函数使用同一时钟
+func (uc *TriggerTaskUseCase) now() time.Time {
+	return uc.functionRegistry.Clock().Now()
+}
+
+// revertDetail 撤销已写入的任务明细并释放预算预占
+func (uc *TriggerTaskUseCase) revertDetail(ctx context.Context, detail *entity.ActUserTaskDetail, reservation *entity.BudgetReservation) {
+	if err := uc.taskDetailRepo.Delete(ctx, detail.ID); err != nil {
+		fmt.Printf("[TriggerTask] Delete task detail %d failed: %v\n", detail.ID, err)
+	}
+	if err := uc.budgetRepo.Release(ctx, reservation.ID); err != nil {
+		fmt.Printf("[TriggerTask] Release budget reservation %d failed: %v\n", reservation.ID, err)
+	}
+}
+
+// maxConflictRetry 用户任务写入版本冲突时的最大重试次数
+const maxConflictRetry = 3
+
+// withConflictRetry 执行 fn，任务写入版本冲突时重新加载任务并重试
+// 冲突说明任务在读取后已被其他写入修改（如锁过期期间其他持有者处理了事件），
+// 基于最新的任务重新执行，既不覆盖其他写入，也不重复累计进度。
+// 重新加载的任务原地覆盖 task 并沿用其锁令牌；令牌过期（ErrStaleFencingToken）说明锁已易主，不再重试
+func (uc *TriggerTaskUseCase) withConflictRetry(
+	ctx context.Context,
+	task *entity.ActUserTask,
+	fn func(task *entity.ActUserTask) error,
+) error {
+	for attempt := 0; ; attempt++ {
+		err := fn(task)
+		if !errors.Is(err, repository.ErrVersionConflict) || attempt >= maxConflictRetry {
+			return err
+		}
+
+		fmt.Printf("[TriggerTask] Task %d version conflict, reloading: %v\n", task.ID, err)
+		latest, err := uc.taskRepo.GetByID(ctx, task.ID)
+		if err != nil {
+			return fmt.Errorf("reload task failed: %w", err)
+		}
+		latest.FencingToken = task.FencingToken
+		*task = *latest
+	}
+}
+
+// progressDelta 计算本次达成的进度增量
+// 任务定义未配置进度表达式时每次 +1，否则按表达式结果向下取整
+func (uc *TriggerTaskUseCase) progressDelta(
+	ctx context.Context,
+	def *entity.ActTaskDefinition,
+	functions valueobject.ExpressionFunctions,
+	args valueobject.ExpressionArguments,
+) (int, error) {
+	if !def.IsWeighted() {
+		return 1, nil
+	}
+
+	value, err := uc.ruleEngine.EvaluateNumber(ctx, def.ProgressExpr, functions, args)
+	if err != nil {
+		return 0, fmt.Errorf("evaluate progress expression failed: %w", err)
+	}
+
+	return int(math.Floor(value)), nil
+}
+
 // performRiskCheck 执行风控检查（同步阻塞）
 func (uc *TriggerTaskUseCase) performRiskCheck(ctx context.Context, userID, taskID int64) error {
 	// 1. 检查用户是否在黑名单中
@@ -0,0 +1,164 @@
+package task
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"mini-sirus/internal/usecase/dto"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// userIDSource 用户ID来源
+// 按顺序分批读取用户ID，用于批量预创建任务
+type userIDSource interface {
+	// Total 用户总数，未知时返回 -1
+	Total() int64
+
+	// Skip 跳过接下来的 n 个用户（断点续跑）
+	Skip(n int64) error
+
+	// Next 读取下一批用户ID，读完时返回空切片
+	Next(size int) ([]int64, error)
+
+	// Close 释放资源
+	Close() error
+}
+
+// newUserIDSource 根据输入创建用户ID来源
+func newUserIDSource(input dto.UserIDSourceInput) (userIDSource, error) {
+	switch input.Type {
+	case dto.UserIDSourceList:
+		if len(input.UserIDs) == 0 {
+			return nil, errors.New("user_ids is required")
+		}
+		return &listUserIDSource{userIDs: input.UserIDs}, nil
+	case dto.UserIDSourceRange:
+		if input.StartID <= 0 || input.EndID < input.StartID {
+			return nil, errors.New("invalid user id range")
+		}
+		return &rangeUserIDSource{next: input.StartID, end: input.EndID, total: input.EndID - input.StartID + 1}, nil
+	case dto.UserIDSourceFile:
+		if input.FilePath == "" {
+			return nil, errors.New("file_path is required")
+		}
+		file, err := os.Open(input.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("open user id file failed: %w", err)
+		}
+		return &fileUserIDSource{file: file, scanner: bufio.NewScanner(file)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported user id source: %s", input.Type)
+	}
+}
+
+// listUserIDSource 列表来源
+type listUserIDSource struct {
+	userIDs []int64
+	pos     int
+}
+
+func (s *listUserIDSource) Total() int64 {
+	return int64(len(s.userIDs))
+}
+
+func (s *listUserIDSource) Skip(n int64) error {
+	s.pos = int(min(int64(s.pos)+n, int64(len(s.userIDs))))
+	return nil
+}
+
+func (s *listUserIDSource) Next(size int) ([]int64, error) {
+	end := min(s.pos+size, len(s.userIDs))
+	batch := s.userIDs[s.pos:end]
+	s.pos = end
+	return batch, nil
+}
+
+func (s *listUserIDSource) Close() error {
+	return nil
+}
+
+// rangeUserIDSource 区间来源
+type rangeUserIDSource struct {
+	next  int64
+	end   int64
+	total int64
+}
+
+func (s *rangeUserIDSource) Total() int64 {
+	return s.total
+}
+
+func (s *rangeUserIDSource) Skip(n int64) error {
+	s.next += n
+	return nil
+}
+
+func (s *rangeUserIDSource) Next(size int) ([]int64, error) {
+	batch := make([]int64, 0, size)
+	for ; s.next <= s.end && len(batch) < size; s.next++ {
+		batch = append(batch, s.next)
+	}
+	return batch, nil
+}
+
+func (s *rangeUserIDSource) Close() error {
+	return nil
+}
+
+// fileUserIDSource 文件来源，每行一个用户ID，忽略空行
+type fileUserIDSource struct {
+	file    *os.File
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (s *fileUserIDSource) Total() int64 {
+	return -1
+}
+
+func (s *fileUserIDSource) Skip(n int64) error {
+	for skipped := int64(0); skipped < n; skipped++ {
+		if _, ok, err := s.read(); err != nil || !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fileUserIDSource) Next(size int) ([]int64, error) {
+	batch := make([]int64, 0, size)
+	for len(batch) < size {
+		userID, ok, err := s.read()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		batch = append(batch, userID)
+	}
+	return batch, nil
+}
+
+func (s *fileUserIDSource) Close() error {
+	return s.file.Close()
+}
+
+// read 读取下一个用户ID
+func (s *fileUserIDSource) read() (int64, bool, error) {
+	for s.scanner.Scan() {
+		s.line++
+		text := strings.TrimSpace(s.scanner.Text())
+		if text == "" {
+			continue
+		}
+		userID, err := strconv.ParseInt(text, 10, 64)
+		if err != nil || userID <= 0 {
+			return 0, false, fmt.Errorf("invalid user id at line %d: %q", s.line, text)
+		}
+		return userID, true, nil
+	}
+	return 0, false, s.scanner.Err()
+}
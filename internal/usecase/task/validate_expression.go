@@ -0,0 +1,52 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"mini-sirus/internal/domain/valueobject"
+	"mini-sirus/internal/usecase/dto"
+	"mini-sirus/internal/usecase/function"
+	"mini-sirus/internal/usecase/port/output"
+)
+
+// validateTaskExpressions 按任务类型的表达式 schema 校验达成条件与进度表达式
+// 只允许引用对应事件DTO声明的参数、任务参数与函数，校验失败返回 *valueobject.ExpressionError
+func validateTaskExpressions(
+	ctx context.Context,
+	ruleEngine output.RuleEngine,
+	functionRegistry *function.Registry,
+	taskModeRegistry *dto.TaskModeRegistry,
+	taskType valueobject.TaskType,
+	params valueobject.TaskParameters,
+	condExpr string,
+	progressExpr string,
+) error {
+	schema, ok := taskModeRegistry.Schema(taskType)
+	if !ok {
+		return fmt.Errorf("%w: no event type registered for task type %s", dto.ErrInvalidInput, taskType)
+	}
+
+	// 任务参数不能覆盖事件参数，否则事件数据会被任务配置静默替换
+	parameters := make(map[string]valueobject.ValueType, len(schema.Types)+len(params))
+	for name, valueType := range schema.Types {
+		parameters[name] = valueType
+	}
+	for _, param := range params {
+		if _, exists := parameters[param.Name]; exists {
+			return &valueobject.ExpressionError{Expr: condExpr, Reason: fmt.Sprintf("task parameter %s conflicts with event argument", param.Name)}
+		}
+		parameters[param.Name] = valueobject.TypeOf(param.Value)
+	}
+
+	functions := functionRegistry.Functions(schema.Functions)
+	if err := ruleEngine.Validate(ctx, condExpr, functions, parameters); err != nil {
+		return err
+	}
+	if progressExpr != "" {
+		if err := ruleEngine.Validate(ctx, progressExpr, functions, parameters); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}